@@ -1,9 +1,14 @@
 // Package acpi is used to leverage the ACPI standard, especially by focusing
 // on parsing the Firmware Performance Data Table to retrieve boot metrics.
+//
+// ACPI tables are little-endian whatever the architecture. They are decoded
+// field by field with binary.LittleEndian, never by casting their bytes to
+// structs, so dumps parse the same on big-endian hosts.
 package acpi
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -12,15 +17,25 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/boreec/boottime/platform"
 )
 
 const (
 	tableHeaderSize   int    = 36
+	pathACPIDir       string = "/sys/firmware/acpi"
 	pathDevMem        string = "/dev/mem"
 	pathFPDTBootDir   string = "/sys/firmware/acpi/fpdt/boot/"
 	pathFPDTTableFile string = "/sys/firmware/acpi/tables/FPDT"
 )
 
+// ErrUnsupportedPlatform is returned when the host cannot have ACPI tables.
+var ErrUnsupportedPlatform = fmt.Errorf("acpi: %w", errors.ErrUnsupported)
+
+// ErrInvalidChecksum is returned when the bytes of an ACPI table do not add to
+// zero.
+var ErrInvalidChecksum = errors.New("invalid ACPI table checksum")
+
 // TableHeader is the standard header common to all ACPI tables (36 bytes).
 type TableHeader struct {
 	// Signature is a a 4-byte slice identifying the table ("ECDT", "FPDT", etc).
@@ -47,6 +62,25 @@ type TableHeader struct {
 	CreatorRevision uint32
 }
 
+// Verify checks the checksum of fullTable, the table starting with h: its
+// Length bytes, including the checksum field, must add to zero modulo 256.
+func (h TableHeader) Verify(fullTable []byte) error {
+	if len(fullTable) < int(h.Length) {
+		return fmt.Errorf("table of %d bytes is shorter than its length %d", len(fullTable), h.Length)
+	}
+
+	var sum uint8
+	for _, b := range fullTable[:h.Length] {
+		sum += b
+	}
+
+	if sum != 0 {
+		return fmt.Errorf("%w: %s table bytes add to %#x", ErrInvalidChecksum, h.Signature, sum)
+	}
+
+	return nil
+}
+
 // TableHeaderFPDT is the common header for FPDT records inside.
 type TableHeaderFPDT struct {
 	// Type depicts the format and contents of the performance record:
@@ -110,44 +144,140 @@ type TableRecordFPDT struct {
 type BootTimeRecord struct {
 	Firmware time.Duration
 	Loader   time.Duration
+	// ExitBootServicesDuration is the time the firmware spent tearing down its
+	// boot services, between ExitBootServicesEntry and ExitBootServicesExit,
+	// which is part of Loader. It is zero when the firmware did not log the
+	// entry, or logged an exit before it.
+	ExitBootServicesDuration time.Duration
+	// RawFPDT is the boot performance record the durations are computed from,
+	// to compute other intervals from its timer values, in nanoseconds. When
+	// read from sysfs, its header is left zero.
+	RawFPDT *TableRecordFPDT
+	// S3 are the durations of the last suspend and resume, or nil if the
+	// firmware has no S3 Performance Table.
+	S3 *S3PerformanceRecord
 }
 
 // RetrieveBootTime attempts to read boot times from Sysfs (Kernel 5.12+)
-// and falls back to reading raw ACPI tables via /dev/mem.
-func RetrieveBootTime() (*BootTimeRecord, error) {
-	if times, err := retrieveBootTimeWithSysfs(); err == nil {
-		return times, nil
+// and falls back to reading raw ACPI tables via /dev/mem. When both fail, the
+// returned error joins the reasons of both failures. The checksum of the FPDT
+// pointing to the table read from memory is verified unless verifyChecksum is
+// false, which is only meant to debug broken firmware.
+func RetrieveBootTime(verifyChecksum bool) (*BootTimeRecord, error) {
+	return RetrieveBootTimeContext(context.Background(), verifyChecksum)
+}
+
+// RetrieveBootTimeContext is RetrieveBootTime, not falling back to the table
+// in memory once ctx is done. Reading the files themselves cannot be
+// interrupted.
+func RetrieveBootTimeContext(ctx context.Context, verifyChecksum bool) (*BootTimeRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return retrieveBootTimeFromTablePointer() // requires root access
+	if p := platform.Detect(); p.IsWSL() {
+		return nil, fmt.Errorf("%w: running under %s", ErrUnsupportedPlatform, p)
+	}
+
+	if err := checkPlatform(); err != nil {
+		return nil, err
+	}
+
+	// Reading /dev/mem requires root access.
+	return retrieveBootTimeWithFallback(retrieveBootTimeWithSysfs, func() (*BootTimeRecord, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return retrieveBootTimeFromTablePointer(verifyChecksum)
+	})
+}
+
+// retrieveBootTimeWithFallback returns the record read with sysfs, or with
+// tablePointer if sysfs fails.
+func retrieveBootTimeWithFallback(sysfs, tablePointer func() (*BootTimeRecord, error)) (*BootTimeRecord, error) {
+	record, sysfsErr := sysfs()
+	if sysfsErr == nil {
+		return record, nil
+	}
+
+	record, tablePointerErr := tablePointer()
+	if tablePointerErr == nil {
+		return record, nil
+	}
+
+	return nil, errors.Join(
+		fmt.Errorf("reading sysfs %s: %w", pathFPDTBootDir, sysfsErr),
+		fmt.Errorf("falling back to %s: %w", pathDevMem, tablePointerErr),
+	)
 }
 
 // retrieveBootTimeWithSysfs reads parsed values from "/sys/firmware/acpi/fpdt/".
 func retrieveBootTimeWithSysfs() (*BootTimeRecord, error) {
-	launchNs, err := readParsedSysfsAttribute("bootloader_launch_ns")
+	record, err := readBootTimeFromSysfs(pathFPDTBootDir)
 	if err != nil {
-		return nil, fmt.Errorf("reading attribute bootloader_launch_ns: %w", err)
+		return nil, err
 	}
 
-	exitNs, err := readParsedSysfsAttribute("exitbootservice_end_ns")
-	if err != nil {
-		return nil, fmt.Errorf("reading attribute exitbootservice_end_ns: %w", err)
+	// The S3 Performance Table is optional, its absence leaves S3 nil.
+	if s3, err := retrieveS3WithSysfs(); err == nil {
+		record.S3 = s3
+	}
+
+	return record, nil
+}
+
+// readBootTimeFromSysfs reads the boot record attributes in dir. Some kernels
+// expose a zero or empty bootloader_launch_ns, which is reported as an error
+// rather than deriving a loader duration spanning the whole boot.
+func readBootTimeFromSysfs(dir string) (*BootTimeRecord, error) {
+	var rec TableRecordFPDT
+	attributes := []struct {
+		name  string
+		value *uint64
+	}{
+		{"reset_end_ns", &rec.ResetEnd},
+		{"bootloader_load_ns", &rec.OSLoaderLoadImageStart},
+		{"bootloader_launch_ns", &rec.OSLoaderStartImageStart},
+		{"exitbootservice_start_ns", &rec.ExitBootServicesEntry},
+		{"exitbootservice_end_ns", &rec.ExitBootServicesExit},
+	}
+	for _, a := range attributes {
+		v, err := readParsedSysfsAttribute(dir, a.name)
+		if err != nil {
+			return nil, fmt.Errorf("reading attribute %s: %w", a.name, err)
+		}
+		*a.value = v
+	}
+
+	launchNs, exitNs := rec.OSLoaderStartImageStart, rec.ExitBootServicesExit
+	if launchNs == 0 {
+		return nil, errors.New("bootloader_launch_ns is zero, the firmware did not log the boot loader launch")
+	}
+	if exitNs < launchNs {
+		return nil, fmt.Errorf("exitbootservice_end_ns %d is before bootloader_launch_ns %d", exitNs, launchNs)
 	}
 
 	return &BootTimeRecord{
-		Firmware: time.Duration(launchNs) * time.Nanosecond,
-		Loader:   time.Duration(exitNs-launchNs) * time.Nanosecond,
+		Firmware:                 time.Duration(launchNs) * time.Nanosecond,
+		Loader:                   time.Duration(exitNs-launchNs) * time.Nanosecond,
+		ExitBootServicesDuration: exitBootServicesDuration(rec),
+		RawFPDT:                  &rec,
 	}, nil
 }
 
-func readParsedSysfsAttribute(attribute string) (uint64, error) {
-	path := filepath.Join(pathFPDTBootDir, attribute)
+func readParsedSysfsAttribute(dir, attribute string) (uint64, error) {
+	path := filepath.Join(dir, attribute)
 	data, err := os.ReadFile(filepath.Clean(path))
 	if err != nil {
 		return 0, fmt.Errorf("reading file %s: %w", path, err)
 	}
 
-	d, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return 0, fmt.Errorf("file %s is empty", path)
+	}
+
+	d, err := strconv.ParseUint(value, 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("parsing uint: %w", err)
 	}
@@ -155,123 +285,247 @@ func readParsedSysfsAttribute(attribute string) (uint64, error) {
 	return d, nil
 }
 
-func retrieveBootTimeFromTablePointer() (*BootTimeRecord, error) {
-	data, err := os.ReadFile(filepath.Clean(pathFPDTTableFile))
-	if err != nil {
-		return nil, fmt.Errorf("read FPDT table file %s: %w", pathFPDTTableFile, err)
-	}
+// Types of the FPDT records.
+const (
+	recordTypeBootPointer uint16 = 0x0000
+	recordTypeS3Pointer   uint16 = 0x0001
+	// recordTypeBootPerformance is the Firmware Basic Boot Performance Data
+	// Record, found in the table the boot pointer record points to.
+	recordTypeBootPerformance uint16 = 0x0002
+	recordTypeVendorFirst     uint16 = 0x1000
+	recordTypeVendorLast      uint16 = 0x3fff
+)
+
+// tableRecordHeaderSize is the size of TableHeaderFPDT.
+const tableRecordHeaderSize int = 4
+
+// fbptTableHeaderSize is the size of FBPTTableHeader.
+const fbptTableHeaderSize int = 8
+
+// FBPTTableHeader is the header of the Firmware Basic Boot Performance Table,
+// which the Firmware Basic Boot Performance Pointer Record of the FPDT points
+// to. Unlike the FPDT, it is not an ACPI table and has no checksum.
+type FBPTTableHeader struct {
+	// Signature is "FBPT".
+	Signature [4]byte
+	// Length is the length of the entire table in bytes.
+	Length uint32
+}
+
+// VendorRecordFPDT is a record reserved for platform, hardware or firmware
+// vendors, whose layout is vendor specific.
+type VendorRecordFPDT struct {
+	Header TableHeaderFPDT
+	// Data is the whole record, including its header.
+	Data []byte
+}
+
+// TableRecordsFPDT are the records of the FPDT table.
+type TableRecordsFPDT struct {
+	// BootPointer is the Firmware Basic Boot Performance Pointer Record, or
+	// nil if the table has none.
+	BootPointer *TablePointerRecordFPDT
+	// S3Pointer is the S3 Performance Table Pointer Record, or nil if the table
+	// has none.
+	S3Pointer *TablePointerRecordFPDT
+	// Vendor are the vendor specific records, in table order.
+	Vendor []VendorRecordFPDT
+}
 
+// ParseTableRecordsFPDT parses every record following the header of the FPDT
+// table in data. Records of unknown types are skipped.
+func ParseTableRecordsFPDT(data []byte) (*TableRecordsFPDT, error) {
 	if len(data) < tableHeaderSize {
 		return nil, errors.New("FPDT table have no header")
 	}
-	buf := bytes.NewReader(data[tableHeaderSize:]) // skip the header
 
-	var fpdtAddress *uint64
+	records := &TableRecordsFPDT{}
+	for offset := tableHeaderSize; offset < len(data); {
+		if len(data)-offset < tableRecordHeaderSize {
+			return nil, fmt.Errorf("truncated record header at offset %d", offset)
+		}
 
-	for buf.Len() > 0 {
 		var sh TableHeaderFPDT
-		headerBytes := make([]byte, 4)
-		if _, err := buf.ReadAt(headerBytes, 0); err != nil {
-			break
+		if err := binary.Read(bytes.NewReader(data[offset:]), binary.LittleEndian, &sh); err != nil {
+			return nil, fmt.Errorf("parsing record header at offset %d: %w", offset, err)
 		}
-		binary.Read(bytes.NewReader(headerBytes), binary.LittleEndian, &sh)
 
-		if sh.Length == 0 {
-			break // Avoid infinite loop
+		if int(sh.Length) < tableRecordHeaderSize {
+			return nil, fmt.Errorf("invalid record length %d at offset %d", sh.Length, offset)
 		}
-
-		recordData := make([]byte, sh.Length)
-		if _, err := buf.Read(recordData); err != nil {
-			break
+		if offset+int(sh.Length) > len(data) {
+			return nil, fmt.Errorf("truncated record of type %#x at offset %d", sh.Type, offset)
 		}
+		recordData := data[offset : offset+int(sh.Length)]
 
-		if sh.Type == 0 {
+		switch {
+		case sh.Type == recordTypeBootPointer, sh.Type == recordTypeS3Pointer:
 			var ptrRec TablePointerRecordFPDT
-			if err := binary.Read(bytes.NewReader(recordData), binary.LittleEndian, &ptrRec); err == nil {
-				fpdtAddress = &ptrRec.Address
-				break
+			if err := binary.Read(bytes.NewReader(recordData), binary.LittleEndian, &ptrRec); err != nil {
+				return nil, fmt.Errorf("parsing pointer record at offset %d: %w", offset, err)
+			}
+			if sh.Type == recordTypeBootPointer {
+				records.BootPointer = &ptrRec
+			} else {
+				records.S3Pointer = &ptrRec
 			}
+		case sh.Type >= recordTypeVendorFirst && sh.Type <= recordTypeVendorLast:
+			records.Vendor = append(records.Vendor, VendorRecordFPDT{Header: sh, Data: recordData})
+		}
+
+		offset += int(sh.Length)
+	}
+
+	return records, nil
+}
+
+// retrieveBootTimeFromTablePointer reads the FPDT from sysfs, after verifying
+// its checksum if verifyChecksum is true, and the FBPT its boot pointer record
+// points to from memory.
+func retrieveBootTimeFromTablePointer(verifyChecksum bool) (*BootTimeRecord, error) {
+	data, err := os.ReadFile(filepath.Clean(pathFPDTTableFile))
+	if err != nil {
+		return nil, fmt.Errorf("read FPDT table file %s: %w", pathFPDTTableFile, err)
+	}
+
+	if verifyChecksum && len(data) >= tableHeaderSize {
+		var hdr TableHeader
+		if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &hdr); err != nil {
+			return nil, fmt.Errorf("parsing ACPI table header: %w", err)
+		}
+		if err := hdr.Verify(data); err != nil {
+			return nil, err
 		}
 	}
 
-	if fpdtAddress == nil {
+	records, err := ParseTableRecordsFPDT(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing FPDT table: %w", err)
+	}
+
+	if records.BootPointer == nil {
 		return nil, errors.New("FPDT pointer not found in FPDT table")
 	}
 
-	record, err := readFPDTFromMemory(int64(*fpdtAddress))
+	address := records.BootPointer.Address
+	record, err := readFBPTFromMemory(int64(address))
 	if err != nil {
-		return nil, fmt.Errorf("reading FPDT table from address %x: %w", fpdtAddress, err)
+		return nil, fmt.Errorf("reading FBPT table from address %x: %w", address, err)
+	}
+
+	// The S3 Performance Table is optional, failing to read it leaves S3 nil.
+	if records.S3Pointer != nil {
+		if s3, err := readS3FromMemory(int64(records.S3Pointer.Address)); err == nil {
+			record.S3 = s3
+		}
 	}
 
 	return record, nil
 }
 
-func readFPDTFromMemory(physAddr int64) (*BootTimeRecord, error) {
+// readFBPTFromMemory parses the Firmware Basic Boot Performance Table at
+// physAddr.
+func readFBPTFromMemory(physAddr int64) (*BootTimeRecord, error) {
 	mem, err := os.Open(filepath.Clean(pathDevMem))
 	if err != nil {
 		return nil, fmt.Errorf("opening %s: %w", pathDevMem, err)
 	}
 	defer mem.Close()
 
-	headerBuf := make([]byte, tableHeaderSize)
-	if _, err := mem.ReadAt(headerBuf, physAddr); err != nil {
-		return nil, fmt.Errorf("reading ACPI table header: %w", err)
+	headerBuf, err := readPhysicalMemory(mem, physAddr, fbptTableHeaderSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading FBPT table header: %w", err)
+	}
+
+	tableData, err := readPhysicalMemory(mem, physAddr, int(binary.LittleEndian.Uint32(headerBuf[4:])))
+	if err != nil {
+		return nil, fmt.Errorf("reading full table: %w", err)
 	}
 
-	var hdr TableHeader
-	if err := binary.Read(bytes.NewReader(headerBuf), binary.LittleEndian, &hdr); err != nil {
-		return nil, fmt.Errorf("parsing ACPI table header: %w", err)
+	return ParseFBPTTable(tableData)
+}
+
+// ParseFBPTTable returns the boot times of the first boot performance record of
+// the Firmware Basic Boot Performance Table, given with its header. This is the
+// table found in memory at the address of the boot pointer record of the FPDT,
+// such as a dump captured on another machine, not the FPDT itself.
+func ParseFBPTTable(data []byte) (*BootTimeRecord, error) {
+	if len(data) < fbptTableHeaderSize {
+		return nil, errors.New("FBPT table have no header")
 	}
 
-	if string(hdr.Signature[:]) != "FPDT" {
-		return nil, fmt.Errorf("table signature memory is not FPDT, but %s", hdr.Signature)
+	var hdr FBPTTableHeader
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("parsing FBPT table header: %w", err)
 	}
 
-	tableData := make([]byte, hdr.Length)
-	if _, err := mem.ReadAt(tableData, physAddr); err != nil {
-		return nil, fmt.Errorf("reading full table: %w", err)
+	if string(hdr.Signature[:]) != "FBPT" {
+		return nil, fmt.Errorf("table signature memory is not FBPT, but %s", hdr.Signature)
 	}
 
-	offset := tableHeaderSize // skip header
-	for offset < int(hdr.Length) {
-		r := bytes.NewReader(tableData[offset:])
+	if int(hdr.Length) < len(data) {
+		data = data[:hdr.Length]
+	}
+
+	for offset := fbptTableHeaderSize; offset < len(data); {
+		if len(data)-offset < tableRecordHeaderSize {
+			return nil, fmt.Errorf("truncated record header at offset %d", offset)
+		}
+
 		var sh TableHeaderFPDT
-		if err := binary.Read(r, binary.LittleEndian, &sh); err != nil {
-			break
+		if err := binary.Read(bytes.NewReader(data[offset:]), binary.LittleEndian, &sh); err != nil {
+			return nil, fmt.Errorf("parsing record header at offset %d: %w", offset, err)
 		}
 
-		if sh.Length == 0 {
-			break
+		if int(sh.Length) < tableRecordHeaderSize {
+			return nil, fmt.Errorf("invalid record length %d at offset %d", sh.Length, offset)
 		}
 
-		if sh.Type == 2 {
+		if sh.Type == recordTypeBootPerformance {
 			var rec TableRecordFPDT
-			r = bytes.NewReader(tableData[offset:]) // reset reader to the record start
-			if err := binary.Read(r, binary.LittleEndian, &rec); err != nil {
+			if err := binary.Read(bytes.NewReader(data[offset:]), binary.LittleEndian, &rec); err != nil {
 				return nil, fmt.Errorf("parsing boot record: %w", err)
 			}
 
-			result := &BootTimeRecord{}
+			return bootTimeFromRecord(rec), nil
+		}
 
-			// Firmware = Time until Loader Starts
-			if rec.OSLoaderLoadImageStart > 0 {
-				result.Firmware = time.Duration(rec.OSLoaderLoadImageStart) * time.Nanosecond
-			} else if rec.ResetEnd > 0 {
-				result.Firmware = time.Duration(rec.ResetEnd) * time.Nanosecond
-			}
+		offset += int(sh.Length)
+	}
 
-			// Loader = Time from Loader Start until ExitBootServices (Kernel handover)
-			if rec.ExitBootServicesExit > 0 && rec.OSLoaderLoadImageStart > 0 {
-				if rec.ExitBootServicesExit > rec.OSLoaderLoadImageStart {
-					result.Loader = time.Duration(rec.ExitBootServicesExit-rec.OSLoaderLoadImageStart) * time.Nanosecond
-				}
-			}
+	return nil, errors.New("no boot performance record found in FBPT")
+}
 
-			return result, nil
-		}
+// bootTimeFromRecord derives the boot time stages from the boot performance
+// record.
+func bootTimeFromRecord(rec TableRecordFPDT) *BootTimeRecord {
+	result := &BootTimeRecord{RawFPDT: &rec}
 
-		offset += int(sh.Length)
+	// Firmware = Time until Loader Starts
+	if rec.OSLoaderLoadImageStart > 0 {
+		result.Firmware = time.Duration(rec.OSLoaderLoadImageStart) * time.Nanosecond
+	} else if rec.ResetEnd > 0 {
+		result.Firmware = time.Duration(rec.ResetEnd) * time.Nanosecond
 	}
 
-	return nil, errors.New("no boot performance record found in FPDT")
+	// Loader = Time from Loader Start until ExitBootServices (Kernel handover)
+	if rec.ExitBootServicesExit > 0 && rec.OSLoaderLoadImageStart > 0 {
+		if rec.ExitBootServicesExit > rec.OSLoaderLoadImageStart {
+			result.Loader = time.Duration(rec.ExitBootServicesExit-rec.OSLoaderLoadImageStart) * time.Nanosecond
+		}
+	}
+
+	result.ExitBootServicesDuration = exitBootServicesDuration(rec)
+
+	return result
+}
+
+// exitBootServicesDuration returns the time between the entry and the exit of
+// ExitBootServices, or zero if either is missing or the exit is logged before
+// the entry.
+func exitBootServicesDuration(rec TableRecordFPDT) time.Duration {
+	if rec.ExitBootServicesEntry == 0 || rec.ExitBootServicesExit < rec.ExitBootServicesEntry {
+		return 0
+	}
+	return time.Duration(rec.ExitBootServicesExit-rec.ExitBootServicesEntry) * time.Nanosecond
 }
@@ -0,0 +1,429 @@
+package acpi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fpdtTable returns an FPDT table made of a blank header and the records.
+func fpdtTable(records ...[]byte) []byte {
+	data := make([]byte, tableHeaderSize)
+	copy(data, "FPDT")
+	for _, r := range records {
+		data = append(data, r...)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)))
+	return data
+}
+
+// fbptTable returns an FBPT table made of its header and the records.
+func fbptTable(records ...[]byte) []byte {
+	data := make([]byte, fbptTableHeaderSize)
+	copy(data, "FBPT")
+	for _, r := range records {
+		data = append(data, r...)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)))
+	return data
+}
+
+func pointerRecord(recordType uint16, address uint64) []byte {
+	r := binary.LittleEndian.AppendUint16(nil, recordType)
+	r = append(r, 16, 1)
+	r = append(r, 0, 0, 0, 0)
+	return binary.LittleEndian.AppendUint64(r, address)
+}
+
+func vendorRecord(recordType uint16, payload ...byte) []byte {
+	r := binary.LittleEndian.AppendUint16(nil, recordType)
+	r = append(r, byte(4+len(payload)), 1)
+	return append(r, payload...)
+}
+
+func TestParseTableRecordsFPDT(t *testing.T) {
+	tcs := map[string]struct {
+		data     []byte
+		validate func(t *testing.T, records *TableRecordsFPDT, err error)
+	}{
+		"vendor record preceding the pointer record": {
+			data: fpdtTable(
+				vendorRecord(0x3000, 0xaa, 0xbb, 0xcc, 0xdd),
+				pointerRecord(recordTypeS3Pointer, 0x7000),
+				pointerRecord(recordTypeBootPointer, 0x7b000000),
+				vendorRecord(0x1001),
+			),
+			validate: func(t *testing.T, records *TableRecordsFPDT, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, records.BootPointer)
+				assert.Equal(t, uint64(0x7b000000), records.BootPointer.Address)
+				require.NotNil(t, records.S3Pointer)
+				assert.Equal(t, uint64(0x7000), records.S3Pointer.Address)
+				require.Len(t, records.Vendor, 2)
+				assert.Equal(t, uint16(0x3000), records.Vendor[0].Header.Type)
+				assert.Equal(t, []byte{0x00, 0x30, 8, 1, 0xaa, 0xbb, 0xcc, 0xdd}, records.Vendor[0].Data)
+				assert.Equal(t, uint16(0x1001), records.Vendor[1].Header.Type)
+			},
+		},
+		"no pointer record": {
+			data: fpdtTable(vendorRecord(0x2000)),
+			validate: func(t *testing.T, records *TableRecordsFPDT, err error) {
+				require.NoError(t, err)
+				assert.Nil(t, records.BootPointer)
+				assert.Len(t, records.Vendor, 1)
+			},
+		},
+		"truncated record": {
+			data: fpdtTable(pointerRecord(recordTypeBootPointer, 0x7b000000)[:10]),
+			validate: func(t *testing.T, records *TableRecordsFPDT, err error) {
+				assert.ErrorContains(t, err, "truncated record of type 0")
+			},
+		},
+		"zero length record": {
+			data: fpdtTable([]byte{0x00, 0x10, 0, 1}),
+			validate: func(t *testing.T, records *TableRecordsFPDT, err error) {
+				assert.ErrorContains(t, err, "invalid record length 0")
+			},
+		},
+		"missing header": {
+			data: []byte("FPDT"),
+			validate: func(t *testing.T, records *TableRecordsFPDT, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			records, err := ParseTableRecordsFPDT(tc.data)
+			tc.validate(t, records, err)
+		})
+	}
+}
+
+func bootPerformanceRecord(resetEnd, loadImageStart, startImageStart, exitEntry, exitExit uint64) []byte {
+	r := binary.LittleEndian.AppendUint16(nil, recordTypeBootPerformance)
+	r = append(r, 48, 2)
+	r = append(r, 0, 0, 0, 0)
+	for _, v := range []uint64{resetEnd, loadImageStart, startImageStart, exitEntry, exitExit} {
+		r = binary.LittleEndian.AppendUint64(r, v)
+	}
+	return r
+}
+
+func TestParseFBPTTable(t *testing.T) {
+	tcs := map[string]struct {
+		data     []byte
+		validate func(t *testing.T, record *BootTimeRecord, err error)
+	}{
+		"boot performance record": {
+			data: fbptTable(bootPerformanceRecord(0, 1_897_000_000, 1_900_000_000, 3_500_000_000, 3_612_000_000)),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1897*time.Millisecond, record.Firmware)
+				assert.Equal(t, 1715*time.Millisecond, record.Loader)
+				assert.Equal(t, 112*time.Millisecond, record.ExitBootServicesDuration)
+				require.NotNil(t, record.RawFPDT)
+				assert.Equal(t, recordTypeBootPerformance, record.RawFPDT.Header.Type)
+				assert.Zero(t, record.RawFPDT.ResetEnd)
+				assert.Equal(t, uint64(1_897_000_000), record.RawFPDT.OSLoaderLoadImageStart)
+				assert.Equal(t, uint64(1_900_000_000), record.RawFPDT.OSLoaderStartImageStart)
+				assert.Equal(t, uint64(3_500_000_000), record.RawFPDT.ExitBootServicesEntry)
+				assert.Equal(t, uint64(3_612_000_000), record.RawFPDT.ExitBootServicesExit)
+			},
+		},
+		"vendor record preceding the boot performance record": {
+			data: fbptTable(vendorRecord(0x3000, 1, 2, 3, 4), bootPerformanceRecord(0, 1_000_000_000, 0, 0, 2_000_000_000)),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, time.Second, record.Firmware)
+				assert.Equal(t, time.Second, record.Loader)
+				assert.Zero(t, record.ExitBootServicesDuration, "entry not logged")
+			},
+		},
+		"exit boot services exit before entry": {
+			data: fbptTable(bootPerformanceRecord(0, 1_897_000_000, 1_900_000_000, 3_700_000_000, 3_612_000_000)),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1715*time.Millisecond, record.Loader)
+				assert.Zero(t, record.ExitBootServicesDuration)
+			},
+		},
+		"firmware falls back to reset end": {
+			data: fbptTable(bootPerformanceRecord(5_000_000, 0, 0, 0, 0)),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 5*time.Millisecond, record.Firmware)
+				assert.Zero(t, record.Loader)
+			},
+		},
+		"missing boot performance record returns error": {
+			data: fbptTable(vendorRecord(0x3000, 1, 2, 3, 4)),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.Error(t, err)
+				assert.Nil(t, record)
+			},
+		},
+		"wrong signature returns error": {
+			data: func() []byte {
+				data := fbptTable(bootPerformanceRecord(0, 1, 0, 0, 2))
+				copy(data, "FPDT")
+				return data
+			}(),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.Error(t, err)
+				assert.Nil(t, record)
+			},
+		},
+		"zero-length record returns error": {
+			data: fbptTable([]byte{0x00, 0x30, 0, 1}),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.Error(t, err)
+				assert.Nil(t, record)
+			},
+		},
+		"missing header returns error": {
+			data: []byte("FBPT"),
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.Error(t, err)
+				assert.Nil(t, record)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			record, err := ParseFBPTTable(tc.data)
+			tc.validate(t, record, err)
+		})
+	}
+}
+
+// withChecksum sets the checksum byte of the table so that it adds to zero.
+func withChecksum(data []byte) []byte {
+	var sum uint8
+	for _, b := range data {
+		sum += b
+	}
+	data[9] -= sum
+	return data
+}
+
+// fpdtDump is an FPDT table with a boot pointer record, and fbptDump the FBPT
+// table with the boot performance record it points to, written byte by byte as
+// they lie in memory, little-endian, so that they do not depend on the byte
+// order of the host running the tests.
+const (
+	fpdtDump = "46504454" + "34000000" + "01" + "00" + "000000000000" + "0000000000000000" + "00000000" + "00000000" + "00000000" +
+		"0000" + "10" + "01" + "00000000" + "0807060504030201"
+	fbptDump = "46425054" + "38000000" +
+		"0200" + "30" + "02" + "00000000" + "0000000000000000" + "40ec117100000000" + "00b33f7100000000" + "00c39dd000000000" + "00bf4ad700000000"
+)
+
+func TestParseFPDTDumpByteOrder(t *testing.T) {
+	fpdt, err := hex.DecodeString(fpdtDump)
+	require.NoError(t, err)
+	fbpt, err := hex.DecodeString(fbptDump)
+	require.NoError(t, err)
+
+	var hdr TableHeader
+	require.NoError(t, binary.Read(bytes.NewReader(fpdt), binary.LittleEndian, &hdr))
+	assert.Equal(t, uint32(52), hdr.Length)
+
+	records, err := ParseTableRecordsFPDT(fpdt)
+	require.NoError(t, err)
+	require.NotNil(t, records.BootPointer)
+	assert.Equal(t, uint8(16), records.BootPointer.Header.Length)
+	assert.Equal(t, uint64(0x0102030405060708), records.BootPointer.Address)
+
+	record, err := ParseFBPTTable(fbpt)
+	require.NoError(t, err)
+	require.NotNil(t, record.RawFPDT)
+	assert.Equal(t, uint8(48), record.RawFPDT.Header.Length)
+	assert.Equal(t, uint64(1_897_000_000), record.RawFPDT.OSLoaderLoadImageStart)
+	assert.Equal(t, uint64(1_900_000_000), record.RawFPDT.OSLoaderStartImageStart)
+	assert.Equal(t, uint64(3_500_000_000), record.RawFPDT.ExitBootServicesEntry)
+	assert.Equal(t, uint64(3_612_000_000), record.RawFPDT.ExitBootServicesExit)
+	assert.Equal(t, 1897*time.Millisecond, record.Firmware)
+
+	// Encoding the parsed records back gives the bytes of the dumps, which a
+	// decoding following the byte order of a big-endian host would not.
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, records.BootPointer))
+	assert.Equal(t, fpdt[tableHeaderSize:], buf.Bytes())
+
+	buf.Reset()
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, record.RawFPDT))
+	assert.Equal(t, fbpt[fbptTableHeaderSize:], buf.Bytes())
+}
+
+func TestTableHeaderVerify(t *testing.T) {
+	tcs := map[string]struct {
+		data     []byte
+		validate func(t *testing.T, err error)
+	}{
+		"valid checksum": {
+			data: withChecksum(fpdtTable(pointerRecord(recordTypeBootPointer, 0x7b000000))),
+			validate: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"invalid checksum": {
+			data: func() []byte {
+				data := withChecksum(fpdtTable(pointerRecord(recordTypeBootPointer, 0x7b000000)))
+				data[len(data)-1]++
+				return data
+			}(),
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidChecksum)
+			},
+		},
+		"table shorter than its length": {
+			data: withChecksum(fpdtTable(vendorRecord(0x3000, 1, 2, 3, 4)))[:tableHeaderSize],
+			validate: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "shorter than its length")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var hdr TableHeader
+			require.NoError(t, binary.Read(bytes.NewReader(tc.data), binary.LittleEndian, &hdr))
+			tc.validate(t, hdr.Verify(tc.data))
+		})
+	}
+}
+
+func TestRetrieveBootTimeWithFallback(t *testing.T) {
+	errSysfs := errors.New("sysfs attribute missing")
+	errTablePointer := errors.New("permission denied")
+	record := &BootTimeRecord{Firmware: time.Second, Loader: time.Second}
+
+	succeed := func() (*BootTimeRecord, error) { return record, nil }
+	failWith := func(err error) func() (*BootTimeRecord, error) {
+		return func() (*BootTimeRecord, error) { return nil, err }
+	}
+
+	tcs := map[string]struct {
+		sysfs        func() (*BootTimeRecord, error)
+		tablePointer func() (*BootTimeRecord, error)
+		validate     func(t *testing.T, r *BootTimeRecord, err error)
+	}{
+		"sysfs succeeds": {
+			sysfs:        succeed,
+			tablePointer: failWith(errTablePointer),
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, record, r)
+			},
+		},
+		"table pointer succeeds after sysfs failure": {
+			sysfs:        failWith(errSysfs),
+			tablePointer: succeed,
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, record, r)
+			},
+		},
+		"both failures are reported": {
+			sysfs:        failWith(errSysfs),
+			tablePointer: failWith(errTablePointer),
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.ErrorIs(t, err, errSysfs)
+				require.ErrorIs(t, err, errTablePointer)
+				assert.Contains(t, err.Error(), errSysfs.Error())
+				assert.Contains(t, err.Error(), errTablePointer.Error())
+				assert.Nil(t, r)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			r, err := retrieveBootTimeWithFallback(tc.sysfs, tc.tablePointer)
+			tc.validate(t, r, err)
+		})
+	}
+}
+
+func TestReadBootTimeFromSysfs(t *testing.T) {
+	attributes := map[string]string{
+		"reset_end_ns":             "5000000\n",
+		"bootloader_load_ns":       "1890000000\n",
+		"bootloader_launch_ns":     "1897000000\n",
+		"exitbootservice_start_ns": "3500000000\n",
+		"exitbootservice_end_ns":   "3612000000\n",
+	}
+
+	tcs := map[string]struct {
+		overrides map[string]string
+		validate  func(t *testing.T, r *BootTimeRecord, err error)
+	}{
+		"valid attributes": {
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1897*time.Millisecond, r.Firmware)
+				assert.Equal(t, 1715*time.Millisecond, r.Loader)
+				assert.Equal(t, 112*time.Millisecond, r.ExitBootServicesDuration)
+			},
+		},
+		"exit before entry has no exit boot services duration": {
+			overrides: map[string]string{"exitbootservice_start_ns": "3700000000\n"},
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1715*time.Millisecond, r.Loader)
+				assert.Zero(t, r.ExitBootServicesDuration)
+			},
+		},
+		"launch after exit returns error": {
+			overrides: map[string]string{"bootloader_launch_ns": "4000000000\n"},
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "exitbootservice_end_ns 3612000000 is before bootloader_launch_ns 4000000000")
+				assert.Nil(t, r)
+			},
+		},
+		"zero launch returns error": {
+			overrides: map[string]string{"bootloader_launch_ns": "0\n"},
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "bootloader_launch_ns is zero")
+				assert.Nil(t, r)
+			},
+		},
+		"empty launch returns error": {
+			overrides: map[string]string{"bootloader_launch_ns": ""},
+			validate: func(t *testing.T, r *BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "bootloader_launch_ns is empty")
+				assert.Nil(t, r)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, value := range attributes {
+				if override, ok := tc.overrides[name]; ok {
+					value = override
+				}
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(value), 0o600))
+			}
+
+			r, err := readBootTimeFromSysfs(dir)
+			tc.validate(t, r, err)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package acpi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// readPhysicalMemory returns length bytes at physAddr of mem, an opened
+// /dev/mem. The pages holding them are mapped first, since hardened kernels
+// may restrict read(2) of /dev/mem while allowing mmap(2) of the ACPI tables,
+// falling back to reading them otherwise.
+func readPhysicalMemory(mem *os.File, physAddr int64, length int) ([]byte, error) {
+	data, mmapErr := mmapPhysicalMemory(mem, physAddr, length)
+	if mmapErr == nil {
+		return data, nil
+	}
+
+	data = make([]byte, length)
+	if _, err := mem.ReadAt(data, physAddr); err != nil {
+		return nil, errors.Join(mmapErr, fmt.Errorf("reading %s: %w", mem.Name(), err))
+	}
+
+	return data, nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package acpi
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapPhysicalMemory is not supported without mmap(2), the memory is then
+// read.
+func mmapPhysicalMemory(mem *os.File, physAddr int64, length int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
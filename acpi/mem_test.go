@@ -0,0 +1,54 @@
+package acpi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPhysicalMemory(t *testing.T) {
+	pageSize := os.Getpagesize()
+	memory := make([]byte, 3*pageSize)
+	for i := range memory {
+		memory[i] = byte(i * 7)
+	}
+	fileName := filepath.Join(t.TempDir(), "mem")
+	require.NoError(t, os.WriteFile(fileName, memory, 0o600))
+
+	tcs := map[string]struct {
+		physAddr int64
+		length   int
+	}{
+		"start of a page":        {physAddr: int64(pageSize), length: tableHeaderSize},
+		"middle of a page":       {physAddr: 100, length: 52},
+		"straddling a page":      {physAddr: int64(pageSize - 10), length: 30},
+		"spanning several pages": {physAddr: 1, length: 2*pageSize + 10},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mem, err := os.Open(fileName)
+			require.NoError(t, err)
+			defer mem.Close()
+
+			data, err := readPhysicalMemory(mem, tc.physAddr, tc.length)
+			require.NoError(t, err)
+			assert.Equal(t, memory[tc.physAddr:tc.physAddr+int64(tc.length)], data)
+		})
+	}
+}
+
+func TestReadPhysicalMemoryUnreadable(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	data, err := readPhysicalMemory(r, 0, tableHeaderSize)
+	require.Error(t, err)
+	assert.Nil(t, data)
+}
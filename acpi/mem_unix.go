@@ -0,0 +1,31 @@
+//go:build unix
+
+package acpi
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapPhysicalMemory returns a copy of length bytes at physAddr of mem, mapping
+// every page they span, including when they straddle a page boundary.
+func mmapPhysicalMemory(mem *os.File, physAddr int64, length int) ([]byte, error) {
+	pageSize := int64(os.Getpagesize())
+	start := physAddr &^ (pageSize - 1)
+	offset := int(physAddr - start)
+
+	mapped, err := unix.Mmap(int(mem.Fd()), start, offset+length, unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %w", mem.Name(), err)
+	}
+
+	data := bytes.Clone(mapped[offset : offset+length])
+	if err := unix.Munmap(mapped); err != nil {
+		return nil, fmt.Errorf("unmapping %s: %w", mem.Name(), err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,18 @@
+//go:build arm || arm64
+
+package acpi
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// checkPlatform reports whether ACPI tables can exist on the host. On ARM,
+// only server-class machines boot with ACPI, while boards such as the
+// Raspberry Pi describe their hardware with a device tree.
+func checkPlatform() error {
+	if _, err := os.Stat(filepath.Clean(pathACPIDir)); err != nil {
+		return ErrUnsupportedPlatform
+	}
+	return nil
+}
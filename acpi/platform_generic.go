@@ -0,0 +1,8 @@
+//go:build !arm && !arm64
+
+package acpi
+
+// checkPlatform reports whether ACPI tables can exist on the host.
+func checkPlatform() error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package acpi
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildARM checks that the module, including the ARM platform checks,
+// compiles for the ARM architectures.
+func TestBuildARM(t *testing.T) {
+	if testing.Short() {
+		t.Skip("cross-compilation is slow")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	for _, arch := range []string{"arm", "arm64"} {
+		t.Run(arch, func(t *testing.T) {
+			cmd := exec.Command(goBin, "build", "./...")
+			cmd.Dir = ".."
+			cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+arch)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+		})
+	}
+}
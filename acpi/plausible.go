@@ -0,0 +1,54 @@
+package acpi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrImplausibleRecord is returned by Plausible when the durations of a record
+// are physically impossible, such as on buggy firmware logging a firmware
+// duration of a few microseconds.
+var ErrImplausibleRecord = errors.New("implausible ACPI boot times")
+
+// FirmwareBounds are the shortest and longest plausible firmware durations.
+type FirmwareBounds struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// DefaultFirmwareBounds are the bounds used by Plausible: no firmware
+// initializes the hardware in less than 50ms, and one taking more than 10min
+// is far more likely a timer which does not count from reset.
+var DefaultFirmwareBounds = FirmwareBounds{Min: 50 * time.Millisecond, Max: 10 * time.Minute}
+
+// Plausible is PlausibleWithin the DefaultFirmwareBounds.
+func (r BootTimeRecord) Plausible() error {
+	return r.PlausibleWithin(DefaultFirmwareBounds)
+}
+
+// PlausibleWithin returns an error wrapping ErrImplausibleRecord if the
+// firmware duration is out of bounds, or if the loader duration is zero while
+// the firmware one is known, which happens when the boot loader timestamps are
+// out of order. A record without firmware duration is not checked.
+func (r BootTimeRecord) PlausibleWithin(bounds FirmwareBounds) error {
+	if r.Firmware == 0 {
+		return nil
+	}
+
+	var errs []error
+	if r.Firmware < bounds.Min {
+		errs = append(errs, fmt.Errorf("firmware duration %s is shorter than %s", r.Firmware, bounds.Min))
+	}
+	if r.Firmware > bounds.Max {
+		errs = append(errs, fmt.Errorf("firmware duration %s is longer than %s", r.Firmware, bounds.Max))
+	}
+	if r.Loader <= 0 {
+		errs = append(errs, fmt.Errorf("loader duration %s is not positive while the firmware one is %s", r.Loader, r.Firmware))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrImplausibleRecord, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package acpi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeRecordPlausible(t *testing.T) {
+	tcs := map[string]struct {
+		record   BootTimeRecord
+		validate func(t *testing.T, err error)
+	}{
+		"plausible record": {
+			record: BootTimeRecord{Firmware: 1897 * time.Millisecond, Loader: 1715 * time.Millisecond},
+			validate: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"record without firmware duration is not checked": {
+			record: BootTimeRecord{},
+			validate: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"firmware of a few microseconds": {
+			record: BootTimeRecord{Firmware: 4 * time.Microsecond, Loader: 1715 * time.Millisecond},
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrImplausibleRecord)
+				assert.ErrorContains(t, err, "firmware duration 4µs is shorter than 50ms")
+			},
+		},
+		"firmware of several hours": {
+			record: BootTimeRecord{Firmware: 5 * time.Hour, Loader: 1715 * time.Millisecond},
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrImplausibleRecord)
+				assert.ErrorContains(t, err, "firmware duration 5h0m0s is longer than 10m0s")
+			},
+		},
+		"zero loader while firmware is known": {
+			record: BootTimeRecord{Firmware: 1897 * time.Millisecond},
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrImplausibleRecord)
+				assert.ErrorContains(t, err, "loader duration 0s is not positive")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tc.validate(t, tc.record.Plausible())
+		})
+	}
+}
+
+func TestBootTimeRecordPlausibleWithin(t *testing.T) {
+	record := BootTimeRecord{Firmware: 30 * time.Millisecond, Loader: 100 * time.Millisecond}
+	require.ErrorIs(t, record.Plausible(), ErrImplausibleRecord)
+	require.NoError(t, record.PlausibleWithin(FirmwareBounds{Min: 10 * time.Millisecond, Max: time.Minute}))
+}
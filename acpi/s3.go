@@ -0,0 +1,191 @@
+package acpi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// s3TableHeaderSize is the size of S3TableHeader.
+	s3TableHeaderSize  int    = 8
+	pathFPDTSuspendDir string = "/sys/firmware/acpi/fpdt/suspend/"
+	pathFPDTResumeDir  string = "/sys/firmware/acpi/fpdt/resume/"
+)
+
+// Types of the records of the S3 Performance Table.
+const (
+	recordTypeS3Resume  uint16 = 0x0000
+	recordTypeS3Suspend uint16 = 0x0001
+)
+
+// S3TableHeader is the header of the S3 Performance Table, which the S3
+// Performance Table Pointer Record of the FPDT points to.
+type S3TableHeader struct {
+	// Signature is "S3PT".
+	Signature [4]byte
+	// Length is the length of the entire table in bytes.
+	Length uint32
+}
+
+// S3ResumeRecordFPDT is the Basic S3 Resume Performance Record.
+type S3ResumeRecordFPDT struct {
+	Header TableHeaderFPDT
+	// ResumeCount is the number of resumes since the last full boot.
+	ResumeCount uint32
+	// FullResume is the timer value logged just prior to the handoff to the OS
+	// waking vector, for the last resume.
+	FullResume uint64
+	// AverageResume is the average of FullResume over the resumes since the
+	// last full boot.
+	AverageResume uint64
+}
+
+// S3SuspendRecordFPDT is the Basic S3 Suspend Performance Record.
+type S3SuspendRecordFPDT struct {
+	Header TableHeaderFPDT
+	// SuspendStart is the timer value logged when the OS writes SLP_TYP and
+	// SLP_EN to enter S3, for the last suspend.
+	SuspendStart uint64
+	// SuspendEnd is the timer value logged by the firmware just prior to the
+	// transition to S3.
+	SuspendEnd uint64
+}
+
+// S3PerformanceRecord is the firmware duration of the last suspend and resume
+// read from the S3 Performance Table. Its fields are zero until the host
+// suspends and resumes.
+type S3PerformanceRecord struct {
+	// ResumeCount is the number of resumes since the last full boot.
+	ResumeCount uint32
+	// Resume is the firmware duration of the last resume.
+	Resume time.Duration
+	// AverageResume is the average firmware duration of the resumes.
+	AverageResume time.Duration
+	// Suspend is the firmware duration of the last suspend.
+	Suspend time.Duration
+}
+
+// ParseS3PerformanceTable returns the suspend and resume durations of the S3
+// Performance Table, given with its header.
+func ParseS3PerformanceTable(data []byte) (*S3PerformanceRecord, error) {
+	if len(data) < s3TableHeaderSize {
+		return nil, errors.New("S3PT table have no header")
+	}
+
+	var hdr S3TableHeader
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("parsing S3PT table header: %w", err)
+	}
+
+	if string(hdr.Signature[:]) != "S3PT" {
+		return nil, fmt.Errorf("table signature memory is not S3PT, but %s", hdr.Signature)
+	}
+
+	if int(hdr.Length) < len(data) {
+		data = data[:hdr.Length]
+	}
+
+	result := &S3PerformanceRecord{}
+	for offset := s3TableHeaderSize; offset < len(data); {
+		if len(data)-offset < tableRecordHeaderSize {
+			return nil, fmt.Errorf("truncated record header at offset %d", offset)
+		}
+
+		var sh TableHeaderFPDT
+		if err := binary.Read(bytes.NewReader(data[offset:]), binary.LittleEndian, &sh); err != nil {
+			return nil, fmt.Errorf("parsing record header at offset %d: %w", offset, err)
+		}
+
+		if int(sh.Length) < tableRecordHeaderSize {
+			return nil, fmt.Errorf("invalid record length %d at offset %d", sh.Length, offset)
+		}
+		if offset+int(sh.Length) > len(data) {
+			return nil, fmt.Errorf("truncated record of type %#x at offset %d", sh.Type, offset)
+		}
+		recordData := bytes.NewReader(data[offset : offset+int(sh.Length)])
+
+		switch sh.Type {
+		case recordTypeS3Resume:
+			var rec S3ResumeRecordFPDT
+			if err := binary.Read(recordData, binary.LittleEndian, &rec); err != nil {
+				return nil, fmt.Errorf("parsing resume record at offset %d: %w", offset, err)
+			}
+			result.ResumeCount = rec.ResumeCount
+			result.Resume = time.Duration(rec.FullResume) * time.Nanosecond
+			result.AverageResume = time.Duration(rec.AverageResume) * time.Nanosecond
+		case recordTypeS3Suspend:
+			var rec S3SuspendRecordFPDT
+			if err := binary.Read(recordData, binary.LittleEndian, &rec); err != nil {
+				return nil, fmt.Errorf("parsing suspend record at offset %d: %w", offset, err)
+			}
+			if rec.SuspendEnd > rec.SuspendStart {
+				result.Suspend = time.Duration(rec.SuspendEnd-rec.SuspendStart) * time.Nanosecond
+			}
+		}
+
+		offset += int(sh.Length)
+	}
+
+	return result, nil
+}
+
+// readS3FromMemory parses the S3 Performance Table at physAddr.
+func readS3FromMemory(physAddr int64) (*S3PerformanceRecord, error) {
+	mem, err := os.Open(filepath.Clean(pathDevMem))
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", pathDevMem, err)
+	}
+	defer mem.Close()
+
+	headerBuf, err := readPhysicalMemory(mem, physAddr, s3TableHeaderSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading S3PT table header: %w", err)
+	}
+
+	tableData, err := readPhysicalMemory(mem, physAddr, int(binary.LittleEndian.Uint32(headerBuf[4:])))
+	if err != nil {
+		return nil, fmt.Errorf("reading full table: %w", err)
+	}
+
+	return ParseS3PerformanceTable(tableData)
+}
+
+// retrieveS3WithSysfs reads the parsed S3 Performance Table from
+// "/sys/firmware/acpi/fpdt/".
+func retrieveS3WithSysfs() (*S3PerformanceRecord, error) {
+	var suspendStart, suspendEnd, resumeCount, resume, averageResume uint64
+	attributes := []struct {
+		dir   string
+		name  string
+		value *uint64
+	}{
+		{pathFPDTSuspendDir, "suspend_start_ns", &suspendStart},
+		{pathFPDTSuspendDir, "suspend_end_ns", &suspendEnd},
+		{pathFPDTResumeDir, "resume_count", &resumeCount},
+		{pathFPDTResumeDir, "resume_prev_ns", &resume},
+		{pathFPDTResumeDir, "resume_avg_ns", &averageResume},
+	}
+	for _, a := range attributes {
+		v, err := readParsedSysfsAttribute(a.dir, a.name)
+		if err != nil {
+			return nil, fmt.Errorf("reading attribute %s: %w", a.name, err)
+		}
+		*a.value = v
+	}
+
+	result := &S3PerformanceRecord{
+		ResumeCount:   uint32(resumeCount),
+		Resume:        time.Duration(resume) * time.Nanosecond,
+		AverageResume: time.Duration(averageResume) * time.Nanosecond,
+	}
+	if suspendEnd > suspendStart {
+		result.Suspend = time.Duration(suspendEnd-suspendStart) * time.Nanosecond
+	}
+
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package acpi
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// s3Table returns an S3 Performance Table made of its header and the records.
+func s3Table(records ...[]byte) []byte {
+	data := make([]byte, s3TableHeaderSize)
+	copy(data, "S3PT")
+	for _, r := range records {
+		data = append(data, r...)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)))
+	return data
+}
+
+func s3ResumeRecord(count uint32, fullResume, averageResume uint64) []byte {
+	r := binary.LittleEndian.AppendUint16(nil, recordTypeS3Resume)
+	r = append(r, 24, 1)
+	r = binary.LittleEndian.AppendUint32(r, count)
+	r = binary.LittleEndian.AppendUint64(r, fullResume)
+	return binary.LittleEndian.AppendUint64(r, averageResume)
+}
+
+func s3SuspendRecord(start, end uint64) []byte {
+	r := binary.LittleEndian.AppendUint16(nil, recordTypeS3Suspend)
+	r = append(r, 20, 1)
+	r = binary.LittleEndian.AppendUint64(r, start)
+	return binary.LittleEndian.AppendUint64(r, end)
+}
+
+func TestParseS3PerformanceTable(t *testing.T) {
+	tcs := map[string]struct {
+		data     []byte
+		validate func(t *testing.T, record *S3PerformanceRecord, err error)
+	}{
+		"resume and suspend records": {
+			data: s3Table(s3ResumeRecord(3, 450_000_000, 500_000_000), s3SuspendRecord(1_000_000_000, 1_120_000_000)),
+			validate: func(t *testing.T, record *S3PerformanceRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &S3PerformanceRecord{
+					ResumeCount:   3,
+					Resume:        450 * time.Millisecond,
+					AverageResume: 500 * time.Millisecond,
+					Suspend:       120 * time.Millisecond,
+				}, record)
+			},
+		},
+		"host never suspended": {
+			data: s3Table(s3ResumeRecord(0, 0, 0), s3SuspendRecord(0, 0)),
+			validate: func(t *testing.T, record *S3PerformanceRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &S3PerformanceRecord{}, record)
+			},
+		},
+		"unknown records are skipped": {
+			data: s3Table(vendorRecord(0x3000, 1, 2, 3, 4), s3SuspendRecord(10, 30)),
+			validate: func(t *testing.T, record *S3PerformanceRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 20*time.Nanosecond, record.Suspend)
+			},
+		},
+		"wrong signature returns error": {
+			data: func() []byte {
+				data := s3Table(s3SuspendRecord(10, 30))
+				copy(data, "FPDT")
+				return data
+			}(),
+			validate: func(t *testing.T, record *S3PerformanceRecord, err error) {
+				require.ErrorContains(t, err, "not S3PT")
+				assert.Nil(t, record)
+			},
+		},
+		"truncated record returns error": {
+			data: s3Table(s3ResumeRecord(1, 2, 3)[:12]),
+			validate: func(t *testing.T, record *S3PerformanceRecord, err error) {
+				require.ErrorContains(t, err, "truncated record")
+				assert.Nil(t, record)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			record, err := ParseS3PerformanceTable(tc.data)
+			tc.validate(t, record, err)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package analysis provides statistics across many boot time records, to
+// compare retrieval methods or summarize a fleet of hosts.
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+// MethodAgreement compares the values of two methods for the same stage, over
+// the records containing both. It returns their mean absolute difference, the
+// Pearson correlation coefficient of their values, and the number of records
+// compared. The correlation is NaN when fewer than two records are compared or
+// when a method reports a constant value.
+func MethodAgreement(
+	records []*model.BootTimeRecord,
+	stage model.BootTimeStage,
+	a, b model.RetrievalMethod,
+) (meanAbsDiff time.Duration, correlation float64, n int) {
+	var xs, ys []float64
+	var sumAbsDiff time.Duration
+
+	for _, r := range records {
+		x, okA := r.Values[stage][a]
+		y, okB := r.Values[stage][b]
+		if !okA || !okB {
+			continue
+		}
+
+		diff := x - y
+		if diff < 0 {
+			diff = -diff
+		}
+		sumAbsDiff += diff
+
+		xs = append(xs, float64(x))
+		ys = append(ys, float64(y))
+	}
+
+	n = len(xs)
+	if n == 0 {
+		return 0, math.NaN(), 0
+	}
+
+	return sumAbsDiff / time.Duration(n), pearson(xs, ys), n
+}
+
+func pearson(xs, ys []float64) float64 {
+	if len(xs) < 2 {
+		return math.NaN()
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx := xs[i] - meanX
+		dy := ys[i] - meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	if varX == 0 || varY == 0 {
+		return math.NaN()
+	}
+
+	return cov / math.Sqrt(varX*varY)
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func firmwareRecord(acpi, analyze time.Duration) *model.BootTimeRecord {
+	values := map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {},
+	}
+	if acpi != 0 {
+		values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT] = acpi
+	}
+	if analyze != 0 {
+		values[model.BootTimeStageFirmware][model.RetrievalMethodSystemdAnalyze] = analyze
+	}
+	return &model.BootTimeRecord{Values: values}
+}
+
+func TestMethodAgreement(t *testing.T) {
+	tcs := map[string]struct {
+		records     []*model.BootTimeRecord
+		meanAbsDiff time.Duration
+		correlation float64
+		n           int
+	}{
+		"methods agreeing up to an offset": {
+			records: []*model.BootTimeRecord{
+				firmwareRecord(1000*time.Millisecond, 1010*time.Millisecond),
+				firmwareRecord(2000*time.Millisecond, 2010*time.Millisecond),
+				firmwareRecord(3000*time.Millisecond, 3010*time.Millisecond),
+				firmwareRecord(4000*time.Millisecond, 0),
+			},
+			meanAbsDiff: 10 * time.Millisecond,
+			correlation: 1,
+			n:           3,
+		},
+		"methods moving in opposite directions": {
+			records: []*model.BootTimeRecord{
+				firmwareRecord(1*time.Second, 3*time.Second),
+				firmwareRecord(3*time.Second, 1*time.Second),
+			},
+			meanAbsDiff: 2 * time.Second,
+			correlation: -1,
+			n:           2,
+		},
+		"no record with both methods": {
+			records: []*model.BootTimeRecord{
+				firmwareRecord(time.Second, 0),
+			},
+			meanAbsDiff: 0,
+			correlation: math.NaN(),
+			n:           0,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			meanAbsDiff, correlation, n := MethodAgreement(
+				tc.records,
+				model.BootTimeStageFirmware,
+				model.RetrievalMethodACPIFPDT,
+				model.RetrievalMethodSystemdAnalyze,
+			)
+			assert.Equal(t, tc.meanAbsDiff, meanAbsDiff, name)
+			assert.Equal(t, tc.n, n, name)
+			if math.IsNaN(tc.correlation) {
+				assert.True(t, math.IsNaN(correlation), name)
+			} else {
+				assert.InDelta(t, tc.correlation, correlation, 1e-9, name)
+			}
+		})
+	}
+}
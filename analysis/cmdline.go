@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/boreec/boottime/model"
+)
+
+// Groups of the records whose kernel command line does not give a value to
+// the parameter.
+const (
+	// CmdlineParamUnknown groups the records without a kernel command line.
+	CmdlineParamUnknown string = "(unknown)"
+	// CmdlineParamUnset groups the records whose command line does not have
+	// the parameter.
+	CmdlineParamUnset string = "(unset)"
+	// CmdlineParamSet groups the records whose command line has the parameter
+	// without value, such as quiet.
+	CmdlineParamSet string = "(set)"
+)
+
+// CmdlineParam returns the value of the parameter in the kernel command line,
+// and whether the parameter is present. As for the kernel, the last occurrence
+// of the parameter wins.
+func CmdlineParam(cmdline, name string) (value string, ok bool) {
+	for _, field := range strings.Fields(cmdline) {
+		key, v, _ := strings.Cut(field, "=")
+		if key == name {
+			value, ok = strings.Trim(v, `"`), true
+		}
+	}
+	return value, ok
+}
+
+// GroupByCmdlineParam groups the records by the value of the parameter in
+// their kernel command line. Records without a value are grouped under
+// CmdlineParamUnknown, CmdlineParamUnset or CmdlineParamSet.
+func GroupByCmdlineParam(records []*model.BootTimeRecord, name string) map[string][]*model.BootTimeRecord {
+	groups := make(map[string][]*model.BootTimeRecord)
+	for _, r := range records {
+		key := CmdlineParamGroup(r, name)
+		groups[key] = append(groups[key], r)
+	}
+	return groups
+}
+
+// CmdlineParamGroup returns the group of the record in GroupByCmdlineParam.
+func CmdlineParamGroup(r *model.BootTimeRecord, name string) string {
+	if r.Metadata == nil || r.Metadata.KernelCmdline == "" {
+		return CmdlineParamUnknown
+	}
+
+	value, ok := CmdlineParam(r.Metadata.KernelCmdline, name)
+	switch {
+	case !ok:
+		return CmdlineParamUnset
+	case value == "":
+		return CmdlineParamSet
+	default:
+		return value
+	}
+}
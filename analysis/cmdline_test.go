@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func cmdlineRecord(cmdline string) *model.BootTimeRecord {
+	return &model.BootTimeRecord{Metadata: &model.Metadata{KernelCmdline: cmdline}}
+}
+
+func TestGroupByCmdlineParam(t *testing.T) {
+	off := cmdlineRecord("BOOT_IMAGE=/vmlinuz root=/dev/sda1 mitigations=off quiet")
+	auto := cmdlineRecord("mitigations=off mitigations=auto")
+	unset := cmdlineRecord("root=/dev/sda1 quiet")
+	unknown := &model.BootTimeRecord{}
+
+	tcs := map[string]struct {
+		param    string
+		expected map[string][]*model.BootTimeRecord
+	}{
+		"parameter with value": {
+			param: "mitigations",
+			expected: map[string][]*model.BootTimeRecord{
+				"off":               {off},
+				"auto":              {auto},
+				CmdlineParamUnset:   {unset},
+				CmdlineParamUnknown: {unknown},
+			},
+		},
+		"parameter without value": {
+			param: "quiet",
+			expected: map[string][]*model.BootTimeRecord{
+				CmdlineParamSet:     {off, unset},
+				CmdlineParamUnset:   {auto},
+				CmdlineParamUnknown: {unknown},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			groups := GroupByCmdlineParam([]*model.BootTimeRecord{off, auto, unset, unknown}, tc.param)
+			assert.Equal(t, tc.expected, groups, name)
+		})
+	}
+}
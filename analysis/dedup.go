@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boreec/boottime/model"
+)
+
+// BootIdentity returns the key identifying the boot measured by the record,
+// and whether the record can be identified at all.
+//
+// The key is made of the machine id of the host and of the firmware and loader
+// durations of every method. These durations are derived from timestamps taken
+// before the kernel started, so they are identical for every record collected
+// during the same boot, for instance when the collection service is restarted,
+// and differ from one boot to the next. A record without any firmware or
+// loader duration cannot be identified. A record without machine id is
+// identified by its durations alone.
+func BootIdentity(r *model.BootTimeRecord) (string, bool) {
+	var key strings.Builder
+	if r.Metadata != nil {
+		key.WriteString(r.Metadata.MachineID)
+	}
+
+	identified := false
+	for _, stage := range []model.BootTimeStage{model.BootTimeStageFirmware, model.BootTimeStageLoader} {
+		for _, method := range (model.Selection{}).Methods() {
+			d, ok := r.Values[stage][method]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&key, "|%s/%s=%d", stage, method, d)
+			identified = true
+		}
+	}
+
+	return key.String(), identified
+}
+
+// DedupByBoot returns the records without those measuring the same boot as an
+// earlier record, according to BootIdentity. Records which cannot be
+// identified are always kept.
+func DedupByBoot(records []*model.BootTimeRecord) []*model.BootTimeRecord {
+	seen := make(map[string]struct{})
+	deduped := make([]*model.BootTimeRecord, 0, len(records))
+	for _, r := range records {
+		if key, ok := BootIdentity(r); ok {
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		deduped = append(deduped, r)
+	}
+	return deduped
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func bootRecord(machineID string, firmware, userspace time.Duration) *model.BootTimeRecord {
+	return &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageFirmware: {
+				model.RetrievalMethodACPIFPDT: firmware,
+			},
+			model.BootTimeStageUserspace: {
+				model.RetrievalMethodSystemdAnalyze: userspace,
+			},
+		},
+		Metadata: &model.Metadata{MachineID: machineID},
+	}
+}
+
+func TestDedupByBoot(t *testing.T) {
+	first := bootRecord("a", 1897*time.Millisecond, 3*time.Second)
+	restarted := bootRecord("a", 1897*time.Millisecond, 3100*time.Millisecond)
+	next := bootRecord("a", 1899*time.Millisecond, 3*time.Second)
+	otherHost := bootRecord("b", 1897*time.Millisecond, 3*time.Second)
+	unidentified := &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageUserspace: {
+				model.RetrievalMethodSystemdAnalyze: 3 * time.Second,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		records  []*model.BootTimeRecord
+		expected []*model.BootTimeRecord
+	}{
+		"same boot is kept once": {
+			records:  []*model.BootTimeRecord{first, restarted, next},
+			expected: []*model.BootTimeRecord{first, next},
+		},
+		"same durations on another host are kept": {
+			records:  []*model.BootTimeRecord{first, otherHost},
+			expected: []*model.BootTimeRecord{first, otherHost},
+		},
+		"records without firmware or loader are kept": {
+			records:  []*model.BootTimeRecord{unidentified, unidentified},
+			expected: []*model.BootTimeRecord{unidentified, unidentified},
+		},
+		"no records": {
+			records:  nil,
+			expected: []*model.BootTimeRecord{},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, DedupByBoot(tc.records), name)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+// TotalTimeHistogram counts the records by total duration reported by method,
+// in the buckets delimited by the ascending bounds: below bounds[0], between
+// each pair of consecutive bounds, and from the last bound. It returns
+// len(bounds)+1 counts. Records without a total for method are not counted.
+func TotalTimeHistogram(records []*model.BootTimeRecord, method model.RetrievalMethod, bounds []time.Duration) []int {
+	counts := make([]int, len(bounds)+1)
+	for _, r := range records {
+		total, ok := r.Values[model.BootTimeStageTotal][method]
+		if !ok {
+			continue
+		}
+
+		// A total equal to a bound belongs to the bucket starting at it.
+		bucket := sort.Search(len(bounds), func(i int) bool {
+			return bounds[i] > total
+		})
+		counts[bucket]++
+	}
+
+	return counts
+}
+
+// BottleneckHistogram counts, for each stage, the records in which the stage
+// reported by method is the longest one, the total aside. Stages tied for the
+// longest count for the earliest of them. Records without any stage for method
+// are not counted.
+func BottleneckHistogram(records []*model.BootTimeRecord, method model.RetrievalMethod) map[model.BootTimeStage]int {
+	counts := make(map[model.BootTimeStage]int)
+	for _, r := range records {
+		var bottleneck model.BootTimeStage
+		var longest time.Duration
+		for _, stage := range (model.Selection{}).Stages() {
+			if stage == model.BootTimeStageTotal {
+				continue
+			}
+
+			d, ok := r.Values[stage][method]
+			if !ok || (bottleneck != "" && d <= longest) {
+				continue
+			}
+			bottleneck, longest = stage, d
+		}
+
+		if bottleneck != "" {
+			counts[bottleneck]++
+		}
+	}
+
+	return counts
+}
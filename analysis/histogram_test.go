@@ -0,0 +1,119 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func totalRecord(method model.RetrievalMethod, total time.Duration) *model.BootTimeRecord {
+	return &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageTotal: {method: total},
+		},
+	}
+}
+
+func TestTotalTimeHistogram(t *testing.T) {
+	bounds := []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second}
+
+	tcs := map[string]struct {
+		records  []*model.BootTimeRecord
+		expected []int
+	}{
+		"records in every bucket": {
+			records: []*model.BootTimeRecord{
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 5*time.Second),
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 9*time.Second),
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 15*time.Second),
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 25*time.Second),
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 45*time.Second),
+			},
+			expected: []int{2, 1, 1, 1},
+		},
+		"total equal to a bound is counted above it": {
+			records: []*model.BootTimeRecord{
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 10*time.Second),
+				totalRecord(model.RetrievalMethodSystemdAnalyze, 30*time.Second),
+			},
+			expected: []int{0, 1, 0, 1},
+		},
+		"records of other methods are not counted": {
+			records: []*model.BootTimeRecord{
+				totalRecord(model.RetrievalMethodSystemdDBUS, 5*time.Second),
+			},
+			expected: []int{0, 0, 0, 0},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, TotalTimeHistogram(tc.records, model.RetrievalMethodSystemdAnalyze, bounds), name)
+		})
+	}
+}
+
+func stagesRecord(method model.RetrievalMethod, stages map[model.BootTimeStage]time.Duration) *model.BootTimeRecord {
+	r := &model.BootTimeRecord{Values: make(map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration)}
+	for stage, d := range stages {
+		r.Values[stage] = map[model.RetrievalMethod]time.Duration{method: d}
+	}
+	return r
+}
+
+func TestBottleneckHistogram(t *testing.T) {
+	tcs := map[string]struct {
+		records  []*model.BootTimeRecord
+		expected map[model.BootTimeStage]int
+	}{
+		"longest stage of each record is counted": {
+			records: []*model.BootTimeRecord{
+				stagesRecord(model.RetrievalMethodSystemdAnalyze, map[model.BootTimeStage]time.Duration{
+					model.BootTimeStageKernel:    time.Second,
+					model.BootTimeStageUserspace: 3 * time.Second,
+					model.BootTimeStageTotal:     4 * time.Second,
+				}),
+				stagesRecord(model.RetrievalMethodSystemdAnalyze, map[model.BootTimeStage]time.Duration{
+					model.BootTimeStageFirmware:  5 * time.Second,
+					model.BootTimeStageUserspace: 3 * time.Second,
+				}),
+				stagesRecord(model.RetrievalMethodSystemdAnalyze, map[model.BootTimeStage]time.Duration{
+					model.BootTimeStageUserspace: 2 * time.Second,
+				}),
+			},
+			expected: map[model.BootTimeStage]int{
+				model.BootTimeStageFirmware:  1,
+				model.BootTimeStageUserspace: 2,
+			},
+		},
+		"tie counts for the earliest stage": {
+			records: []*model.BootTimeRecord{
+				stagesRecord(model.RetrievalMethodSystemdAnalyze, map[model.BootTimeStage]time.Duration{
+					model.BootTimeStageKernel:    time.Second,
+					model.BootTimeStageUserspace: time.Second,
+				}),
+			},
+			expected: map[model.BootTimeStage]int{
+				model.BootTimeStageKernel: 1,
+			},
+		},
+		"records of other methods are not counted": {
+			records: []*model.BootTimeRecord{
+				stagesRecord(model.RetrievalMethodSystemdDBUS, map[model.BootTimeStage]time.Duration{
+					model.BootTimeStageUserspace: time.Second,
+				}),
+			},
+			expected: map[model.BootTimeStage]int{},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, BottleneckHistogram(tc.records, model.RetrievalMethodSystemdAnalyze), name)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/boreec/boottime/model"
+)
+
+// MetadataField is a field of the record metadata the records can be grouped
+// by.
+type MetadataField string
+
+// Metadata fields the records can be grouped by, named as their JSON key.
+const (
+	MetadataFieldBIOSVersion   MetadataField = "bios_version"
+	MetadataFieldBIOSDate      MetadataField = "bios_date"
+	MetadataFieldMachineID     MetadataField = "machine_id"
+	MetadataFieldHostname      MetadataField = "hostname"
+	MetadataFieldKernelVersion MetadataField = "kernel_version"
+)
+
+var metadataFields = []MetadataField{
+	MetadataFieldBIOSVersion,
+	MetadataFieldBIOSDate,
+	MetadataFieldMachineID,
+	MetadataFieldHostname,
+	MetadataFieldKernelVersion,
+}
+
+// ParseMetadataField returns the metadata field named s.
+func ParseMetadataField(s string) (MetadataField, error) {
+	field := MetadataField(s)
+	if !slices.Contains(metadataFields, field) {
+		return "", fmt.Errorf("unknown metadata field %q, expected one of %v", s, metadataFields)
+	}
+	return field, nil
+}
+
+// MetadataGroup returns the value of the metadata field of the record, or
+// CmdlineParamUnknown if the record does not have it, such as records collected
+// in a container without DMI or before the field was recorded.
+func MetadataGroup(r *model.BootTimeRecord, field MetadataField) string {
+	if r.Metadata == nil {
+		return CmdlineParamUnknown
+	}
+
+	var value string
+	switch field {
+	case MetadataFieldBIOSVersion:
+		value = r.Metadata.BIOSVersion
+	case MetadataFieldBIOSDate:
+		value = r.Metadata.BIOSDate
+	case MetadataFieldMachineID:
+		value = r.Metadata.MachineID
+	case MetadataFieldHostname:
+		value = r.Metadata.Hostname
+	case MetadataFieldKernelVersion:
+		value = r.Metadata.KernelVersion
+	}
+
+	if value == "" {
+		return CmdlineParamUnknown
+	}
+	return value
+}
+
+// GroupByMetadata groups the records by the value of the metadata field.
+func GroupByMetadata(records []*model.BootTimeRecord, field MetadataField) map[string][]*model.BootTimeRecord {
+	groups := make(map[string][]*model.BootTimeRecord)
+	for _, r := range records {
+		key := MetadataGroup(r, field)
+		groups[key] = append(groups[key], r)
+	}
+	return groups
+}
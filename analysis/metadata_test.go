@@ -0,0 +1,48 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByMetadata(t *testing.T) {
+	before := &model.BootTimeRecord{Metadata: &model.Metadata{BIOSVersion: "1.2.3", BIOSDate: "01/02/2025"}}
+	after := &model.BootTimeRecord{Metadata: &model.Metadata{BIOSVersion: "1.3.0", BIOSDate: "06/07/2025"}}
+	container := &model.BootTimeRecord{Metadata: &model.Metadata{KernelCmdline: "quiet"}}
+	unknown := &model.BootTimeRecord{}
+
+	groups := GroupByMetadata([]*model.BootTimeRecord{before, after, container, unknown, before}, MetadataFieldBIOSVersion)
+	assert.Equal(t, map[string][]*model.BootTimeRecord{
+		"1.2.3":             {before, before},
+		"1.3.0":             {after},
+		CmdlineParamUnknown: {container, unknown},
+	}, groups)
+}
+
+func TestGroupByMetadataHostname(t *testing.T) {
+	node1 := &model.BootTimeRecord{Metadata: &model.Metadata{Hostname: "node-1", KernelVersion: "6.8.0"}}
+	node2 := &model.BootTimeRecord{Metadata: &model.Metadata{Hostname: "node-2", KernelVersion: "6.8.0"}}
+	old := &model.BootTimeRecord{Metadata: &model.Metadata{BIOSVersion: "1.2.3"}}
+
+	assert.Equal(t, map[string][]*model.BootTimeRecord{
+		"node-1":            {node1},
+		"node-2":            {node2},
+		CmdlineParamUnknown: {old},
+	}, GroupByMetadata([]*model.BootTimeRecord{node1, node2, old}, MetadataFieldHostname))
+	assert.Equal(t, map[string][]*model.BootTimeRecord{
+		"6.8.0":             {node1, node2},
+		CmdlineParamUnknown: {old},
+	}, GroupByMetadata([]*model.BootTimeRecord{node1, node2, old}, MetadataFieldKernelVersion))
+}
+
+func TestParseMetadataField(t *testing.T) {
+	field, err := ParseMetadataField("bios_version")
+	require.NoError(t, err)
+	assert.Equal(t, MetadataFieldBIOSVersion, field)
+
+	_, err = ParseMetadataField("kernel_cmdline")
+	require.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"slices"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+const (
+	// postUpdateWindow is the number of records on each side of a record it is
+	// compared to.
+	postUpdateWindow int = 3
+	// postUpdateFactor is how many times slower than its neighbours the
+	// userspace of a record must be to be marked.
+	postUpdateFactor float64 = 2
+)
+
+// MarkPostUpdateBoots reports, for each record, whether it looks like the first
+// boot after a system update, which runs migrations or relabeling and is much
+// slower than the steady state. A record is marked when its userspace duration
+// is more than twice the median of the records surrounding it. Records are
+// expected in boot order, and records without userspace are never marked.
+func MarkPostUpdateBoots(records []*model.BootTimeRecord) []bool {
+	userspace := make([]time.Duration, len(records))
+	for i, r := range records {
+		userspace[i] = userspaceDuration(r)
+	}
+
+	marks := make([]bool, len(records))
+	for i, d := range userspace {
+		if d == 0 {
+			continue
+		}
+
+		var neighbours []time.Duration
+		for j := max(0, i-postUpdateWindow); j <= min(len(records)-1, i+postUpdateWindow); j++ {
+			if j != i && userspace[j] != 0 {
+				neighbours = append(neighbours, userspace[j])
+			}
+		}
+		if len(neighbours) == 0 {
+			continue
+		}
+
+		marks[i] = float64(d) > postUpdateFactor*float64(median(neighbours))
+	}
+
+	return marks
+}
+
+// userspaceDuration returns the userspace duration of the first method
+// reporting it in canonical order, or zero.
+func userspaceDuration(r *model.BootTimeRecord) time.Duration {
+	for _, method := range (model.Selection{}).Methods() {
+		if d, ok := r.Values[model.BootTimeStageUserspace][method]; ok {
+			return d
+		}
+	}
+	return 0
+}
+
+func median(durations []time.Duration) time.Duration {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func userspaceRecords(durations ...time.Duration) []*model.BootTimeRecord {
+	records := make([]*model.BootTimeRecord, len(durations))
+	for i, d := range durations {
+		records[i] = &model.BootTimeRecord{
+			Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{},
+		}
+		if d != 0 {
+			records[i].Values[model.BootTimeStageUserspace] = map[model.RetrievalMethod]time.Duration{
+				model.RetrievalMethodSystemdAnalyze: d,
+			}
+		}
+	}
+	return records
+}
+
+func TestMarkPostUpdateBoots(t *testing.T) {
+	tcs := map[string]struct {
+		records  []*model.BootTimeRecord
+		expected []bool
+	}{
+		"slow boot among steady ones": {
+			records:  userspaceRecords(5*time.Second, 6*time.Second, 5*time.Second, 20*time.Second, 5*time.Second, 6*time.Second),
+			expected: []bool{false, false, false, true, false, false},
+		},
+		"first record after an update": {
+			records:  userspaceRecords(30*time.Second, 5*time.Second, 5*time.Second, 6*time.Second),
+			expected: []bool{true, false, false, false},
+		},
+		"steady boots": {
+			records:  userspaceRecords(5*time.Second, 7*time.Second, 6*time.Second),
+			expected: []bool{false, false, false},
+		},
+		"records without userspace are not marked": {
+			records:  userspaceRecords(0, 5*time.Second),
+			expected: []bool{false, false},
+		},
+		"single record": {
+			records:  userspaceRecords(30 * time.Second),
+			expected: []bool{false},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, MarkPostUpdateBoots(tc.records), name)
+		})
+	}
+}
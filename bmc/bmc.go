@@ -0,0 +1,91 @@
+// Package bmc retrieves the firmware (POST) duration recorded by the baseboard
+// management controller of servers, through the IPMI System Event Log.
+package bmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// selTimeLayout is the layout of the date and time columns of `ipmitool sel
+// elist`.
+const selTimeLayout string = "01/02/2006 15:04:05"
+
+// ErrBootEventsNotFound is returned when the event log does not contain a
+// system boot followed by an OS boot event.
+var ErrBootEventsNotFound = errors.New("system boot and OS boot events not found in SEL")
+
+// BootTimeRecord contains the boot time stages provided by the BMC.
+type BootTimeRecord struct {
+	// Firmware is the time between the last "System Boot Initiated" event and
+	// the following "OS Boot" event, logged when the firmware hands over to
+	// the boot device. The SEL has a one second resolution.
+	Firmware time.Duration
+}
+
+// RetrieveBootTime runs `ipmitool sel elist` and parses the events of the
+// last boot. It requires access to the BMC, usually as root.
+func RetrieveBootTime() (*BootTimeRecord, error) {
+	return RetrieveBootTimeContext(context.Background())
+}
+
+// RetrieveBootTimeContext is RetrieveBootTime, killing ipmitool when ctx is
+// done, since an unresponsive BMC can make it hang.
+func RetrieveBootTimeContext(ctx context.Context) (*BootTimeRecord, error) {
+	cmd := exec.CommandContext(ctx, "ipmitool", "sel", "elist")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	btr, err := ParseSELOutput(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("parsing command output: %w", err)
+	}
+
+	return btr, nil
+}
+
+// ParseSELOutput parses the output of `ipmitool sel elist`, whose lines look
+// like:
+//
+//	1a | 04/10/2024 | 10:00:02 | System Boot Initiated #0x6f | Initiated by power up | Asserted
+func ParseSELOutput(output string) (*BootTimeRecord, error) {
+	var bootStart, osBoot time.Time
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 4 {
+			continue
+		}
+
+		ts, err := time.Parse(selTimeLayout, strings.TrimSpace(fields[1])+" "+strings.TrimSpace(fields[2]))
+		if err != nil {
+			// Entries logged before the BMC clock is set have no date.
+			continue
+		}
+
+		sensor := strings.TrimSpace(fields[3])
+		switch {
+		case strings.HasPrefix(sensor, "System Boot Initiated"):
+			bootStart = ts
+			osBoot = time.Time{}
+		case strings.HasPrefix(sensor, "OS Boot") && !bootStart.IsZero() && osBoot.IsZero():
+			osBoot = ts
+		}
+	}
+
+	if bootStart.IsZero() || osBoot.IsZero() {
+		return nil, ErrBootEventsNotFound
+	}
+
+	if osBoot.Before(bootStart) {
+		return nil, fmt.Errorf("OS boot event at %s before system boot event at %s", osBoot, bootStart)
+	}
+
+	return &BootTimeRecord{Firmware: osBoot.Sub(bootStart)}, nil
+}
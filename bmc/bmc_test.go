@@ -0,0 +1,53 @@
+package bmc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSELOutput(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, btr *BootTimeRecord, err error, name string)
+	}{
+		"parse last boot successfully": {
+			input: `   1 | 04/09/2024 | 09:00:00 | System Boot Initiated #0x6f | Initiated by power up | Asserted
+   2 | 04/09/2024 | 09:00:58 | OS Boot #0x6c | C: boot completed | Asserted
+   3 | Pre-Init  |0000000010| Power Unit #0x01 | Power off/down | Asserted
+   4 | 04/10/2024 | 10:00:02 | System Boot Initiated #0x6f | Initiated by hard reset | Asserted
+   5 | 04/10/2024 | 10:00:03 | System Firmware Progress #0x6d | Motherboard initialization | Asserted
+   6 | 04/10/2024 | 10:00:45 | OS Boot #0x6c | C: boot completed | Asserted
+   7 | 04/10/2024 | 10:03:00 | OS Boot #0x6c | C: boot completed | Asserted`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, btr, name)
+				assert.Equal(t, 43*time.Second, btr.Firmware, name)
+			},
+		},
+		"parse log without OS boot returns error": {
+			input: `   1 | 04/10/2024 | 10:00:02 | System Boot Initiated #0x6f | Initiated by power up | Asserted`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.ErrorIs(t, err, ErrBootEventsNotFound, name)
+				require.Nil(t, btr, name)
+			},
+		},
+		"parse empty log returns error": {
+			input: "",
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.ErrorIs(t, err, ErrBootEventsNotFound, name)
+				require.Nil(t, btr, name)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			btr, err := ParseSELOutput(tc.input)
+			tc.validate(t, btr, err, name)
+		})
+	}
+}
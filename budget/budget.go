@@ -0,0 +1,137 @@
+// Package budget checks boot time records against per-stage maximum durations
+// defined for hardware profiles.
+//
+// A budget file lists profiles, each matching one or several DMI product
+// names:
+//
+//	profiles:
+//	  - name: laptop
+//	    product_names: ["ThinkPad X1 Carbon Gen 11"]
+//	    max:
+//	      firmware: 3s
+//	      total: 20s
+//	  - name: default
+//	    max:
+//	      total: 30s
+//
+// A profile without product names matches any product, which makes it a
+// fallback when listed last.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"gopkg.in/yaml.v3"
+)
+
+const pathDMIProductName string = "/sys/class/dmi/id/product_name"
+
+// ErrNoMatchingProfile is returned when no profile matches the host product.
+var ErrNoMatchingProfile = errors.New("no budget profile matches the product")
+
+// Profile is a set of per-stage maximum durations applying to some products.
+type Profile struct {
+	Name         string                                `yaml:"name"`
+	ProductNames []string                              `yaml:"product_names"`
+	Max          map[model.BootTimeStage]time.Duration `yaml:"max"`
+}
+
+type file struct {
+	Profiles []Profile `yaml:"profiles"`
+}
+
+// Budget is the per-stage maximum durations selected for the host.
+type Budget struct {
+	Profile string
+	Max     map[model.BootTimeStage]time.Duration
+}
+
+// Violation is a stage duration exceeding its budget.
+type Violation struct {
+	Stage  model.BootTimeStage
+	Method model.RetrievalMethod
+	Value  time.Duration
+	Max    time.Duration
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s (%s) took %s, budget is %s", v.Stage, v.Method, v.Value, v.Max)
+}
+
+// LoadProfiles reads the profiles of the budget file and validates their
+// stage names.
+func LoadProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", path, err)
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unmarshalling budget file: %w", err)
+	}
+
+	for _, p := range f.Profiles {
+		for stage := range p.Max {
+			if _, err := model.ParseBootTimeStage(string(stage)); err != nil {
+				return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+			}
+		}
+	}
+
+	return f.Profiles, nil
+}
+
+// MatchProfile returns the budget of the first profile matching the DMI
+// product name of the host.
+func MatchProfile(profiles []Profile) (*Budget, error) {
+	data, err := os.ReadFile(pathDMIProductName)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", pathDMIProductName, err)
+	}
+
+	return matchProfile(strings.TrimSpace(string(data)), profiles)
+}
+
+func matchProfile(productName string, profiles []Profile) (*Budget, error) {
+	for _, p := range profiles {
+		if len(p.ProductNames) == 0 || slices.Contains(p.ProductNames, productName) {
+			return &Budget{Profile: p.Name, Max: p.Max}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w %q", ErrNoMatchingProfile, productName)
+}
+
+// Check returns every value of the record exceeding the budget of its stage,
+// whatever the retrieval method, in canonical stage order.
+func (b Budget) Check(r *model.BootTimeRecord) []Violation {
+	var violations []Violation
+	for _, stage := range (model.Selection{}).Stages() {
+		limit, ok := b.Max[stage]
+		if !ok {
+			continue
+		}
+
+		for _, method := range (model.Selection{}).Methods() {
+			d, ok := r.Values[stage][method]
+			if ok && d > limit {
+				violations = append(violations, Violation{
+					Stage:  stage,
+					Method: method,
+					Value:  d,
+					Max:    limit,
+				})
+			}
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,104 @@
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProfilesAndCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budgets.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`profiles:
+  - name: laptop
+    product_names: ["ThinkPad X1 Carbon Gen 11"]
+    max:
+      firmware: 3s
+      total: 20s
+  - name: default
+    max:
+      total: 30s
+`), 0o600))
+
+	profiles, err := LoadProfiles(path)
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+
+	record := &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageFirmware: {
+				model.RetrievalMethodACPIFPDT:    2 * time.Second,
+				model.RetrievalMethodSystemdDBUS: 4 * time.Second,
+			},
+			model.BootTimeStageTotal: {
+				model.RetrievalMethodSystemdDBUS: 25 * time.Second,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		productName string
+		validate    func(t *testing.T, b *Budget, err error, name string)
+	}{
+		"matching product uses its profile": {
+			productName: "ThinkPad X1 Carbon Gen 11",
+			validate: func(t *testing.T, b *Budget, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, b, name)
+				assert.Equal(t, "laptop", b.Profile, name)
+				assert.Equal(t, []Violation{
+					{
+						Stage:  model.BootTimeStageFirmware,
+						Method: model.RetrievalMethodSystemdDBUS,
+						Value:  4 * time.Second,
+						Max:    3 * time.Second,
+					},
+					{
+						Stage:  model.BootTimeStageTotal,
+						Method: model.RetrievalMethodSystemdDBUS,
+						Value:  25 * time.Second,
+						Max:    20 * time.Second,
+					},
+				}, b.Check(record), name)
+			},
+		},
+		"unknown product uses the fallback profile": {
+			productName: "Standard PC (Q35 + ICH9, 2009)",
+			validate: func(t *testing.T, b *Budget, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, b, name)
+				assert.Equal(t, "default", b.Profile, name)
+				assert.Empty(t, b.Check(record), name)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			b, err := matchProfile(tc.productName, profiles)
+			tc.validate(t, b, err, name)
+		})
+	}
+}
+
+func TestMatchProfileWithoutFallback(t *testing.T) {
+	_, err := matchProfile("Unknown", []Profile{{Name: "laptop", ProductNames: []string{"X1"}}})
+	require.ErrorIs(t, err, ErrNoMatchingProfile)
+}
+
+func TestLoadProfilesWithUnknownStage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budgets.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`profiles:
+  - name: default
+    max:
+      bios: 3s
+`), 0o600))
+
+	_, err := LoadProfiles(path)
+	require.Error(t, err)
+}
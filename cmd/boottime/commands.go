@@ -0,0 +1,421 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boreec/boottime/exec"
+	"github.com/boreec/boottime/model"
+)
+
+// command is a subcommand of boottime, invoked as `boottime <name> ...`.
+type command struct {
+	name        string
+	description string
+	// setup registers the command flags on fs and returns the function running
+	// the command with the positional arguments left after parsing.
+	setup func(fs *flag.FlagSet) func(args []string) error
+}
+
+var commands = []command{
+	{
+		name:        "probe",
+		description: "print the platform of the host and which retrieval methods work on it",
+		setup:       setupProbe,
+	},
+	{
+		name:        "critical-chain",
+		description: "print the chain of units which gated the completion of the boot, from systemd-analyze critical-chain",
+		setup:       setupCriticalChain,
+	},
+	{
+		name:        "collect",
+		description: "receive records pushed by hosts and append them to a jsonl file",
+		setup:       setupCollect,
+	},
+	{
+		name:        "stats",
+		description: "print mean, median, p99, interquartile range, min, max and standard deviation of a jsonl file as JSON",
+		setup:       setupStats,
+	},
+	{
+		name:        "agreement",
+		description: "print the mean absolute difference and correlation of each pair of methods in a jsonl file",
+		setup:       setupAgreement,
+	},
+	{
+		name:        "buckets",
+		description: "print how many records of a jsonl file have a total duration in each bucket",
+		setup:       setupBuckets,
+	},
+	{
+		name:        "bottleneck",
+		description: "print in how many records of a jsonl file each stage is the longest one",
+		setup:       setupBottleneck,
+	},
+	{
+		name:        "deltas",
+		description: "print how much longer each stage of the records of a jsonl file took than in a reference jsonl file",
+		setup:       setupDeltas,
+	},
+	{
+		name:        "diff",
+		description: "print how much the average of each stage and method changed from a jsonl file to another",
+		setup:       setupDiff,
+	},
+	{
+		name:        "recompute",
+		description: "recompute the records of a jsonl file from their raw timestamps into another jsonl file",
+		setup:       setupRecompute,
+	},
+	{
+		name:        "merge",
+		description: "merge the records of several jsonl files into one, optionally without duplicates",
+		setup:       setupMerge,
+	},
+	{
+		name:        "strip",
+		description: "copy the records of a jsonl file into another jsonl file without the values of some methods",
+		setup:       setupStrip,
+	},
+	{
+		name:        "import-legacy",
+		description: "convert the records of a jsonl file written by bootprobe into another jsonl file",
+		setup:       setupImportLegacy,
+	},
+	{
+		name:        "explore",
+		description: "explore the records of a jsonl file interactively",
+		setup:       setupExplore,
+	},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func runCommand(c command, arguments []string) error {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	run := c.setup(fs)
+	if err := fs.Parse(arguments); err != nil {
+		return err
+	}
+	return run(fs.Args())
+}
+
+func setupProbe(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("expected no arg, found %d", len(args))
+		}
+
+		return exec.Probe(os.Stdout)
+	}
+}
+
+func setupCriticalChain(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("expected no arg, found %d", len(args))
+		}
+
+		return exec.PrintCriticalChain(os.Stdout)
+	}
+}
+
+func setupCollect(fs *flag.FlagSet) func(args []string) error {
+	listen := fs.String("listen", ":9999", "address to listen on")
+
+	return func(args []string) error {
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return exec.CollectRemoteRecords(*listen, fileName, os.Stderr)
+	}
+}
+
+// printWarnings prints the warnings of a subcommand to stderr, and returns
+// its error.
+func printWarnings(warnings []exec.Warning, err error) error {
+	exec.PrintWarnings(os.Stderr, warnings)
+	return err
+}
+
+func setupStats(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return printWarnings(exec.PrintStatsSummary(fileName))
+	}
+}
+
+func setupAgreement(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return printWarnings(exec.PrintMethodAgreement(fileName))
+	}
+}
+
+func setupBuckets(fs *flag.FlagSet) func(args []string) error {
+	bounds := []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second}
+	fs.Func("bounds", "comma-separated ascending durations delimiting the buckets (default 10s,20s,30s)", func(s string) error {
+		bounds = nil
+		for _, field := range strings.Split(s, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(field))
+			if err != nil {
+				return err
+			}
+			if len(bounds) > 0 && d <= bounds[len(bounds)-1] {
+				return fmt.Errorf("bound %s is not greater than %s", d, bounds[len(bounds)-1])
+			}
+			bounds = append(bounds, d)
+		}
+		return nil
+	})
+
+	method := model.RetrievalMethodSystemdAnalyze
+	fs.Func("method", "retrieval method of the total durations (default systemd_analyze)", func(s string) error {
+		m, err := model.ParseRetrievalMethod(s)
+		if err != nil {
+			return err
+		}
+		method = m
+		return nil
+	})
+
+	return func(args []string) error {
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return printWarnings(exec.PrintTotalTimeHistogram(fileName, method, bounds))
+	}
+}
+
+func setupBottleneck(fs *flag.FlagSet) func(args []string) error {
+	method := model.RetrievalMethodCollapsed
+	fs.Func("method", "retrieval method of the compared durations (default the consensus of every method)", func(s string) error {
+		m, err := model.ParseRetrievalMethod(s)
+		if err != nil {
+			return err
+		}
+		method = m
+		return nil
+	})
+
+	return func(args []string) error {
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return printWarnings(exec.PrintBottlenecks(fileName, method))
+	}
+}
+
+func setupDeltas(fs *flag.FlagSet) func(args []string) error {
+	ref := fs.String("ref", "", "jsonl file of the reference records, such as a known-good baseline boot")
+
+	method := model.RetrievalMethodSystemdAnalyze
+	fs.Func("method", "retrieval method of the compared durations (default systemd_analyze)", func(s string) error {
+		m, err := model.ParseRetrievalMethod(s)
+		if err != nil {
+			return err
+		}
+		method = m
+		return nil
+	})
+
+	return func(args []string) error {
+		if _, err := jsonlFileArg([]string{*ref}); err != nil {
+			return fmt.Errorf("flag --ref: %w", err)
+		}
+
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return printWarnings(exec.PrintDeltas(*ref, fileName, method))
+	}
+}
+
+func setupDiff(fs *flag.FlagSet) func(args []string) error {
+	prettify := fs.Bool("p", false, "print a table, with the regressions colored on a terminal, instead of JSON")
+	tolerance := fs.Duration("tolerance", time.Millisecond, "changes up to this duration, such as the jitter of the clocks, are not reported")
+
+	return func(args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("expected 2 args for before and after jsonl files, found %d", len(args))
+		}
+
+		before, err := jsonlFileArg(args[:1])
+		if err != nil {
+			return err
+		}
+		after, err := jsonlFileArg(args[1:])
+		if err != nil {
+			return err
+		}
+
+		return printWarnings(exec.PrintRecordsDiff(before, after, *prettify, *tolerance))
+	}
+}
+
+func setupRecompute(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("expected 2 args for input and output jsonl files, found %d", len(args))
+		}
+
+		in, err := jsonlFileArg(args[:1])
+		if err != nil {
+			return err
+		}
+		out, err := jsonlFileArg(args[1:])
+		if err != nil {
+			return err
+		}
+		if in == out {
+			return errors.New("output file must differ from input file")
+		}
+
+		return printWarnings(exec.RecomputeFile(in, out))
+	}
+}
+
+func setupStrip(fs *flag.FlagSet) func(args []string) error {
+	var methods []model.RetrievalMethod
+	fs.Func("method", "comma-separated retrieval methods to remove from the records", func(s string) error {
+		for _, name := range strings.Split(s, ",") {
+			method, err := model.ParseRetrievalMethod(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			methods = append(methods, method)
+		}
+		return nil
+	})
+
+	return func(args []string) error {
+		if len(methods) == 0 {
+			return errors.New("flag --method required")
+		}
+		if len(args) != 2 {
+			return fmt.Errorf("expected 2 args for input and output jsonl files, found %d", len(args))
+		}
+
+		in, err := jsonlFileArg(args[:1])
+		if err != nil {
+			return err
+		}
+		out, err := jsonlFileArg(args[1:])
+		if err != nil {
+			return err
+		}
+		if in == out {
+			return errors.New("output file must differ from input file")
+		}
+
+		return printWarnings(exec.StripFile(in, out, methods))
+	}
+}
+
+func setupMerge(fs *flag.FlagSet) func(args []string) error {
+	out := fs.String("o", "", "jsonl file to write the merged records to")
+	dedup := fs.Bool("dedup", false, "leave out the records identical to an earlier one")
+
+	return func(args []string) error {
+		if _, err := jsonlFileArg([]string{*out}); err != nil {
+			return fmt.Errorf("flag -o: %w", err)
+		}
+		if len(args) == 0 {
+			return errors.New("expected at least 1 arg for input jsonl files, found 0")
+		}
+		for _, in := range args {
+			if _, err := jsonlFileArg([]string{in}); err != nil {
+				return err
+			}
+		}
+
+		merge, err := exec.MergeFiles(*out, *dedup, args...)
+		if err != nil {
+			return err
+		}
+		exec.PrintWarnings(os.Stderr, merge.Warnings)
+
+		fmt.Printf("Wrote %d records to %s, %d duplicates left out.\n", merge.Written, *out, merge.Duplicates)
+		return nil
+	}
+}
+
+func setupImportLegacy(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("expected 2 args for input and output jsonl files, found %d", len(args))
+		}
+
+		in, err := jsonlFileArg(args[:1])
+		if err != nil {
+			return err
+		}
+		out, err := jsonlFileArg(args[1:])
+		if err != nil {
+			return err
+		}
+		if in == out {
+			return errors.New("output file must differ from input file")
+		}
+
+		imp, err := exec.ImportLegacyFile(in, out)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote %d records to %s, %d converted from bootprobe.\n", imp.Converted+imp.Copied, out, imp.Converted)
+		return nil
+	}
+}
+
+func setupExplore(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		fileName, err := jsonlFileArg(args)
+		if err != nil {
+			return err
+		}
+
+		return exec.Explore(fileName, os.Stdin, os.Stdout)
+	}
+}
+
+// jsonlFileArg returns the single jsonl file name expected in args.
+func jsonlFileArg(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected 1 arg for jsonl file, found %d", len(args))
+	}
+
+	if !strings.HasSuffix(args[0], ".jsonl") {
+		return "", errors.New("argument should be a file name with .jsonl suffix")
+	}
+
+	return args[0], nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	// Registered here since the completion refers to every command.
+	commands = append(commands, command{
+		name:        "completion",
+		description: "print the shell completion script for bash, zsh or fish",
+		setup:       setupCompletion,
+	})
+}
+
+func setupCompletion(fs *flag.FlagSet) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected 1 arg for shell, found %d", len(args))
+		}
+
+		return writeCompletion(os.Stdout, args[0])
+	}
+}
+
+// completionFlag is a flag as seen by the completion scripts.
+type completionFlag struct {
+	name  string
+	usage string
+}
+
+// completionFlags returns the flags registered by define, in lexical order.
+func completionFlags(define func(fs *flag.FlagSet)) []completionFlag {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	define(fs)
+
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, completionFlag{name: f.Name, usage: f.Usage})
+	})
+	return flags
+}
+
+func rootCompletionFlags() []completionFlag {
+	return completionFlags(func(fs *flag.FlagSet) {
+		defineFlags(fs, &Flags{})
+	})
+}
+
+func commandCompletionFlags(c command) []completionFlag {
+	return completionFlags(func(fs *flag.FlagSet) {
+		c.setup(fs)
+	})
+}
+
+// flagArg returns the flag as typed on the command line. The flag package
+// accepts both forms, but single letters are conventionally used with one dash.
+func flagArg(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func flagArgs(flags []completionFlag) string {
+	args := make([]string, len(flags))
+	for i, f := range flags {
+		args[i] = flagArg(f.name)
+	}
+	return strings.Join(args, " ")
+}
+
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w)
+	case "zsh":
+		return writeZshCompletion(w)
+	case "fish":
+		return writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q, expected one of bash, zsh, fish", shell)
+	}
+}
+
+func writeBashCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# bash completion for boottime\n")
+	b.WriteString("_boottime() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    local opts\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s) opts=%q ;;\n", c.name, flagArgs(commandCompletionFlags(c)))
+	}
+	fmt.Fprintf(&b, "        *) opts=%q ;;\n", flagArgs(rootCompletionFlags()))
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	b.WriteString("    elif [[ $COMP_CWORD -eq 1 ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\") $(compgen -f -X '!*.jsonl' -- \"$cur\"))\n", commandNames())
+	b.WriteString("    else\n")
+	b.WriteString("        COMPREPLY=($(compgen -f -X '!*.jsonl' -- \"$cur\"))\n")
+	b.WriteString("    fi\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o filenames -o plusdirs -F _boottime boottime\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeZshCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("#compdef boottime\n")
+	b.WriteString("_boottime() {\n")
+	b.WriteString("    local -a opts\n")
+	b.WriteString("    case $words[2] in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s) opts=(%s) ;;\n", c.name, flagArgs(commandCompletionFlags(c)))
+	}
+	fmt.Fprintf(&b, "        *) opts=(%s) ;;\n", flagArgs(rootCompletionFlags()))
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ $PREFIX == -* ]]; then\n")
+	b.WriteString("        compadd -- $opts\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    if (( CURRENT == 2 )); then\n")
+	fmt.Fprintf(&b, "        compadd -- %s\n", commandNames())
+	b.WriteString("    fi\n")
+	b.WriteString("    _files -g '*.jsonl'\n")
+	b.WriteString("}\n")
+	b.WriteString("compdef _boottime boottime\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFishCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# fish completion for boottime\n")
+	b.WriteString("complete -c boottime -f\n")
+	b.WriteString("complete -c boottime -a '(__fish_complete_suffix .jsonl)'\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c boottime -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.description))
+		for _, f := range commandCompletionFlags(c) {
+			fmt.Fprintf(&b, "complete -c boottime -n '__fish_seen_subcommand_from %s' %s -d %s\n", c.name, fishFlag(f.name), fishQuote(f.usage))
+		}
+	}
+	for _, f := range rootCompletionFlags() {
+		fmt.Fprintf(&b, "complete -c boottime -n __fish_use_subcommand %s -d %s\n", fishFlag(f.name), fishQuote(f.usage))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fishFlag(name string) string {
+	if len(name) == 1 {
+		return "-s " + name
+	}
+	return "-l " + name
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
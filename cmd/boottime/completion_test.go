@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeCompletion(&buf, shell))
+
+			script := buf.String()
+			for _, c := range commands {
+				assert.Contains(t, script, c.name)
+				for _, f := range commandCompletionFlags(c) {
+					assert.Contains(t, script, f.name)
+				}
+			}
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			defineFlags(fs, &Flags{})
+			fs.VisitAll(func(f *flag.Flag) {
+				assert.Contains(t, script, f.Name)
+			})
+		})
+	}
+}
+
+func TestWriteCompletionUnknownShell(t *testing.T) {
+	var buf bytes.Buffer
+	require.Error(t, writeCompletion(&buf, "powershell"))
+}
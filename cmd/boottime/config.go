@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configKeyFile is the config key of the jsonl file, used when the command
+// line has no positional argument.
+const configKeyFile = "file"
+
+// config holds the defaults read from a config file. Values are keyed by the
+// long name of the flag they set, lists being joined with commas as on the
+// command line.
+type config struct {
+	FileName string
+	Values   map[string]string
+}
+
+// defaultConfigPath returns the config file read when --config is not set,
+// ~/.config/boottime/config.yaml unless XDG_CONFIG_HOME is set.
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "boottime", "config.yaml"), nil
+}
+
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshalling config file: %w", err)
+	}
+
+	c := &config{Values: make(map[string]string, len(raw))}
+	for key, v := range raw {
+		value, err := configValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("config key %q: %w", key, err)
+		}
+		if key == configKeyFile {
+			c.FileName = value
+			continue
+		}
+		c.Values[key] = value
+	}
+	return c, nil
+}
+
+// configValue returns the flag value of a YAML scalar or list of scalars.
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool, int, float64:
+		return fmt.Sprint(v), nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			if _, ok := e.([]any); ok {
+				return "", errors.New("nested lists are not supported")
+			}
+			value, err := configValue(e)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v, expected a scalar or a list", v)
+	}
+}
+
+// apply sets on fs the flags of the config file which were not set on the
+// command line, so that explicit flags take precedence over the file.
+func (c *config) apply(fs *flag.FlagSet) error {
+	var explicit []*flag.Flag
+	fs.Visit(func(f *flag.Flag) {
+		explicit = append(explicit, f)
+	})
+
+	for _, name := range slices.Sorted(maps.Keys(c.Values)) {
+		f := fs.Lookup(name)
+		// Short flags are aliases of long ones, the file only uses the latter.
+		if f == nil || len(name) == 1 || name == "config" {
+			return fmt.Errorf("unknown config key %q", name)
+		}
+
+		if slices.ContainsFunc(explicit, func(e *flag.Flag) bool {
+			return e.Name == name || sameValue(e.Value, f.Value)
+		}) {
+			continue
+		}
+
+		if err := fs.Set(name, c.Values[name]); err != nil {
+			return fmt.Errorf("config key %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// sameValue reports whether a and b point to the same variable, which is the
+// case of a flag and its short alias.
+func sameValue(a, b flag.Value) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != reflect.Pointer || vb.Kind() != reflect.Pointer {
+		return false
+	}
+	return va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
+
+// applyConfig applies to fs the config file at path, or the one at
+// defaultPath if path is empty and it exists. It returns the jsonl file name
+// defined by the config file, if any.
+func applyConfig(fs *flag.FlagSet, path, defaultPath string) (string, error) {
+	if path == "" {
+		if defaultPath == "" {
+			return "", nil
+		}
+		if _, err := os.Stat(defaultPath); errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		path = defaultPath
+	}
+
+	c, err := loadConfig(path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.apply(fs); err != nil {
+		return "", fmt.Errorf("applying config file %s: %w", path, err)
+	}
+	return c.FileName, nil
+}
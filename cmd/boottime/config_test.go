@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgsConfig(t *testing.T) {
+	tcs := map[string]struct {
+		config    string
+		arguments []string
+		validate  func(t *testing.T, args *Args, flags *Flags, err error)
+	}{
+		"config file sets the flags": {
+			config: `format: triples
+exclude-method: [efi_var, acpi_fpdt]
+budget-file: budget.yaml
+average-boot-records: true
+file: records.jsonl
+`,
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "records.jsonl", args.FileName)
+				assert.Equal(t, formatTriples, flags.Format)
+				assert.Equal(t, "budget.yaml", flags.BudgetFile)
+				assert.True(t, flags.RunAggregate)
+				assert.Equal(t, []model.RetrievalMethod{model.RetrievalMethodEFIVar, model.RetrievalMethodACPIFPDT}, flags.Selection.ExcludedMethods)
+			},
+		},
+		"explicit flags override the config file": {
+			config: `format: triples
+exclude-method: efi_var
+file: records.jsonl
+`,
+			arguments: []string{"-A", "--format", "csv", "--exclude-method", "systemd_dbus", "other.jsonl"},
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "other.jsonl", args.FileName)
+				assert.Equal(t, formatCSV, flags.Format)
+				assert.Equal(t, []model.RetrievalMethod{model.RetrievalMethodSystemdDBUS}, flags.Selection.ExcludedMethods)
+			},
+		},
+		"explicit short flag overrides its long name in the config file": {
+			config: `prettify: false
+average-boot-records: true
+`,
+			arguments: []string{"-p", "records.jsonl"},
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.True(t, flags.Prettify)
+			},
+		},
+		"config file values are validated": {
+			config:    `format: xml`,
+			arguments: []string{"-A", "records.jsonl"},
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.ErrorContains(t, err, "xml")
+			},
+		},
+		"unknown config key returns error": {
+			config:    `potatoes: true`,
+			arguments: []string{"-A", "records.jsonl"},
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.ErrorContains(t, err, "unknown config key")
+			},
+		},
+		"short flag config key returns error": {
+			config:    `A: true`,
+			arguments: []string{"records.jsonl"},
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.ErrorContains(t, err, "unknown config key")
+			},
+		},
+		"config file applies the flag validations": {
+			config:    `all-columns: true`,
+			arguments: []string{"-A", "records.jsonl"},
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--all-columns requires --format csv")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0o600))
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, append([]string{"--config", path}, tc.arguments...), "", &args, &flags)
+			tc.validate(t, &args, &flags, err)
+		})
+	}
+}
+
+func TestParseArgsMissingConfigFile(t *testing.T) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var args Args
+	var flags Flags
+	err := parseArgs(fs, []string{"--config", filepath.Join(t.TempDir(), "missing.yaml"), "-A", "records.jsonl"}, "", &args, &flags)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseArgsDefaultConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	defaultConfig := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(defaultConfig, []byte("file: records.jsonl\naverage-boot-records: true\n"), 0o600))
+	other := filepath.Join(dir, "other.yaml")
+	require.NoError(t, os.WriteFile(other, []byte("retrieve-boot-time: true\n"), 0o600))
+
+	tcs := map[string]struct {
+		arguments     []string
+		defaultConfig string
+		validate      func(t *testing.T, args *Args, flags *Flags, err error)
+	}{
+		"default config file is read without --config": {
+			defaultConfig: defaultConfig,
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "records.jsonl", args.FileName)
+				assert.True(t, flags.RunAggregate)
+			},
+		},
+		"--config replaces the default config file": {
+			arguments:     []string{"--config", other, "results.jsonl"},
+			defaultConfig: defaultConfig,
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "results.jsonl", args.FileName)
+				assert.True(t, flags.RunRetrieveBootTime)
+				assert.False(t, flags.RunAggregate)
+			},
+		},
+		"missing default config file is ignored": {
+			arguments:     []string{"-A", "results.jsonl"},
+			defaultConfig: filepath.Join(dir, "missing.yaml"),
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "results.jsonl", args.FileName)
+			},
+		},
+		"no default config file": {
+			validate: func(t *testing.T, args *Args, flags *Flags, err error) {
+				require.ErrorContains(t, err, "expected 1 arg for jsonl file, found 0")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, tc.arguments, tc.defaultConfig, &args, &flags)
+			tc.validate(t, &args, &flags, err)
+		})
+	}
+}
@@ -3,54 +3,399 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/boreec/boottime/analysis"
 	"github.com/boreec/boottime/exec"
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/prometheus"
+	"github.com/boreec/boottime/remote"
+	"github.com/boreec/boottime/systemd"
 )
 
+// stdoutFileName is the jsonl file of -o writing the record to stdout, to pipe
+// it into another tool.
+const stdoutFileName = "-"
+
 func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the subcommand named by the first argument, or the default mode.
+func run(arguments []string) error {
+	if len(arguments) > 0 {
+		if c, ok := findCommand(arguments[0]); ok {
+			return runCommand(c, arguments[1:])
+		}
+	}
+
 	var args Args
 	var flags Flags
 
-	if err := parseArgs(&args, &flags); err != nil {
-		panic(err.Error())
+	// Without a config directory, there is no default config file to read.
+	defaultConfig, _ := defaultConfigPath()
+	if err := parseArgs(flag.CommandLine, arguments, defaultConfig, &args, &flags); err != nil {
+		return err
+	}
+
+	result, err := runWithArgs(&args, &flags)
+	if err != nil {
+		return err
+	}
+
+	if !flags.Quiet {
+		exec.PrintWarnings(os.Stderr, result.Warnings)
+	}
+
+	if err := render(os.Stdout, result, &flags); err != nil {
+		return err
 	}
 
-	if err := runWithArgs(&args, &flags); err != nil {
-		panic(err.Error())
+	if flags.Reboot && result.RunsLeft > 0 {
+		fmt.Fprintf(os.Stderr, "runs: %d records left, rebooting\n", result.RunsLeft)
+		return systemd.Reboot()
 	}
+
+	return nil
+}
+
+// Result is the outcome of running boottime in the default mode, rendered by
+// main according to the flags.
+type Result struct {
+	// Record is the retrieved record, or the average, or median, of the records.
+	Record *model.BootTimeRecord
+	// Count is the number of records averaged, zero when retrieving.
+	Count int
+	// Average is the average of the records with their spread, nil when
+	// retrieving or grouping.
+	Average *exec.Average
+	// Groups are the averages of the records grouped with --group-by or
+	// --group-by-cmdline-param, in which case Record is nil.
+	Groups map[string]*exec.Average
+	// Blame are the slowest units of the boot with --blame, when retrieving.
+	Blame []systemd.UnitTiming
+	// RunsLeft is the number of records still to collect with --runs.
+	RunsLeft int
+	// Skipped is set when the retrieved record was not written to the jsonl
+	// file because of --sample-rate.
+	Skipped bool
+	// Warnings are the problems which did not prevent the run.
+	Warnings []exec.Warning
 }
 
 type Flags struct {
 	RunRetrieveBootTime bool
 	RunAggregate        bool
 	Prettify            bool
+	Format              string
+	AllColumns          bool
+	UniformUnits        bool
+	Verbose             bool
+	Quiet               bool
+	AllowEmpty          bool
+	DryRun              bool
+	BMC                 bool
+	Raw                 bool
+	Collapse            model.CollapseStrategy
+	AnalyzeScope        systemd.AnalyzeScope
+	SendAddr            string
+	RemoteWriteURL      string
+	BudgetFile          string
+	MaxRecords          int
+	Selection           model.Selection
+	BestOfBreed         bool
+	Consensus           bool
+	Preferences         map[model.BootTimeStage]model.RetrievalMethod
+	TolerateTruncated   bool
+	SkipMalformed       bool
+	ExcludePostUpdate   bool
+	GroupByCmdlineParam string
+	GroupBy             analysis.MetadataField
+	DedupBoots          bool
+	ConfigFile          string
+	SkipACPIChecksum    bool
+	CoerceSchemas       bool
+	SampleRate          int
+	Median              bool
+	Trim                float64
+	Shares              bool
+	Percentiles         []float64
+	OutputDir           string
+	Output              string
+	Spread              bool
+	Stats               bool
+	Blame               int
+	Timeout             time.Duration
+	Methods             []model.RetrievalMethod
+	Stages              []model.BootTimeStage
+	Check               time.Duration
+	Watch               time.Duration
+	Samples             int
+	Runs                int
+	Reboot              bool
 }
 
 type Args struct {
 	FileName string
+	// ExtraFileNames are the jsonl files averaged with FileName by -A, such as
+	// those of the other hosts of a fleet.
+	ExtraFileNames []string
 }
 
-func parseArgs(args *Args, flags *Flags) error {
-	flag.BoolVar(&flags.RunRetrieveBootTime, "R", false, "retrieve boot time")
-	flag.BoolVar(&flags.RunRetrieveBootTime, "retrieve-boot-time", false, "retrieve boot time")
+// defineFlags registers the flags of the default mode on fs.
+func defineFlags(fs *flag.FlagSet, flags *Flags) {
+	fs.StringVar(&flags.ConfigFile, "config", "", "read the defaults of the flags from this YAML file (default ~/.config/boottime/config.yaml if it exists)")
+
+	fs.BoolVar(&flags.RunRetrieveBootTime, "R", false, "retrieve boot time")
+	fs.BoolVar(&flags.RunRetrieveBootTime, "retrieve-boot-time", false, "retrieve boot time")
+
+	fs.BoolVar(&flags.RunAggregate, "A", false, "average boot time records")
+	fs.BoolVar(&flags.RunAggregate, "average-boot-records", false, "average boot time records")
+
+	fs.BoolVar(&flags.Median, "m", false, "with -A, take the median of the records instead of their average")
+	fs.BoolVar(&flags.Median, "median", false, "with -A, take the median of the records instead of their average")
+	fs.Float64Var(&flags.Trim, "trim", 0, "with -A, leave this fraction of the shortest and longest durations of every stage and method out of the average, such as 0.1")
+
+	fs.Func("percentiles", "with -A, print these comma-separated percentiles of every stage and method, such as 50,90,99, instead of the average", func(s string) error {
+		flags.Percentiles = nil
+		for _, field := range strings.Split(s, ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return fmt.Errorf("invalid percentile %q: %w", field, err)
+			}
+			if p < 0 || p > 100 {
+				return fmt.Errorf("percentile %v out of range, expected between 0 and 100", p)
+			}
+			flags.Percentiles = append(flags.Percentiles, p)
+		}
+		return nil
+	})
+
+	fs.BoolVar(&flags.Prettify, "p", false, "prettify results")
+	fs.BoolVar(&flags.Prettify, "prettify", false, "prettify results")
+	flags.Format = formatJSON
+	fs.Func("format", "output format, json, triples for one [stage, method, seconds] array per record, csv with one stage_method column per cell, prometheus for the node_exporter textfile collector, or influx for the InfluxDB line protocol (default json)", func(s string) error {
+		if s != formatJSON && s != formatTriples && s != formatCSV && s != formatPrometheus && s != formatInflux {
+			return fmt.Errorf("unknown format %q, expected %s, %s, %s, %s or %s", s, formatJSON, formatTriples, formatCSV, formatPrometheus, formatInflux)
+		}
+		flags.Format = s
+		return nil
+	})
+	fs.BoolVar(&flags.AllColumns, "all-columns", false, "write every stage_method column with --format csv, even those without data, for a stable header")
+
+	fs.BoolVar(&flags.Verbose, "v", false, "annotate prettified results with the confidence of each method")
+	fs.BoolVar(&flags.Verbose, "verbose", false, "annotate prettified results with the confidence of each method")
+	fs.BoolVar(&flags.Quiet, "q", false, "do not print warnings")
+	fs.BoolVar(&flags.Quiet, "quiet", false, "do not print warnings")
+	fs.BoolVar(&flags.Spread, "spread", false, "with -A, also print the min, max and standard deviation of every stage and method")
+	fs.BoolVar(&flags.Stats, "stats", false, "with -A -p, annotate every average with the min and max of its stage and method")
+	fs.BoolVar(&flags.Shares, "shares", false, "annotate prettified results with the share of each duration in the total of its method")
+	fs.BoolVar(&flags.UniformUnits, "uniform-units", false, "use the same unit for all durations of a stage in prettified results")
+
+	fs.BoolVar(&flags.AllowEmpty, "allow-empty", false, "write the record even if no boot time was collected")
+
+	fs.Func("methods", "with -R, comma-separated retrieval methods to run, such as acpi_fpdt,systemd_dbus (default all)", func(s string) error {
+		flags.Methods = nil
+		for _, name := range strings.Split(s, ",") {
+			method, err := model.ParseRetrievalMethod(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			flags.Methods = append(flags.Methods, method)
+		}
+		return nil
+	})
+
+	fs.Func("stages", "with -R, comma-separated boot time stages to write in the record, such as firmware,loader (default all)", func(s string) error {
+		flags.Stages = nil
+		for _, name := range strings.Split(s, ",") {
+			stage, err := model.ParseBootTimeStage(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			flags.Stages = append(flags.Stages, stage)
+		}
+		return nil
+	})
+
+	fs.BoolVar(&flags.BMC, "bmc", false, "also retrieve the firmware duration from the BMC event log with ipmitool")
+
+	fs.BoolVar(&flags.SkipACPIChecksum, "skip-acpi-checksum", false, "do not verify the checksum of the FPDT before reading the boot performance table from memory, to debug broken firmware")
+
+	fs.BoolVar(&flags.Raw, "raw", false, "also store the raw systemd timestamps, to recompute the record later with the recompute command")
+
+	fs.Func("collapse-methods", "write a single duration per stage, reduced from its methods with consensus, mean or best", func(s string) error {
+		strategy, err := model.ParseCollapseStrategy(s)
+		if err != nil {
+			return err
+		}
+		flags.Collapse = strategy
+		return nil
+	})
+
+	fs.Func("analyze-scope", "service manager measured by systemd-analyze, system for the boot or user for the user session (default system)", func(s string) error {
+		scope, err := systemd.ParseAnalyzeScope(s)
+		if err != nil {
+			return err
+		}
+		flags.AnalyzeScope = scope
+		return nil
+	})
+
+	fs.StringVar(&flags.Output, "o", "", "with -R, write to this jsonl file instead of the file argument, or to stdout with -")
+	fs.StringVar(&flags.Output, "output", "", "with -R, write to this jsonl file instead of the file argument, or to stdout with -")
+	fs.StringVar(&flags.OutputDir, "output-dir", "", "with -R, write to <dir>/<hostname>-<date>.jsonl instead of the jsonl file argument, creating the directory if needed")
+
+	fs.IntVar(&flags.Blame, "blame", 0, "with -R, also print the N units which took the longest to start, from systemd-analyze blame")
+
+	fs.DurationVar(&flags.Timeout, "timeout", 0, "with -R, leave out the methods which did not return after this duration, such as 30s (default no timeout)")
+
+	fs.BoolVar(&flags.DryRun, "dry-run", false, "print the retrieved record to stdout instead of writing it")
+
+	fs.DurationVar(&flags.Watch, "watch", 0, "with -R and --samples, sleep this duration between the samples, such as 5s")
+	fs.IntVar(&flags.Samples, "samples", 1, "with -R and --watch, retrieve and write this many records within the boot, to measure the noise of the methods")
+
+	fs.IntVar(&flags.SampleRate, "sample-rate", 1, "only write the retrieved record of 1 in this many boots, counted in a state file next to the jsonl file")
 
-	flag.BoolVar(&flags.RunAggregate, "A", false, "average boot time records")
-	flag.BoolVar(&flags.RunAggregate, "average-boot-records", false, "average boot time records")
+	fs.IntVar(&flags.Runs, "n", 0, "with -R and --reboot, collect N records, rebooting after each one, counted in a state file next to the jsonl file")
+	fs.IntVar(&flags.Runs, "runs", 0, "with -R and --reboot, collect N records, rebooting after each one, counted in a state file next to the jsonl file")
+	fs.BoolVar(&flags.Reboot, "reboot", false, "with --runs, reboot the host after writing the record until the N records are collected")
 
-	flag.BoolVar(&flags.Prettify, "p", false, "prettify results")
-	flag.BoolVar(&flags.Prettify, "prettify", false, "prettify results")
-	flag.Parse()
+	fs.StringVar(&flags.SendAddr, "send", "", "also send the retrieved record to a collector at this address")
+	fs.StringVar(&flags.RemoteWriteURL, "remote-write", "", "also push the retrieved record to this Prometheus remote write endpoint")
 
-	argsUnparsed := flag.Args()
-	if len(argsUnparsed) == 0 {
+	fs.StringVar(&flags.BudgetFile, "budget-file", "", "check the retrieved record against the budget of the matching hardware profile")
+
+	fs.DurationVar(&flags.Check, "check", 0, "with -R, fail if two methods of a stage of the retrieved record differ by more than this duration, such as 100ms")
+
+	fs.IntVar(&flags.MaxRecords, "max-records", 0, "maximum number of records to average (0 for all)")
+
+	fs.BoolVar(&flags.TolerateTruncated, "tolerate-truncated-tail", true, "ignore the last record of the file, with a warning, if an interrupted append truncated it")
+	fs.BoolVar(&flags.SkipMalformed, "skip-malformed", true, "skip the lines of the file failing to parse, with a warning, instead of failing")
+
+	fs.BoolVar(&flags.ExcludePostUpdate, "exclude-first-after-update", false, "leave out of the average the boots much slower than their neighbours, such as the first one after an update")
+
+	fs.BoolVar(&flags.CoerceSchemas, "coerce", false, "average a file mixing flat records, written with --collapse-methods, and nested records, the flat values being averaged as the collapsed method")
+
+	fs.BoolVar(&flags.DedupBoots, "dedup-boots", false, "leave out of the average the records measuring the same boot as an earlier record")
+
+	fs.Func("group-by", "average the records separately for every value of this metadata field: bios_version, bios_date, machine_id, hostname or kernel_version", func(s string) error {
+		field, err := analysis.ParseMetadataField(s)
+		if err != nil {
+			return err
+		}
+		flags.GroupBy = field
+		return nil
+	})
+
+	fs.StringVar(&flags.GroupByCmdlineParam, "group-by-cmdline-param", "", "average the records separately for every value of this kernel command line parameter")
+
+	fs.BoolVar(&flags.BestOfBreed, "best-of-breed", false, "print a single duration per stage, from the most accurate method for that stage")
+	fs.BoolVar(&flags.Consensus, "consensus", false, "print a single duration per stage, from the most trustworthy method which measured it")
+	fs.Func("prefer", "comma-separated stage=method pairs overriding the method used by --best-of-breed", func(s string) error {
+		if flags.Preferences == nil {
+			flags.Preferences = model.DefaultPreferences()
+		}
+		for _, pair := range strings.Split(s, ",") {
+			name, methodName, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return fmt.Errorf("expected stage=method, found %q", pair)
+			}
+			stage, err := model.ParseBootTimeStage(name)
+			if err != nil {
+				return err
+			}
+			method, err := model.ParseRetrievalMethod(methodName)
+			if err != nil {
+				return err
+			}
+			flags.Preferences[stage] = method
+		}
+		return nil
+	})
+
+	fs.Func("exclude-stage", "comma-separated boot time stages to exclude from the results", func(s string) error {
+		for _, name := range strings.Split(s, ",") {
+			stage, err := model.ParseBootTimeStage(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			flags.Selection.ExcludedStages = append(flags.Selection.ExcludedStages, stage)
+		}
+		return nil
+	})
+	fs.Func("exclude-method", "comma-separated retrieval methods to exclude from the results", func(s string) error {
+		for _, name := range strings.Split(s, ",") {
+			method, err := model.ParseRetrievalMethod(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			flags.Selection.ExcludedMethods = append(flags.Selection.ExcludedMethods, method)
+		}
+		return nil
+	})
+}
+
+// parseArgs parses the arguments of the default mode, the flags not set in
+// arguments taking their value from the config file of --config, or from
+// defaultConfig if it exists. An empty defaultConfig reads no config file by
+// default.
+func parseArgs(fs *flag.FlagSet, arguments []string, defaultConfig string, args *Args, flags *Flags) error {
+	defineFlags(fs, flags)
+	if err := fs.Parse(arguments); err != nil {
+		return err
+	}
+
+	configFileName, err := applyConfig(fs, flags.ConfigFile, defaultConfig)
+	if err != nil {
+		return err
+	}
+
+	argsUnparsed := fs.Args()
+	switch {
+	case flags.Output != "":
+		if len(argsUnparsed) > 0 || flags.OutputDir != "" {
+			return errors.New("flag --output is incompatible with --output-dir and a jsonl file argument")
+		}
+		if !flags.RunRetrieveBootTime {
+			return errors.New("flag --output requires -R")
+		}
+		args.FileName = flags.Output
+	case flags.OutputDir != "":
+		if len(argsUnparsed) > 0 {
+			return errors.New("flag --output-dir is incompatible with a jsonl file argument")
+		}
+		if !flags.RunRetrieveBootTime {
+			return errors.New("flag --output-dir requires -R")
+		}
+		if args.FileName, err = exec.OutputFileName(flags.OutputDir); err != nil {
+			return err
+		}
+	case len(argsUnparsed) > 0:
+		args.FileName = argsUnparsed[0]
+		args.ExtraFileNames = argsUnparsed[1:]
+	case configFileName != "":
+		args.FileName = configFileName
+	default:
 		return errors.New("expected 1 arg for jsonl file, found 0")
 	}
-	args.FileName = argsUnparsed[0]
 
-	if !strings.HasSuffix(args.FileName, ".jsonl") {
+	if args.FileName != stdoutFileName && !strings.HasSuffix(args.FileName, ".jsonl") {
 		return errors.New("argument should be a file name with .jsonl suffix")
 	}
+	for _, fileName := range args.ExtraFileNames {
+		if !strings.HasSuffix(fileName, ".jsonl") {
+			return fmt.Errorf("argument %s should be a file name with .jsonl suffix", fileName)
+		}
+	}
 
 	if flags.RunAggregate && flags.RunRetrieveBootTime {
 		return errors.New("flags -A and -R are incompatible")
@@ -60,17 +405,294 @@ func parseArgs(args *Args, flags *Flags) error {
 		return errors.New("flags -A or -R required")
 	}
 
+	if len(args.ExtraFileNames) > 0 && !flags.RunAggregate {
+		return errors.New("several jsonl files require -A")
+	}
+
+	if flags.Median && !flags.RunAggregate {
+		return errors.New("flag --median requires -A")
+	}
+
+	if flags.Trim < 0 || flags.Trim >= 0.5 {
+		return errors.New("flag --trim must be at least 0 and less than 0.5")
+	}
+
+	if flags.Trim > 0 && (!flags.RunAggregate || flags.Median) {
+		return errors.New("flag --trim requires -A, without --median")
+	}
+
+	if flags.Spread && !flags.RunAggregate {
+		return errors.New("flag --spread requires -A")
+	}
+
+	if flags.Spread && (flags.BestOfBreed || flags.Consensus || flags.Format != formatJSON) {
+		return errors.New("flag --spread is incompatible with --best-of-breed, --consensus and --format")
+	}
+
+	if flags.Stats && (!flags.RunAggregate || !flags.Prettify) {
+		return errors.New("flag --stats requires -A and -p")
+	}
+
+	if flags.Stats && (flags.Spread || flags.BestOfBreed || flags.Consensus || flags.Format != formatJSON) {
+		return errors.New("flag --stats is incompatible with --spread, --best-of-breed, --consensus and --format")
+	}
+
+	if flags.Blame < 0 {
+		return errors.New("flag --blame must not be negative")
+	}
+
+	if flags.Blame > 0 && (!flags.RunRetrieveBootTime || flags.DryRun || flags.Format != formatJSON) {
+		return errors.New("flag --blame requires -R, without --dry-run or --format, which also print to stdout")
+	}
+
+	if flags.Percentiles != nil && !flags.RunAggregate {
+		return errors.New("flag --percentiles requires -A")
+	}
+
+	if flags.Percentiles != nil && (flags.Median || flags.Trim > 0 || flags.Spread || flags.Stats || singleDuration(flags) || flags.Format != formatJSON) {
+		return errors.New("flag --percentiles is incompatible with --median, --trim, --spread, --stats, --best-of-breed, --consensus and --format")
+	}
+
+	if flags.Consensus && !flags.RunAggregate {
+		return errors.New("flag --consensus requires -A")
+	}
+
+	if flags.BestOfBreed && !flags.RunAggregate {
+		return errors.New("flag --best-of-breed requires -A")
+	}
+
+	if flags.Consensus && flags.BestOfBreed {
+		return errors.New("flags --consensus and --best-of-breed are incompatible")
+	}
+
+	if flags.Preferences != nil && !flags.BestOfBreed {
+		return errors.New("flag --prefer requires --best-of-breed")
+	}
+
+	if flags.GroupBy != "" && flags.GroupByCmdlineParam != "" {
+		return errors.New("flags --group-by and --group-by-cmdline-param are incompatible")
+	}
+
+	if flags.Format == formatPrometheus && (flags.GroupBy != "" || flags.GroupByCmdlineParam != "") {
+		return errors.New("flag --format prometheus is incompatible with --group-by and --group-by-cmdline-param")
+	}
+
+	if flags.AllColumns && flags.Format != formatCSV {
+		return errors.New("flag --all-columns requires --format csv")
+	}
+
+	if flags.SampleRate < 1 {
+		return errors.New("flag --sample-rate must be at least 1")
+	}
+
+	if flags.Methods != nil && !flags.RunRetrieveBootTime {
+		return errors.New("flag --methods requires -R")
+	}
+
+	if flags.Stages != nil && !flags.RunRetrieveBootTime {
+		return errors.New("flag --stages requires -R, use --exclude-stage with -A")
+	}
+
+	if slices.Contains(flags.Methods, model.RetrievalMethodBMC) && !flags.BMC {
+		return errors.New("flag --methods with bmc requires --bmc")
+	}
+
+	if flags.Raw && flags.Methods != nil && !slices.Contains(flags.Methods, model.RetrievalMethodSystemdDBUS) {
+		return errors.New("flag --raw requires the systemd_dbus method in --methods")
+	}
+
+	if flags.Check < 0 {
+		return errors.New("flag --check must not be negative")
+	}
+
+	if flags.Check > 0 && !flags.RunRetrieveBootTime {
+		return errors.New("flag --check requires -R")
+	}
+
+	if flags.Samples < 1 {
+		return errors.New("flag --samples must be at least 1")
+	}
+
+	if flags.Watch < 0 {
+		return errors.New("flag --watch must not be negative")
+	}
+
+	if (flags.Watch > 0) != (flags.Samples > 1) {
+		return errors.New("flags --watch and --samples greater than 1 require each other")
+	}
+
+	if flags.Samples > 1 && (!flags.RunRetrieveBootTime || flags.Runs > 0 || flags.SampleRate > 1 || flags.SendAddr != "" || flags.RemoteWriteURL != "") {
+		return errors.New("flag --samples requires -R, without --runs, --sample-rate, --send or --remote-write, which expect a record per boot")
+	}
+
+	if flags.Runs < 0 {
+		return errors.New("flag --runs must not be negative")
+	}
+
+	if flags.Runs > 0 && !flags.Reboot {
+		return errors.New("flag --runs requires --reboot, a single boot only yields one record")
+	}
+
+	if flags.Reboot && flags.Runs == 0 {
+		return errors.New("flag --reboot requires --runs")
+	}
+
+	if flags.Runs > 0 && (!flags.RunRetrieveBootTime || flags.DryRun || flags.SampleRate > 1) {
+		return errors.New("flag --runs requires -R, without --dry-run or --sample-rate, which do not write every record")
+	}
+
+	if flags.MaxRecords < 0 {
+		return errors.New("flag --max-records must not be negative")
+	}
+
+	if args.FileName == stdoutFileName && (flags.DryRun || flags.SampleRate > 1 || flags.Runs > 0 || flags.Blame > 0 || flags.Format != formatJSON) {
+		return errors.New("flag --output - is incompatible with --dry-run, --sample-rate, --runs, --blame and --format")
+	}
+
 	return nil
 }
 
-func runWithArgs(args *Args, flags *Flags) error {
+func runWithArgs(args *Args, flags *Flags) (*Result, error) {
 	if flags.RunRetrieveBootTime {
-		return exec.RetrieveBootTimes(args.FileName)
+		opts := []exec.Option{
+			exec.WithAllowEmpty(flags.AllowEmpty),
+			exec.WithBMC(flags.BMC),
+			exec.WithRaw(flags.Raw),
+			exec.WithCollapse(flags.Collapse),
+			exec.WithAnalyzeScope(flags.AnalyzeScope),
+			exec.WithSkipACPIChecksum(flags.SkipACPIChecksum),
+			exec.WithSampleRate(flags.SampleRate),
+			exec.WithTimeout(flags.Timeout),
+			exec.WithRuns(flags.Runs),
+			exec.WithMethods(flags.Methods),
+			exec.WithStages(flags.Stages),
+		}
+		if flags.DryRun {
+			opts = append(opts, exec.WithDryRun(os.Stdout))
+		}
+		if args.FileName == stdoutFileName {
+			opts = append(opts, exec.WithOutput(os.Stdout))
+		}
+
+		// A dry run writes nothing, so it leaves the output directory alone.
+		if flags.OutputDir != "" && !flags.DryRun {
+			if err := exec.CreateOutputDir(flags.OutputDir); err != nil {
+				return nil, err
+			}
+		}
+
+		retrieve := exec.RetrieveBootTimes
+		if flags.Samples > 1 {
+			retrieve = func(fileName string, opts ...exec.Option) (*exec.Retrieval, error) {
+				watch, err := exec.WatchBootTimes(fileName, flags.Watch, flags.Samples, opts...)
+				if err != nil {
+					return nil, err
+				}
+				return &exec.Retrieval{Record: watch.Records[len(watch.Records)-1], Warnings: watch.Warnings}, nil
+			}
+		}
+
+		retrieval, err := retrieve(args.FileName, opts...)
+		if err != nil {
+			return nil, err
+		}
+		record := retrieval.Record
+
+		// A dry run must not have any side effect outside of this host.
+		if flags.SendAddr != "" && !flags.DryRun {
+			if err := remote.Send(flags.SendAddr, record); err != nil {
+				return nil, err
+			}
+		}
+
+		if flags.RemoteWriteURL != "" && !flags.DryRun {
+			if err := prometheus.RemoteWrite(flags.RemoteWriteURL, record, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+
+		if flags.BudgetFile != "" {
+			violations, err := exec.CheckBudget(flags.BudgetFile, record)
+			for _, v := range violations {
+				fmt.Fprintf(os.Stderr, "budget: %s\n", v)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if flags.Check > 0 {
+			discrepancies, err := exec.CheckDiscrepancies(record, flags.Check)
+			for _, d := range discrepancies {
+				fmt.Fprintf(os.Stderr, "check: %s, more than %s\n", d, flags.Check)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result := &Result{Record: record, RunsLeft: retrieval.RunsLeft, Skipped: retrieval.Skipped, Warnings: retrieval.Warnings}
+		if flags.Blame > 0 {
+			// The units are only informative, the record is already written.
+			units, err := systemd.RetrieveBlame()
+			if err != nil {
+				result.Warnings = append(result.Warnings, exec.Warning{Err: fmt.Errorf("blame left out: %w", err)})
+			}
+			result.Blame = units[:min(flags.Blame, len(units))]
+		}
+
+		return result, nil
 	}
 
 	if flags.RunAggregate {
-		return exec.PrintRecordsAverage(args.FileName, flags.Prettify)
+		opts := []exec.AggregateOption{
+			exec.WithMaxRecords(flags.MaxRecords),
+			exec.WithSelection(flags.Selection),
+			exec.WithTolerateTruncatedTail(flags.TolerateTruncated),
+			exec.WithSkipMalformedLines(flags.SkipMalformed),
+			exec.WithExcludePostUpdateBoots(flags.ExcludePostUpdate),
+			exec.WithDedupByBoot(flags.DedupBoots),
+			exec.WithCoerceSchemas(flags.CoerceSchemas),
+			exec.WithMedian(flags.Median),
+			exec.WithTrim(flags.Trim),
+			exec.WithPercentiles(flags.Percentiles),
+			exec.WithExtraFiles(args.ExtraFileNames...),
+		}
+
+		if flags.GroupByCmdlineParam != "" {
+			groups, warnings, err := exec.AverageRecordsByCmdlineParam(args.FileName, flags.GroupByCmdlineParam, opts...)
+			if err != nil {
+				return nil, aggregateError(err)
+			}
+
+			return &Result{Groups: groups, Warnings: warnings}, nil
+		}
+
+		if flags.GroupBy != "" {
+			groups, warnings, err := exec.AverageRecordsByMetadata(args.FileName, flags.GroupBy, opts...)
+			if err != nil {
+				return nil, aggregateError(err)
+			}
+
+			return &Result{Groups: groups, Warnings: warnings}, nil
+		}
+
+		avg, err := exec.AverageRecords(args.FileName, opts...)
+		if err != nil {
+			return nil, aggregateError(err)
+		}
+
+		return &Result{Record: avg.Record, Count: avg.Count, Average: avg, Warnings: avg.Warnings}, nil
 	}
 
-	return nil
+	return &Result{}, nil
+}
+
+// aggregateError returns err with the flag averaging the records anyway when it
+// reports records of mixed schemas.
+func aggregateError(err error) error {
+	if errors.Is(err, model.ErrMixedSchemas) {
+		return fmt.Errorf("%w (use --coerce to average them together)", err)
+	}
+	return err
 }
@@ -0,0 +1,1109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/analysis"
+	"github.com/boreec/boottime/exec"
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/systemd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecords = `{"firmware":{"acpi_fpdt":"2s","systemd_analyze":"2.1s"},"kernel":{"systemd_analyze":"700ms"}}
+{"firmware":{"acpi_fpdt":"4s","systemd_analyze":"4.1s"},"kernel":{"systemd_analyze":"900ms"}}
+`
+
+func writeRecords(t *testing.T, content string) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "records.jsonl")
+	require.NoError(t, os.WriteFile(fileName, []byte(content), 0o644))
+	return fileName
+}
+
+func TestRunWithArgsAggregate(t *testing.T) {
+	tcs := map[string]struct {
+		content  string
+		flags    Flags
+		validate func(t *testing.T, result *Result, err error)
+	}{
+		"average every record": {
+			content: testRecords,
+			flags:   Flags{RunAggregate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Empty(t, result.Warnings)
+				assert.Equal(t, 3*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				assert.Equal(t, 800*time.Millisecond, result.Record.Values[model.BootTimeStageKernel][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"median of every record": {
+			content: testRecords + `{"firmware":{"acpi_fpdt":"30s"},"kernel":{"systemd_analyze":"800ms"}}
+`,
+			flags: Flags{RunAggregate: true, Median: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, result.Count)
+				assert.Equal(t, 4*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				assert.Equal(t, 800*time.Millisecond, result.Record.Values[model.BootTimeStageKernel][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"trimmed average of every record": {
+			content: testRecords + `{"firmware":{"acpi_fpdt":"30s"}}
+{"firmware":{"acpi_fpdt":"3s"}}
+`,
+			flags: Flags{RunAggregate: true, Trim: 0.25},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 4, result.Count)
+				assert.Equal(t, 3500*time.Millisecond, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				assert.Equal(t, 800*time.Millisecond, result.Record.Values[model.BootTimeStageKernel][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"exclude first boot after update": {
+			content: `{"userspace":{"systemd_analyze":"30s"}}
+{"userspace":{"systemd_analyze":"5s"}}
+{"userspace":{"systemd_analyze":"7s"}}
+`,
+			flags: Flags{RunAggregate: true, ExcludePostUpdate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Equal(t, 6*time.Second, result.Record.Values[model.BootTimeStageUserspace][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"dedup records of the same boot": {
+			content: `{"firmware":{"acpi_fpdt":"2s"},"userspace":{"systemd_analyze":"3s"},"metadata":{"machine_id":"a"}}
+{"firmware":{"acpi_fpdt":"2s"},"userspace":{"systemd_analyze":"3.5s"},"metadata":{"machine_id":"a"}}
+{"firmware":{"acpi_fpdt":"2.1s"},"userspace":{"systemd_analyze":"5s"},"metadata":{"machine_id":"a"}}
+`,
+			flags: Flags{RunAggregate: true, DedupBoots: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Equal(t, 4*time.Second, result.Record.Values[model.BootTimeStageUserspace][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"group by kernel command line parameter": {
+			content: `{"userspace":{"systemd_analyze":"3s"},"metadata":{"kernel_cmdline":"quiet mitigations=off"}}
+{"userspace":{"systemd_analyze":"4s"},"metadata":{"kernel_cmdline":"quiet"}}
+{"userspace":{"systemd_analyze":"5s"},"metadata":{"kernel_cmdline":"mitigations=off"}}
+`,
+			flags: Flags{RunAggregate: true, GroupByCmdlineParam: "mitigations"},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				require.Len(t, result.Groups, 2)
+				assert.Equal(t, 2, result.Groups["off"].Count)
+				assert.Equal(t, 4*time.Second, result.Groups["off"].Record.Values[model.BootTimeStageUserspace][model.RetrievalMethodSystemdAnalyze])
+				assert.Equal(t, 1, result.Groups["(unset)"].Count)
+			},
+		},
+		"group by bios version": {
+			content: `{"firmware":{"acpi_fpdt":"3s"},"metadata":{"bios_version":"1.2.3"}}
+{"firmware":{"acpi_fpdt":"5s"},"metadata":{"bios_version":"1.2.3"}}
+{"firmware":{"acpi_fpdt":"2s"},"metadata":{"bios_version":"1.3.0"}}
+{"firmware":{"acpi_fpdt":"9s"}}
+`,
+			flags: Flags{RunAggregate: true, GroupBy: analysis.MetadataFieldBIOSVersion},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				require.Len(t, result.Groups, 3)
+				assert.Equal(t, 4*time.Second, result.Groups["1.2.3"].Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				assert.Equal(t, 1, result.Groups["1.3.0"].Count)
+				assert.Equal(t, 1, result.Groups["(unknown)"].Count)
+			},
+		},
+		"mixed flat and nested records fail": {
+			content: testRecords + `{"firmware":"3s","kernel":"800ms"}
+`,
+			flags: Flags{RunAggregate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.ErrorIs(t, err, model.ErrMixedSchemas)
+				assert.ErrorContains(t, err, "--coerce")
+			},
+		},
+		"mixed flat and nested records are coerced": {
+			content: testRecords + `{"firmware":"3s","kernel":"800ms"}
+`,
+			flags: Flags{RunAggregate: true, CoerceSchemas: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, result.Count)
+				assert.Equal(t, 3*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				assert.Equal(t, 3*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodCollapsed])
+			},
+		},
+		"tolerated truncated tail is a warning": {
+			content: testRecords + `{"firmware":{"acpi_f`,
+			flags:   Flags{RunAggregate: true, TolerateTruncated: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Len(t, result.Warnings, 1)
+			},
+		},
+		"truncated tail fails when not tolerated": {
+			content: testRecords + `{"firmware":{"acpi_f`,
+			flags:   Flags{RunAggregate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				assert.ErrorIs(t, err, model.ErrTruncatedRecord)
+			},
+		},
+		"malformed line fails": {
+			content: `{"firmware":{"acpi_fpdt":"2s"}}
+{"firmware":{"acpi_fpdt":
+{"firmware":{"acpi_fpdt":"4s"}}
+`,
+			flags: Flags{RunAggregate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.ErrorContains(t, err, "unmarshalling boot time record from line")
+				assert.Nil(t, result)
+			},
+		},
+		"malformed line is skipped with a warning": {
+			content: `{"firmware":{"acpi_fpdt":"2s"}}
+{"firmware":{"acpi_fpdt":
+{"firmware":{"acpi_fpdt":"4s"}}
+`,
+			flags: Flags{RunAggregate: true, SkipMalformed: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Equal(t, 3*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				require.Len(t, result.Warnings, 1)
+				assert.ErrorContains(t, result.Warnings[0].Err, "skipping malformed line 2 of ")
+			},
+		},
+		"skipped truncated tail fails when not tolerated": {
+			content: testRecords + `{"firmware":{"acpi_f`,
+			flags:   Flags{RunAggregate: true, SkipMalformed: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				assert.ErrorIs(t, err, model.ErrTruncatedRecord)
+			},
+		},
+		"skipped malformed lines with the first boots after an update": {
+			content: `{"userspace":{"systemd_analyze":"30s"}}
+potatoes
+{"userspace":{"systemd_analyze":"5s"}}
+{"userspace":{"systemd_analyze":"7s"}}
+{"userspace":{"systemd_a`,
+			flags: Flags{RunAggregate: true, ExcludePostUpdate: true, SkipMalformed: true, TolerateTruncated: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Len(t, result.Warnings, 2)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			args := Args{FileName: writeRecords(t, tc.content)}
+			result, err := runWithArgs(&args, &tc.flags)
+			tc.validate(t, result, err)
+		})
+	}
+}
+
+func TestRunWithArgsAggregateFiles(t *testing.T) {
+	tcs := map[string]struct {
+		contents []string
+		flags    Flags
+		validate func(t *testing.T, result *Result, err error)
+	}{
+		"records of every file are averaged together": {
+			contents: []string{testRecords, `{"firmware":{"acpi_fpdt":"9s"}}
+`},
+			flags: Flags{RunAggregate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, result.Count)
+				assert.Equal(t, 5*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+			},
+		},
+		"max records counts the records of every file": {
+			contents: []string{`{"firmware":{"acpi_fpdt":"2s"}}
+`, testRecords},
+			flags: Flags{RunAggregate: true, MaxRecords: 2},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+				assert.Equal(t, 2*time.Second, result.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+			},
+		},
+		"duplicate boots across files are left out": {
+			contents: []string{
+				`{"firmware":{"acpi_fpdt":"2s"},"metadata":{"machine_id":"a","timestamp":"2026-03-04T05:06:07Z"}}` + "\n",
+				`{"firmware":{"acpi_fpdt":"2s"},"metadata":{"machine_id":"a","timestamp":"2026-03-04T05:06:07Z"}}` + "\n" +
+					`{"firmware":{"acpi_fpdt":"4s"},"metadata":{"machine_id":"b","timestamp":"2026-03-04T05:06:07Z"}}` + "\n",
+			},
+			flags: Flags{RunAggregate: true, DedupBoots: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, result.Count)
+			},
+		},
+		"groups span every file": {
+			contents: []string{
+				`{"firmware":{"acpi_fpdt":"3s"},"metadata":{"bios_version":"1.2.3"}}` + "\n",
+				`{"firmware":{"acpi_fpdt":"5s"},"metadata":{"bios_version":"1.2.3"}}` + "\n",
+			},
+			flags: Flags{RunAggregate: true, GroupBy: analysis.MetadataFieldBIOSVersion},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				require.Len(t, result.Groups, 1)
+				assert.Equal(t, 2, result.Groups["1.2.3"].Count)
+			},
+		},
+		"percentiles span every file": {
+			contents: []string{testRecords, `{"firmware":{"acpi_fpdt":"9s"}}
+`},
+			flags: Flags{RunAggregate: true, Percentiles: []float64{50, 100}},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, result.Average.Percentiles)
+				assert.Equal(t, 3, result.Average.Percentiles.Records)
+				assert.Equal(t, model.CellPercentiles{Count: 3, Values: []time.Duration{4 * time.Second, 9 * time.Second}},
+					result.Average.Percentiles.Stages[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+			},
+		},
+		"percentiles apply max records and selection": {
+			contents: []string{testRecords, `{"firmware":{"acpi_fpdt":"9s"}}
+`},
+			flags: Flags{RunAggregate: true, MaxRecords: 2, Percentiles: []float64{50}, Selection: model.Selection{ExcludedMethods: []model.RetrievalMethod{model.RetrievalMethodSystemdAnalyze}}},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, result.Average.Percentiles)
+				assert.Equal(t, 2, result.Average.Percentiles.Records)
+				assert.Equal(t, map[model.BootTimeStage]map[model.RetrievalMethod]model.CellPercentiles{
+					model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: {Count: 2, Values: []time.Duration{3 * time.Second}}},
+				}, result.Average.Percentiles.Stages)
+			},
+		},
+		"malformed extra file fails": {
+			contents: []string{testRecords, "potatoes\n"},
+			flags:    Flags{RunAggregate: true},
+			validate: func(t *testing.T, result *Result, err error) {
+				require.ErrorContains(t, err, "unmarshalling boot time record from line")
+				assert.Nil(t, result)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var args Args
+			for i, content := range tc.contents {
+				if i == 0 {
+					args.FileName = writeRecords(t, content)
+					continue
+				}
+				args.ExtraFileNames = append(args.ExtraFileNames, writeRecords(t, content))
+			}
+			result, err := runWithArgs(&args, &tc.flags)
+			tc.validate(t, result, err)
+		})
+	}
+}
+
+func TestParseArgsFiles(t *testing.T) {
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, args *Args, err error)
+	}{
+		"several files are averaged": {
+			arguments: []string{"-A", "node1.jsonl", "node2.jsonl", "node3.jsonl"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "node1.jsonl", args.FileName)
+				assert.Equal(t, []string{"node2.jsonl", "node3.jsonl"}, args.ExtraFileNames)
+			},
+		},
+		"single file has no extra files": {
+			arguments: []string{"-A", "node1.jsonl"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.NoError(t, err)
+				assert.Empty(t, args.ExtraFileNames)
+			},
+		},
+		"extra file without jsonl suffix returns error": {
+			arguments: []string{"-A", "node1.jsonl", "node2.json"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.ErrorContains(t, err, "argument node2.json should be a file name with .jsonl suffix")
+			},
+		},
+		"retrieval returns error": {
+			arguments: []string{"-R", "node1.jsonl", "node2.jsonl"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.ErrorContains(t, err, "several jsonl files require -A")
+			},
+		},
+		"percentiles of several files": {
+			arguments: []string{"-A", "--percentiles", "50", "node1.jsonl", "node2.jsonl"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"node2.jsonl"}, args.ExtraFileNames)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, tc.arguments, "", &args, &flags)
+			tc.validate(t, &args, err)
+		})
+	}
+}
+
+func TestRenderConsensus(t *testing.T) {
+	record := &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageFirmware: {
+				model.RetrievalMethodEFIVar:         1700 * time.Millisecond,
+				model.RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+			},
+			model.BootTimeStageKernel: {
+				model.RetrievalMethodSystemdAnalyze: 641 * time.Millisecond,
+			},
+		},
+	}
+	avg := &exec.Average{Record: record, Count: 1, Min: record, Max: record, StdDev: &model.BootTimeRecord{}}
+	result := &Result{Record: record, Count: 1, Average: avg}
+
+	tcs := map[string]struct {
+		flags    Flags
+		expected string
+	}{
+		"json": {
+			flags:    Flags{RunAggregate: true, Consensus: true},
+			expected: `{"firmware":"1.7s","kernel":"641ms"}` + "\n",
+		},
+		"triples": {
+			flags:    Flags{RunAggregate: true, Consensus: true, Format: formatTriples},
+			expected: `[["firmware","efi_var",1.7],["kernel","systemd_analyze",0.641]]` + "\n",
+		},
+		"csv": {
+			flags:    Flags{RunAggregate: true, Consensus: true, Format: formatCSV},
+			expected: "firmware_efi_var,kernel_systemd_analyze\n1.7,0.641\n",
+		},
+		"table": {
+			flags: Flags{RunAggregate: true, Consensus: true, Prettify: true, Verbose: true},
+			expected: `Boot time average for 1 records.
+Stage     Duration  Method                    
+firmware  1.7s      efi_var (high)            
+kernel    641ms     systemd_analyze (medium)  
+`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, render(&buf, result, &tc.flags))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestParseArgsConsensus(t *testing.T) {
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, err error)
+	}{
+		"aggregate": {
+			arguments: []string{"-A", "--consensus", "records.jsonl"},
+			validate: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"retrieval returns error": {
+			arguments: []string{"-R", "--consensus", "records.jsonl"},
+			validate: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "--consensus requires -A")
+			},
+		},
+		"best of breed with retrieval returns error": {
+			arguments: []string{"-R", "--best-of-breed", "records.jsonl"},
+			validate: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "--best-of-breed requires -A")
+			},
+		},
+		"best of breed returns error": {
+			arguments: []string{"-A", "--consensus", "--best-of-breed", "records.jsonl"},
+			validate: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "--consensus and --best-of-breed are incompatible")
+			},
+		},
+		"spread returns error": {
+			arguments: []string{"-A", "--consensus", "--spread", "records.jsonl"},
+			validate: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "--spread is incompatible with --best-of-breed, --consensus and --format")
+			},
+		},
+		"percentiles returns error": {
+			arguments: []string{"-A", "--consensus", "--percentiles", "50", "records.jsonl"},
+			validate: func(t *testing.T, err error) {
+				require.ErrorContains(t, err, "--percentiles is incompatible with --median, --trim, --spread, --stats, --best-of-breed, --consensus and --format")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			tc.validate(t, parseArgs(fs, tc.arguments, "", &args, &flags))
+		})
+	}
+}
+
+func TestRenderInfluxGroups(t *testing.T) {
+	result := &Result{
+		Groups: map[string]*exec.Average{
+			"1.3.0": {Record: &model.BootTimeRecord{
+				Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2 * time.Second},
+				},
+			}},
+			"1.2.3": {Record: &model.BootTimeRecord{
+				Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageTotal: {model.RetrievalMethodSystemdAnalyze: 9 * time.Second},
+				},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	flags := Flags{RunAggregate: true, Format: formatInflux, GroupBy: analysis.MetadataFieldBIOSVersion}
+	require.NoError(t, render(&buf, result, &flags))
+	assert.Equal(t, "boottime,bios_version=1.2.3 total_systemd_analyze=9\nboottime,bios_version=1.3.0 firmware_acpi_fpdt=2\n", buf.String())
+}
+
+func TestRenderInfluxRetrievedRecordTags(t *testing.T) {
+	result := &Result{Record: &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2 * time.Second},
+		},
+		Metadata: &model.Metadata{
+			Hostname:  "node 1",
+			MachineID: "a",
+			Timestamp: time.Unix(1772600767, 0),
+		},
+	}}
+
+	var buf bytes.Buffer
+	flags := Flags{RunRetrieveBootTime: true, Format: formatInflux}
+	require.NoError(t, render(&buf, result, &flags))
+	assert.Equal(t, "boottime,host=node\\ 1,machine_id=a firmware_acpi_fpdt=2 1772600767000000000\n", buf.String())
+}
+
+func TestRenderGroupsMedian(t *testing.T) {
+	result := &Result{
+		Groups: map[string]*exec.Average{
+			"1.2.3": {Record: &model.BootTimeRecord{}, Count: 3},
+		},
+	}
+
+	var buf bytes.Buffer
+	flags := Flags{RunAggregate: true, Median: true, Prettify: true, Format: formatJSON, GroupBy: analysis.MetadataFieldBIOSVersion}
+	require.NoError(t, render(&buf, result, &flags))
+	assert.True(t, strings.HasPrefix(buf.String(), "bios_version=1.2.3: boot time median for 3 records.\n"), buf.String())
+}
+
+func TestRenderPercentiles(t *testing.T) {
+	stats := &model.BootTimeStatistics{
+		Records:     3,
+		Percentiles: []float64{50, 99},
+		Stages: map[model.BootTimeStage]map[model.RetrievalMethod]model.CellPercentiles{
+			model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: {Count: 3, Values: []time.Duration{2 * time.Second, 4 * time.Second}}},
+		},
+	}
+
+	tcs := map[string]struct {
+		result   *Result
+		flags    Flags
+		expected string
+	}{
+		"json": {
+			result:   &Result{Count: 3, Average: &exec.Average{Count: 3, Percentiles: stats}},
+			flags:    Flags{RunAggregate: true, Format: formatJSON, Percentiles: stats.Percentiles},
+			expected: `{"records":3,"stages":{"firmware":{"acpi_fpdt":{"count":3,"p50":"2s","p99":"4s"}}}}` + "\n",
+		},
+		"table": {
+			result: &Result{Count: 3, Average: &exec.Average{Count: 3, Percentiles: stats}},
+			flags:  Flags{RunAggregate: true, Prettify: true, Format: formatJSON, Percentiles: stats.Percentiles},
+			expected: "Boot time percentiles for 3 records.\n" +
+				"Stage     Method     Count  p50  p99\n" +
+				"firmware  acpi_fpdt  3      2s   4s\n",
+		},
+		"groups table": {
+			result: &Result{Groups: map[string]*exec.Average{"1.2.3": {Count: 3, Percentiles: stats}}},
+			flags:  Flags{RunAggregate: true, Prettify: true, Format: formatJSON, Percentiles: stats.Percentiles, GroupBy: analysis.MetadataFieldBIOSVersion},
+			expected: "bios_version=1.2.3: boot time percentiles for 3 records.\n" +
+				"Stage     Method     Count  p50  p99\n" +
+				"firmware  acpi_fpdt  3      2s   4s\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, render(&buf, tc.result, &tc.flags))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestRenderCSVAllColumns(t *testing.T) {
+	result := &Result{
+		Groups: map[string]*exec.Average{
+			"1.3.0": {Record: &model.BootTimeRecord{
+				Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2 * time.Second},
+				},
+			}},
+			"1.2.3": {Record: &model.BootTimeRecord{
+				Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageTotal: {model.RetrievalMethodSystemdAnalyze: 9 * time.Second},
+				},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	flags := Flags{RunAggregate: true, Format: formatCSV, AllColumns: true, GroupBy: analysis.MetadataFieldBIOSVersion}
+	require.NoError(t, render(&buf, result, &flags))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	header := records[0]
+	assert.Equal(t, "bios_version", header[0])
+	assert.Equal(t, "firmware_acpi_fpdt", header[1])
+	assert.Equal(t, "total_systemd_journal", header[len(header)-1])
+	assert.NotContains(t, header, "total_collapsed")
+	assert.Equal(t, "1.2.3", records[1][0])
+	assert.Equal(t, "1.3.0", records[2][0])
+	assert.Equal(t, "2", records[2][1])
+	assert.Empty(t, records[1][1])
+}
+
+func TestRender(t *testing.T) {
+	firmwareRecord := func(fpdt, analyze time.Duration) *model.BootTimeRecord {
+		return &model.BootTimeRecord{
+			Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+				model.BootTimeStageFirmware: {
+					model.RetrievalMethodACPIFPDT:       fpdt,
+					model.RetrievalMethodSystemdAnalyze: analyze,
+				},
+			},
+		}
+	}
+	avg := &exec.Average{
+		Record: firmwareRecord(3*time.Second, 3100*time.Millisecond),
+		Count:  2,
+		Min:    firmwareRecord(2*time.Second, 2100*time.Millisecond),
+		Max:    firmwareRecord(4*time.Second, 4100*time.Millisecond),
+		StdDev: firmwareRecord(time.Second, time.Second),
+	}
+	result := &Result{Record: avg.Record, Count: avg.Count, Average: avg}
+
+	tcs := map[string]struct {
+		flags    Flags
+		expected string
+	}{
+		"json": {
+			flags:    Flags{RunAggregate: true},
+			expected: `{"firmware":{"acpi_fpdt":"3s","systemd_analyze":"3.1s"}}` + "\n",
+		},
+		"best of breed json": {
+			flags:    Flags{RunAggregate: true, BestOfBreed: true},
+			expected: `{"firmware":"3s"}` + "\n",
+		},
+		"triples": {
+			flags:    Flags{RunAggregate: true, Format: formatTriples},
+			expected: `[["firmware","acpi_fpdt",3],["firmware","systemd_analyze",3.1]]` + "\n",
+		},
+		"best of breed triples": {
+			flags:    Flags{RunAggregate: true, BestOfBreed: true, Format: formatTriples},
+			expected: `[["firmware","acpi_fpdt",3]]` + "\n",
+		},
+		"retrieval triples": {
+			flags:    Flags{RunRetrieveBootTime: true, Format: formatTriples},
+			expected: `[["firmware","acpi_fpdt",3],["firmware","systemd_analyze",3.1]]` + "\n",
+		},
+		"csv": {
+			flags:    Flags{RunAggregate: true, Format: formatCSV},
+			expected: "firmware_acpi_fpdt,firmware_systemd_analyze\n3,3.1\n",
+		},
+		"best of breed csv": {
+			flags:    Flags{RunAggregate: true, BestOfBreed: true, Format: formatCSV},
+			expected: "firmware_acpi_fpdt\n3\n",
+		},
+		"retrieval csv": {
+			flags:    Flags{RunRetrieveBootTime: true, Format: formatCSV},
+			expected: "firmware_acpi_fpdt,firmware_systemd_analyze\n3,3.1\n",
+		},
+		"prometheus": {
+			flags:    Flags{RunAggregate: true, Format: formatPrometheus},
+			expected: "# HELP boottime_stage_seconds Duration of the boot stage, as measured by the retrieval method.\n# TYPE boottime_stage_seconds gauge\n" + "boottime_stage_seconds{stage=\"firmware\",method=\"acpi_fpdt\"} 3\nboottime_stage_seconds{stage=\"firmware\",method=\"systemd_analyze\"} 3.1\n",
+		},
+		"retrieval prometheus": {
+			flags:    Flags{RunRetrieveBootTime: true, Format: formatPrometheus},
+			expected: "# HELP boottime_stage_seconds Duration of the boot stage, as measured by the retrieval method.\n# TYPE boottime_stage_seconds gauge\n" + "boottime_stage_seconds{stage=\"firmware\",method=\"acpi_fpdt\"} 3\nboottime_stage_seconds{stage=\"firmware\",method=\"systemd_analyze\"} 3.1\n",
+		},
+		"influx": {
+			flags:    Flags{RunAggregate: true, Format: formatInflux},
+			expected: "boottime firmware_acpi_fpdt=3,firmware_systemd_analyze=3.1\n",
+		},
+		"retrieval influx": {
+			flags:    Flags{RunRetrieveBootTime: true, Format: formatInflux},
+			expected: "boottime firmware_acpi_fpdt=3,firmware_systemd_analyze=3.1\n",
+		},
+		"spread json": {
+			flags:    Flags{RunAggregate: true, Spread: true, Format: formatJSON},
+			expected: `{"average":{"firmware":{"acpi_fpdt":"3s","systemd_analyze":"3.1s"}},"max":{"firmware":{"acpi_fpdt":"4s","systemd_analyze":"4.1s"}},"min":{"firmware":{"acpi_fpdt":"2s","systemd_analyze":"2.1s"}},"stddev":{"firmware":{"acpi_fpdt":"1s","systemd_analyze":"1s"}}}` + "\n",
+		},
+		"spread table": {
+			flags: Flags{RunAggregate: true, Spread: true, Prettify: true, Median: true, Format: formatJSON},
+			expected: `Boot time median for 2 records.
+Stage     Method           Median  Min   Max   StdDev
+firmware  acpi_fpdt        3s      2s    4s    1s
+firmware  systemd_analyze  3.1s    2.1s  4.1s  1s
+`,
+		},
+		"stats table": {
+			flags: Flags{
+				RunAggregate: true, Stats: true, Prettify: true, Format: formatJSON,
+				Selection: model.Selection{
+					ExcludedStages:  []model.BootTimeStage{model.BootTimeStageLoader, model.BootTimeStageKernel, model.BootTimeStageInitrd, model.BootTimeStageUserspace, model.BootTimeStageTotal},
+					ExcludedMethods: []model.RetrievalMethod{model.RetrievalMethodBMC, model.RetrievalMethodDeviceTree, model.RetrievalMethodEFIVar, model.RetrievalMethodSystemdDBUS, model.RetrievalMethodSystemdJournal, model.RetrievalMethodCollapsed},
+				},
+			},
+			expected: "Boot time average for 2 records.\n" +
+				"Stage     acpi_fpdt   systemd_analyze   \n" +
+				"firmware  3s (2s–4s)  3.1s (2.1s–4.1s)  \n",
+		},
+		"median table": {
+			flags: Flags{
+				RunAggregate: true, Median: true, Prettify: true, Format: formatJSON,
+				Selection: model.Selection{
+					ExcludedStages:  []model.BootTimeStage{model.BootTimeStageLoader, model.BootTimeStageKernel, model.BootTimeStageInitrd, model.BootTimeStageUserspace, model.BootTimeStageTotal},
+					ExcludedMethods: []model.RetrievalMethod{model.RetrievalMethodBMC, model.RetrievalMethodDeviceTree, model.RetrievalMethodEFIVar, model.RetrievalMethodSystemdDBUS, model.RetrievalMethodSystemdJournal},
+				},
+			},
+			expected: "Boot time median for 2 records.\n" +
+				"Stage     acpi_fpdt  systemd_analyze  \n" +
+				"firmware  3s         3.1s             \n",
+		},
+		"retrieval is not rendered": {
+			flags:    Flags{RunRetrieveBootTime: true},
+			expected: "",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, render(&buf, result, &tc.flags))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestRenderBlame(t *testing.T) {
+	result := &Result{
+		Record: &model.BootTimeRecord{},
+		Blame: []systemd.UnitTiming{
+			{Name: "apt-daily.service", Duration: time.Minute + 3402*time.Millisecond},
+			{Name: "snapd.service", Duration: 987 * time.Millisecond},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, result, &Flags{RunRetrieveBootTime: true, Blame: 2, Format: formatJSON}))
+	assert.Equal(t, `Unit               Duration
+apt-daily.service  1m3.402s
+snapd.service      987ms
+`, buf.String())
+}
+
+func TestRenderSampledOutRecord(t *testing.T) {
+	record := &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageKernel: {model.RetrievalMethodSystemdDBUS: 718 * time.Millisecond},
+		},
+	}
+
+	tcs := map[string]struct {
+		result   *Result
+		expected string
+	}{
+		"written record is not rendered": {
+			result:   &Result{Record: record},
+			expected: "",
+		},
+		"sampled out record is rendered": {
+			result:   &Result{Record: record, Skipped: true},
+			expected: `{"kernel":{"systemd_dbus":"718ms"}}` + "\n",
+		},
+		"sampled out record is rendered before the blame": {
+			result:   &Result{Record: record, Skipped: true, Blame: []systemd.UnitTiming{{Name: "snapd.service", Duration: 987 * time.Millisecond}}},
+			expected: `{"kernel":{"systemd_dbus":"718ms"}}` + "\nUnit           Duration\nsnapd.service  987ms\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, render(&buf, tc.result, &Flags{RunRetrieveBootTime: true, Format: formatJSON}))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestParseArgsOutputDir(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, dir string, args *Args, err error)
+	}{
+		"file named after the host": {
+			arguments: []string{"-R"},
+			validate: func(t *testing.T, dir string, args *Args, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, dir, filepath.Dir(args.FileName))
+				assert.True(t, strings.HasPrefix(filepath.Base(args.FileName), host+"-"))
+				assert.True(t, strings.HasSuffix(args.FileName, ".jsonl"))
+				assert.NoDirExists(t, dir, "the directory is only created when writing")
+			},
+		},
+		"jsonl file argument returns error": {
+			arguments: []string{"-R", "records.jsonl"},
+			validate: func(t *testing.T, dir string, args *Args, err error) {
+				require.ErrorContains(t, err, "incompatible with a jsonl file argument")
+			},
+		},
+		"aggregate returns error": {
+			arguments: []string{"-A"},
+			validate: func(t *testing.T, dir string, args *Args, err error) {
+				require.ErrorContains(t, err, "--output-dir requires -R")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := filepath.Join(t.TempDir(), "boottime")
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, append([]string{"--output-dir", dir}, tc.arguments...), "", &args, &flags)
+			tc.validate(t, dir, &args, err)
+		})
+	}
+}
+
+func TestParseArgsOutput(t *testing.T) {
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, args *Args, err error)
+	}{
+		"stdout": {
+			arguments: []string{"-R", "-o", "-"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, stdoutFileName, args.FileName)
+			},
+		},
+		"jsonl file": {
+			arguments: []string{"-R", "--output", "records.jsonl"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "records.jsonl", args.FileName)
+			},
+		},
+		"file without jsonl suffix returns error": {
+			arguments: []string{"-R", "-o", "records.json"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.ErrorContains(t, err, ".jsonl suffix")
+			},
+		},
+		"jsonl file argument returns error": {
+			arguments: []string{"-R", "-o", "-", "records.jsonl"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.ErrorContains(t, err, "incompatible with --output-dir and a jsonl file argument")
+			},
+		},
+		"aggregate returns error": {
+			arguments: []string{"-A", "-o", "-"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.ErrorContains(t, err, "--output requires -R")
+			},
+		},
+		"stdout with csv returns error": {
+			arguments: []string{"-R", "-o", "-", "--format", "csv"},
+			validate: func(t *testing.T, args *Args, err error) {
+				require.ErrorContains(t, err, "--output - is incompatible")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, tc.arguments, "", &args, &flags)
+			tc.validate(t, &args, err)
+		})
+	}
+}
+
+func TestParseArgsSampling(t *testing.T) {
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, flags *Flags, err error)
+	}{
+		"watch samples": {
+			arguments: []string{"-R", "--watch", "5s", "--samples", "10", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 5*time.Second, flags.Watch)
+				assert.Equal(t, 10, flags.Samples)
+			},
+		},
+		"watch without samples returns error": {
+			arguments: []string{"-R", "--watch", "5s", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "require each other")
+			},
+		},
+		"samples without watch returns error": {
+			arguments: []string{"-R", "--samples", "10", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "require each other")
+			},
+		},
+		"samples with runs returns error": {
+			arguments: []string{"-R", "--watch", "5s", "--samples", "10", "--runs", "3", "--reboot", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--samples requires -R, without --runs")
+			},
+		},
+		"runs with reboot": {
+			arguments: []string{"-R", "-n", "5", "--reboot", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 5, flags.Runs)
+				assert.True(t, flags.Reboot)
+			},
+		},
+		"runs without reboot returns error": {
+			arguments: []string{"-R", "--runs", "5", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--runs requires --reboot")
+			},
+		},
+		"reboot without runs returns error": {
+			arguments: []string{"-R", "--reboot", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--reboot requires --runs")
+			},
+		},
+		"runs with dry run returns error": {
+			arguments: []string{"-R", "--runs", "5", "--reboot", "--dry-run", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--runs requires -R, without --dry-run")
+			},
+		},
+		"runs with aggregate returns error": {
+			arguments: []string{"-A", "--runs", "5", "--reboot", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--runs requires -R")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, tc.arguments, "", &args, &flags)
+			tc.validate(t, &flags, err)
+		})
+	}
+}
+
+func TestParseArgsTrim(t *testing.T) {
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, flags *Flags, err error)
+	}{
+		"trim": {
+			arguments: []string{"-A", "--trim", "0.1", "records.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.InDelta(t, 0.1, flags.Trim, 0)
+			},
+		},
+		"negative trim returns error": {
+			arguments: []string{"-A", "--trim", "-0.1", "records.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--trim must be at least 0 and less than 0.5")
+			},
+		},
+		"half trim returns error": {
+			arguments: []string{"-A", "--trim", "0.5", "records.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--trim must be at least 0 and less than 0.5")
+			},
+		},
+		"retrieval returns error": {
+			arguments: []string{"-R", "--trim", "0.1", "records.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--trim requires -A, without --median")
+			},
+		},
+		"median returns error": {
+			arguments: []string{"-A", "--median", "--trim", "0.1", "records.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--trim requires -A, without --median")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, tc.arguments, "", &args, &flags)
+			tc.validate(t, &flags, err)
+		})
+	}
+}
+
+func TestParseArgsSelection(t *testing.T) {
+	tcs := map[string]struct {
+		arguments []string
+		validate  func(t *testing.T, flags *Flags, err error)
+	}{
+		"selected methods": {
+			arguments: []string{"-R", "--methods", "acpi_fpdt, systemd_dbus", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []model.RetrievalMethod{model.RetrievalMethodACPIFPDT, model.RetrievalMethodSystemdDBUS}, flags.Methods)
+			},
+		},
+		"unknown method lists the valid ones": {
+			arguments: []string{"-R", "--methods", "acpi", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, `unknown retrieval method "acpi", expected one of acpi_fpdt, bmc`)
+			},
+		},
+		"collapsed is not a retrieval method": {
+			arguments: []string{"-R", "--methods", "collapsed", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, `unknown retrieval method "collapsed", expected one of acpi_fpdt, bmc, devicetree, efi_var, systemd_dbus, systemd_analyze, systemd_journal`)
+			},
+		},
+		"bmc without --bmc returns error": {
+			arguments: []string{"-R", "--methods", "bmc", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "requires --bmc")
+			},
+		},
+		"raw without systemd_dbus returns error": {
+			arguments: []string{"-R", "--raw", "--methods", "acpi_fpdt", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--raw requires the systemd_dbus method")
+			},
+		},
+		"aggregate returns error": {
+			arguments: []string{"-A", "--methods", "acpi_fpdt", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--methods requires -R")
+			},
+		},
+		"selected stages": {
+			arguments: []string{"-R", "--stages", "firmware,loader", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []model.BootTimeStage{model.BootTimeStageFirmware, model.BootTimeStageLoader}, flags.Stages)
+			},
+		},
+		"unknown stage lists the valid ones": {
+			arguments: []string{"-R", "--stages", "firmwre", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, `unknown boot time stage "firmwre", expected one of`)
+			},
+		},
+		"stages with aggregate returns error": {
+			arguments: []string{"-A", "--stages", "firmware", "results.jsonl"},
+			validate: func(t *testing.T, flags *Flags, err error) {
+				require.ErrorContains(t, err, "--stages requires -R")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			var args Args
+			var flags Flags
+			err := parseArgs(fs, tc.arguments, "", &args, &flags)
+			tc.validate(t, &flags, err)
+		})
+	}
+}
+
+func TestRunReturnsCommandError(t *testing.T) {
+	err := run([]string{"diff", "before.jsonl"})
+	require.EqualError(t, err, "expected 2 args for before and after jsonl files, found 1")
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boreec/boottime/exec"
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/systemd"
+)
+
+// Output formats of --format.
+const (
+	formatJSON    string = "json"
+	formatTriples string = "triples"
+	formatCSV     string = "csv"
+	// formatPrometheus is the text exposition format of the node_exporter
+	// textfile collector.
+	formatPrometheus string = "prometheus"
+	// formatInflux is the InfluxDB line protocol.
+	formatInflux string = "influx"
+)
+
+// render writes the result of the default mode to w. Retrieved records are only
+// rendered with --format triples, csv, prometheus or influx, since they are written to
+// the jsonl file, or when --sample-rate left them out of it.
+func render(w io.Writer, result *Result, flags *Flags) error {
+	if !flags.RunAggregate {
+		switch {
+		case result.Record == nil:
+		case result.Skipped && flags.Format == formatJSON:
+			if err := renderJSON(w, result.Record); err != nil {
+				return err
+			}
+			if result.Blame != nil {
+				return renderBlame(w, result.Blame)
+			}
+		case flags.Format == formatTriples:
+			return renderJSON(w, result.Record.ToTriples())
+		case flags.Format == formatCSV:
+			return renderCSV(w, "", nil, []*model.BootTimeRecord{result.Record}, flags)
+		case flags.Format == formatPrometheus:
+			return result.Record.WritePrometheus(w)
+		case flags.Format == formatInflux:
+			return result.Record.WriteInfluxLineProtocol(w, model.InfluxMeasurement, influxTags(result.Record.Metadata))
+		case result.Blame != nil:
+			return renderBlame(w, result.Blame)
+		}
+		return nil
+	}
+
+	if result.Groups != nil {
+		return renderGroups(w, result.Groups, flags)
+	}
+
+	switch {
+	case flags.Percentiles != nil && flags.Prettify:
+		fmt.Fprintf(w, "Boot time percentiles for %d records.\n", result.Count)
+		return renderPercentilesTable(w, result.Average.Percentiles)
+	case flags.Percentiles != nil:
+		return renderJSON(w, result.Average.Percentiles)
+	case flags.Spread && flags.Prettify:
+		fmt.Fprintf(w, "Boot time %s for %d records.\n", averageName(flags), result.Count)
+		return renderSpreadTable(w, result.Average, flags)
+	case flags.Spread:
+		return renderJSON(w, spreadValue(result.Average, flags))
+	case flags.Format == formatPrometheus:
+		return csvRecord(result.Record, flags).WritePrometheus(w)
+	case flags.Format == formatInflux:
+		return csvRecord(result.Record, flags).WriteInfluxLineProtocol(w, model.InfluxMeasurement, nil)
+	case flags.Format == formatCSV:
+		return renderCSV(w, "", nil, []*model.BootTimeRecord{csvRecord(result.Record, flags)}, flags)
+	case flags.Prettify && flags.Format != formatTriples:
+		fmt.Fprintf(w, "Boot time %s for %d records.\n", averageName(flags), result.Count)
+		return renderTable(w, result.Average, flags)
+	}
+
+	return renderJSON(w, jsonValue(result.Record, flags))
+}
+
+// renderGroups renders the average of every group, in lexical order of the
+// group names.
+func renderGroups(w io.Writer, groups map[string]*exec.Average, flags *Flags) error {
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	slices.Sort(names)
+
+	if flags.Format == formatCSV {
+		records := make([]*model.BootTimeRecord, 0, len(names))
+		for _, group := range names {
+			records = append(records, csvRecord(groups[group].Record, flags))
+		}
+		return renderCSV(w, groupName(flags), names, records, flags)
+	}
+
+	if flags.Format == formatInflux {
+		for _, group := range names {
+			tags := map[string]string{groupName(flags): group}
+			if err := csvRecord(groups[group].Record, flags).WriteInfluxLineProtocol(w, model.InfluxMeasurement, tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !flags.Prettify || flags.Format == formatTriples {
+		values := make(map[string]any, len(groups))
+		for group, avg := range groups {
+			if flags.Percentiles != nil {
+				values[group] = avg.Percentiles
+				continue
+			}
+			if flags.Spread {
+				values[group] = spreadValue(avg, flags)
+				continue
+			}
+			values[group] = jsonValue(avg.Record, flags)
+		}
+		return renderJSON(w, values)
+	}
+
+	for i, group := range names {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		statistic := averageName(flags)
+		if flags.Percentiles != nil {
+			statistic = "percentiles"
+		}
+		fmt.Fprintf(w, "%s=%s: boot time %s for %d records.\n", groupName(flags), group, statistic, groups[group].Count)
+		render := func() error { return renderTable(w, groups[group], flags) }
+		switch {
+		case flags.Percentiles != nil:
+			render = func() error { return renderPercentilesTable(w, groups[group].Percentiles) }
+		case flags.Spread:
+			render = func() error { return renderSpreadTable(w, groups[group], flags) }
+		}
+		if err := render(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// influxTags returns the tags of a retrieved record in the InfluxDB line
+// protocol, identifying the host it was retrieved on.
+func influxTags(metadata *model.Metadata) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	return map[string]string{"host": metadata.Hostname, "machine_id": metadata.MachineID}
+}
+
+// groupName returns the name of what the records are grouped by.
+func groupName(flags *Flags) string {
+	if flags.GroupBy != "" {
+		return string(flags.GroupBy)
+	}
+	return flags.GroupByCmdlineParam
+}
+
+// jsonValue returns the value encoding the averaged record in JSON: the record
+// itself, or a single duration per stage with --best-of-breed or --consensus.
+// With --format triples, the value is the triples of the record, restricted to
+// the preferred methods with --best-of-breed or --consensus.
+func jsonValue(btr *model.BootTimeRecord, flags *Flags) any {
+	if flags.Format == formatTriples {
+		if singleDuration(flags) {
+			btr = bestOfBreedRecord(btr, preferences(btr, flags))
+		}
+		return btr.ToTriples()
+	}
+
+	if !singleDuration(flags) {
+		return btr
+	}
+
+	composite := btr.BestOfBreed(preferences(btr, flags))
+	raw := make(map[model.BootTimeStage]model.Duration, len(composite))
+	for stage, d := range composite {
+		raw[stage] = model.Duration(d)
+	}
+	return raw
+}
+
+// averageName returns the name of the reduction of the records, the average
+// or, with --median, the median.
+func averageName(flags *Flags) string {
+	if flags.Median {
+		return "median"
+	}
+	return "average"
+}
+
+// spreadValue returns the value encoding the averaged records with their spread
+// in JSON, with a record for each of the average, min, max and standard
+// deviation.
+func spreadValue(avg *exec.Average, flags *Flags) map[string]*model.BootTimeRecord {
+	return map[string]*model.BootTimeRecord{
+		averageName(flags): avg.Record,
+		"min":              avg.Min,
+		"max":              avg.Max,
+		"stddev":           avg.StdDev,
+	}
+}
+
+// bestOfBreedRecord returns a record holding only the value of the preferred
+// method of each stage.
+func bestOfBreedRecord(btr *model.BootTimeRecord, prefs map[model.BootTimeStage]model.RetrievalMethod) *model.BootTimeRecord {
+	out := &model.BootTimeRecord{
+		Values: make(map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration),
+	}
+	for stage, d := range btr.BestOfBreed(prefs) {
+		out.Values[stage] = map[model.RetrievalMethod]time.Duration{prefs[stage]: d}
+	}
+	return out
+}
+
+// csvRecord returns the averaged record to write as CSV or Prometheus metrics,
+// restricted to the preferred methods with --best-of-breed or --consensus.
+func csvRecord(btr *model.BootTimeRecord, flags *Flags) *model.BootTimeRecord {
+	if singleDuration(flags) {
+		return bestOfBreedRecord(btr, preferences(btr, flags))
+	}
+	return btr
+}
+
+// renderCSV writes a header and one row per record. With a group column, each
+// row starts with the group of its record. The columns are those of
+// model.CSVColumns, every one of them with --all-columns.
+func renderCSV(w io.Writer, groupColumn string, groupNames []string, records []*model.BootTimeRecord, flags *Flags) error {
+	columns := model.CSVColumns(flags.AllColumns, records...)
+
+	cw := csv.NewWriter(w)
+
+	var header []string
+	if groupColumn != "" {
+		header = append(header, groupColumn)
+	}
+	for _, c := range columns {
+		header = append(header, c.String())
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("writing csv header: %w", err)
+	}
+
+	for i, r := range records {
+		var row []string
+		if groupColumn != "" {
+			row = append(row, groupNames[i])
+		}
+		row = append(row, r.CSVRow(columns)...)
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("writing csv row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func renderJSON(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshalling averaged results to json: %w", err)
+	}
+	fmt.Fprintf(w, "%s\n", string(data))
+
+	return nil
+}
+
+// singleDuration reports whether a single duration per stage is printed, with
+// --best-of-breed or --consensus.
+func singleDuration(flags *Flags) bool {
+	return flags.BestOfBreed || flags.Consensus
+}
+
+// preferences returns the method of every stage printed with --best-of-breed,
+// or the most trustworthy method of btr with --consensus.
+func preferences(btr *model.BootTimeRecord, flags *Flags) map[model.BootTimeStage]model.RetrievalMethod {
+	if flags.Consensus {
+		return btr.ConsensusMethods()
+	}
+	if flags.Preferences == nil {
+		return model.DefaultPreferences()
+	}
+	return flags.Preferences
+}
+
+// renderTable renders the averaged record as a table of stages and methods, or
+// of stages and their preferred method with --best-of-breed or --consensus.
+// With --stats, every average is followed by the range of its stage and method.
+func renderTable(w io.Writer, avg *exec.Average, flags *Flags) error {
+	btr := avg.Record
+	if singleDuration(flags) {
+		prefs := preferences(btr, flags)
+		return renderBestOfBreedTable(w, btr.BestOfBreed(prefs), prefs, flags)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	tableOpts := []model.TableOption{model.WithSelection(flags.Selection)}
+	if flags.UniformUnits {
+		tableOpts = append(tableOpts, model.WithUniformUnits())
+	}
+	if flags.Shares {
+		tableOpts = append(tableOpts, model.WithShares())
+	}
+	if flags.Verbose {
+		tableOpts = append(tableOpts, model.WithConfidence())
+	}
+	if flags.Stats {
+		tableOpts = append(tableOpts, model.WithRange(avg.Min, avg.Max))
+	}
+
+	rows := btr.ToTable(tableOpts...)
+	for _, row := range rows {
+		for _, cell := range row {
+			fmt.Fprint(tw, cell, "\t")
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// renderSpreadTable renders the averaged records as a table with a row per
+// stage and method, and a column for each of the average, min, max and
+// standard deviation.
+func renderSpreadTable(w io.Writer, avg *exec.Average, flags *Flags) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	column := "Average"
+	if flags.Median {
+		column = "Median"
+	}
+	fmt.Fprintf(tw, "Stage\tMethod\t%s\tMin\tMax\tStdDev\n", column)
+	// Collapsed records are averaged as the collapsed method, which is not a
+	// retrieval method of the selection.
+	methods := append(flags.Selection.Methods(), model.RetrievalMethodCollapsed)
+	for _, stage := range flags.Selection.Stages() {
+		for _, method := range methods {
+			d, ok := avg.Record.Values[stage][method]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", stage, method, d,
+				avg.Min.Values[stage][method], avg.Max.Values[stage][method], avg.StdDev.Values[stage][method])
+		}
+	}
+
+	return tw.Flush()
+}
+
+// renderPercentilesTable renders the percentiles as a table with a row per
+// stage and method, the number of its samples and a column per percentile.
+func renderPercentilesTable(w io.Writer, stats *model.BootTimeStatistics) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "Stage\tMethod\tCount")
+	for _, p := range stats.Percentiles {
+		fmt.Fprintf(tw, "\t%s", model.PercentileName(p))
+	}
+	fmt.Fprintln(tw)
+
+	// Collapsed records are summarized as the collapsed method, which is not
+	// a retrieval method of the selection.
+	methods := append(model.Selection{}.Methods(), model.RetrievalMethodCollapsed)
+	for _, stage := range (model.Selection{}).Stages() {
+		for _, method := range methods {
+			cell, ok := stats.Stages[stage][method]
+			if !ok {
+				continue
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%d", stage, method, cell.Count)
+			for _, d := range cell.Values {
+				fmt.Fprintf(tw, "\t%s", d)
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// renderBlame renders the units of --blame as a table, the slowest first.
+func renderBlame(w io.Writer, units []systemd.UnitTiming) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Unit\tDuration")
+	for _, u := range units {
+		fmt.Fprintf(tw, "%s\t%s\n", u.Name, u.Duration)
+	}
+
+	return tw.Flush()
+}
+
+func renderBestOfBreedTable(w io.Writer, composite map[model.BootTimeStage]time.Duration, prefs map[model.BootTimeStage]model.RetrievalMethod, flags *Flags) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Stage\tDuration\tMethod\t")
+	for _, stage := range flags.Selection.Stages() {
+		d, ok := composite[stage]
+		if !ok {
+			continue
+		}
+
+		method := string(prefs[stage])
+		if flags.Verbose {
+			method += " (" + model.Confidence(prefs[stage], stage).String() + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", stage, d, method)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,156 @@
+// Package devicetree retrieves the firmware and loader durations recorded by
+// U-Boot in the device tree, on boards without ACPI tables nor EFI variables.
+//
+// When built with CONFIG_BOOTSTAGE_FDT, U-Boot adds a bootstage node to the
+// device tree passed to the kernel, with one child node per boot stage:
+//
+//	bootstage {
+//		0 { name = "reset"; mark = <0>; };
+//		1 { name = "main_loop"; mark = <1840213>; };
+//		2 { name = "start_kernel"; mark = <2931044>; };
+//	};
+//
+// Marks are in microseconds since reset. The kernel exposes the nodes as
+// directories and the properties as files of their raw big-endian encoding.
+package devicetree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const pathDeviceTreeDir string = "/sys/firmware/devicetree/base"
+
+const (
+	// stageMainLoop is marked once U-Boot, including its SPL, has initialized
+	// the board and is ready to boot.
+	stageMainLoop string = "main_loop"
+	// stageStartKernel is the last mark before jumping to the kernel.
+	stageStartKernel string = "start_kernel"
+)
+
+// bootStageDirs are the locations of the bootstage node, relative to the
+// device tree root. U-Boot adds it at the root, some vendor trees under chosen.
+var bootStageDirs = []string{"bootstage", "chosen/bootstage"}
+
+// ErrUnsupportedPlatform is returned when the host was not booted with a
+// device tree.
+var ErrUnsupportedPlatform = fmt.Errorf("devicetree: %w", errors.ErrUnsupported)
+
+// ErrBootStageNotFound is returned when the device tree has no bootstage node,
+// or when the node has none of the marks mapped to boot time stages.
+var ErrBootStageNotFound = errors.New("bootstage marks not found in device tree")
+
+// BootTimeRecord contains the boot time stages recorded by U-Boot. A stage is
+// zero when its marks are missing.
+type BootTimeRecord struct {
+	// Firmware is the time from reset to the U-Boot main loop.
+	Firmware time.Duration
+	// Loader is the time from the U-Boot main loop to the kernel start, mostly
+	// spent loading the kernel image.
+	Loader time.Duration
+}
+
+// RetrieveBootTime reads the bootstage node of the device tree passed to the
+// running kernel. The returned error wraps errors.ErrUnsupported when the
+// host has no device tree or no bootstage node.
+func RetrieveBootTime() (*BootTimeRecord, error) {
+	if _, err := os.Stat(pathDeviceTreeDir); err != nil {
+		return nil, ErrUnsupportedPlatform
+	}
+
+	root := os.DirFS(pathDeviceTreeDir)
+	for _, dir := range bootStageDirs {
+		node, err := fs.Sub(root, dir)
+		if err != nil {
+			return nil, fmt.Errorf("opening node %s: %w", dir, err)
+		}
+
+		if _, err := fs.Stat(node, "."); err != nil {
+			continue
+		}
+
+		return ParseBootStage(node)
+	}
+
+	// Most device tree boards do not use a loader recording its boot stages,
+	// which is not worth failing the retrieval.
+	return nil, fmt.Errorf("%w: %w", ErrBootStageNotFound, errors.ErrUnsupported)
+}
+
+// ParseBootStage parses the child nodes of the bootstage node rooted at fsys.
+func ParseBootStage(fsys fs.FS) (*BootTimeRecord, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading bootstage node: %w", err)
+	}
+
+	marks := make(map[string]time.Duration)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		name, err := fs.ReadFile(fsys, path.Join(entry.Name(), "name"))
+		if err != nil {
+			return nil, fmt.Errorf("reading name of stage %s: %w", entry.Name(), err)
+		}
+
+		// Stages measuring an accumulated time, such as the time spent in a
+		// driver, have an accum property instead of a mark.
+		data, err := fs.ReadFile(fsys, path.Join(entry.Name(), "mark"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading mark of stage %s: %w", entry.Name(), err)
+		}
+
+		us, err := parseCell(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing mark of stage %s: %w", entry.Name(), err)
+		}
+
+		marks[parseString(name)] = time.Duration(us) * time.Microsecond
+	}
+
+	mainLoop, hasMainLoop := marks[stageMainLoop]
+	startKernel, hasStartKernel := marks[stageStartKernel]
+	if !hasMainLoop {
+		return nil, ErrBootStageNotFound
+	}
+
+	btr := &BootTimeRecord{Firmware: mainLoop}
+	if hasStartKernel {
+		if startKernel < mainLoop {
+			return nil, fmt.Errorf("start_kernel mark %s is before main_loop mark %s", startKernel, mainLoop)
+		}
+		btr.Loader = startKernel - mainLoop
+	}
+
+	return btr, nil
+}
+
+// parseCell decodes a big-endian integer property made of one or two 32-bit
+// cells.
+func parseCell(data []byte) (uint64, error) {
+	switch len(data) {
+	case 4:
+		return uint64(binary.BigEndian.Uint32(data)), nil
+	case 8:
+		return binary.BigEndian.Uint64(data), nil
+	default:
+		return 0, fmt.Errorf("unexpected property size %d", len(data))
+	}
+}
+
+// parseString decodes a NUL-terminated string property.
+func parseString(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
@@ -0,0 +1,94 @@
+package devicetree
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stage(fsys fstest.MapFS, index, name string, mark []byte) {
+	fsys[index+"/name"] = &fstest.MapFile{Data: append([]byte(name), 0)}
+	if mark != nil {
+		fsys[index+"/mark"] = &fstest.MapFile{Data: mark}
+	}
+}
+
+func TestParseBootStage(t *testing.T) {
+	tcs := map[string]struct {
+		fsys     fstest.MapFS
+		validate func(t *testing.T, btr *BootTimeRecord, err error)
+	}{
+		"u-boot marks": {
+			fsys: func() fstest.MapFS {
+				fsys := fstest.MapFS{}
+				stage(fsys, "0", "reset", []byte{0, 0, 0, 0})
+				// 1840213us.
+				stage(fsys, "1", "main_loop", []byte{0x00, 0x1c, 0x14, 0x55})
+				// 2931044us, encoded as two cells.
+				stage(fsys, "2", "start_kernel", []byte{0, 0, 0, 0, 0x00, 0x2c, 0xb9, 0x64})
+				stage(fsys, "3", "mmc_read", nil)
+				return fsys
+			}(),
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1840213*time.Microsecond, btr.Firmware)
+				assert.Equal(t, (2931044-1840213)*time.Microsecond, btr.Loader)
+			},
+		},
+		"missing start_kernel": {
+			fsys: func() fstest.MapFS {
+				fsys := fstest.MapFS{}
+				stage(fsys, "0", "main_loop", []byte{0, 0, 0x03, 0xe8})
+				return fsys
+			}(),
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, time.Millisecond, btr.Firmware)
+				assert.Zero(t, btr.Loader)
+			},
+		},
+		"start_kernel before main_loop returns error": {
+			fsys: func() fstest.MapFS {
+				fsys := fstest.MapFS{}
+				stage(fsys, "0", "main_loop", []byte{0, 0, 0x07, 0xd0})
+				stage(fsys, "1", "start_kernel", []byte{0, 0, 0x03, 0xe8})
+				return fsys
+			}(),
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "start_kernel mark 1ms is before main_loop mark 2ms")
+				assert.Nil(t, btr)
+			},
+		},
+		"missing main_loop": {
+			fsys: func() fstest.MapFS {
+				fsys := fstest.MapFS{}
+				stage(fsys, "0", "start_kernel", []byte{0, 0, 0x03, 0xe8})
+				return fsys
+			}(),
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				assert.ErrorIs(t, err, ErrBootStageNotFound)
+			},
+		},
+		"malformed mark": {
+			fsys: func() fstest.MapFS {
+				fsys := fstest.MapFS{}
+				stage(fsys, "0", "main_loop", []byte{0, 1, 2})
+				return fsys
+			}(),
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				assert.ErrorContains(t, err, "unexpected property size 3")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			btr, err := ParseBootStage(tc.fsys)
+			tc.validate(t, btr, err)
+		})
+	}
+}
@@ -1,6 +1,9 @@
+// Package efi reads the boot loader timestamps that systemd-boot (and other
+// loaders implementing the Boot Loader Interface) store in EFI variables.
 package efi
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -9,77 +12,196 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/boreec/boottime/platform"
+)
+
+const (
+	pathEFIDir  string = "/sys/firmware/efi"
+	efivarsPath string = "/sys/firmware/efi/efivars"
+
+	// attributeNonVolatile is the EFI_VARIABLE_NON_VOLATILE attribute bit.
+	attributeNonVolatile uint32 = 0x00000001
+
+	// loaderVendorGUID is the vendor GUID of the variables of the Boot Loader
+	// Interface, which efivarfs appends to their names.
+	loaderVendorGUID string = "4a67b082-0a4c-41cf-b6c7-440b29bb8c4f"
 )
 
-const efivarsPath string = "/sys/firmware/efi/efivars"
+// Names of the loader variables, without their vendor GUID.
+const (
+	varLoaderTimeInit string = "LoaderTimeInitUSec"
+	varLoaderTimeMenu string = "LoaderTimeMenuUSec"
+	varLoaderTimeExec string = "LoaderTimeExecUSec"
+)
 
+// ErrLoaderTimeInitNotFound is returned when the LoaderTimeInitUSec variable is
+// absent. Without it, neither the firmware nor the loader stage can be derived.
+var ErrLoaderTimeInitNotFound = errors.New("EFI variable LoaderTimeInitUSec not found")
+
+// ErrUnsupportedPlatform is returned when the host cannot have EFI variables.
+var ErrUnsupportedPlatform = fmt.Errorf("efi: %w", errors.ErrUnsupported)
+
+// BootTimeRecord contains the boot time stages derived from the loader EFI
+// variables. The variables are microseconds measured from the firmware timer
+// start (usually the CPU reset):
+//   - LoaderTimeInitUSec is logged when the boot loader is initialized, which
+//     marks the end of the firmware stage and the start of the loader stage.
+//     On Secure Boot systems, shim runs before the boot loader and writes no
+//     timestamp, so its time is part of the firmware stage.
+//   - LoaderTimeMenuUSec is logged when the boot loader shows its menu, or
+//     would have shown it with a zero timeout.
+//   - LoaderTimeExecUSec is logged just before the boot loader executes the
+//     kernel, which marks the end of the loader stage.
 type BootTimeRecord struct {
+	// Firmware is LoaderTimeInitUSec.
 	Firmware time.Duration
-	Loader   time.Duration
+	// Loader is LoaderTimeExecUSec - LoaderTimeInitUSec. It is zero when
+	// LoaderTimeExecUSec is not available.
+	Loader time.Duration
+	// LoaderSetup is LoaderTimeMenuUSec - LoaderTimeInitUSec, the time the
+	// boot loader took to find the boot entries. LoaderSetup and LoaderMenu
+	// are zero when LoaderTimeMenuUSec or LoaderTimeExecUSec is not
+	// available, such as with a boot loader other than systemd-boot.
+	LoaderSetup time.Duration
+	// LoaderMenu is LoaderTimeExecUSec - LoaderTimeMenuUSec, the time spent in
+	// the menu, waiting for the timeout or the user, and loading the kernel.
+	LoaderMenu time.Duration
+	// NonVolatile is true when one of the variables is stored in non-volatile
+	// memory. systemd-boot writes them as volatile variables, which only live
+	// until the next reset, so a non-volatile one may be left over from a
+	// previous boot.
+	NonVolatile bool
 }
 
+// RetrieveBootTime reads the LoaderTimeInitUSec and LoaderTimeExecUSec
+// variables. If only LoaderTimeInitUSec is present, the record only contains the
+// firmware duration.
 func RetrieveBootTime() (*BootTimeRecord, error) {
-	entries, err := os.ReadDir(efivarsPath)
+	return RetrieveBootTimeContext(context.Background())
+}
+
+// RetrieveBootTimeContext is RetrieveBootTime, giving up between the reads of
+// the variables once ctx is done. Reading a variable cannot be interrupted.
+func RetrieveBootTimeContext(ctx context.Context) (*BootTimeRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if p := platform.Detect(); p.IsWSL() {
+		return nil, fmt.Errorf("%w: running under %s", ErrUnsupportedPlatform, p)
+	}
+
+	if err := checkPlatform(); err != nil {
+		return nil, err
+	}
+
+	return readLoaderTimes(ctx, efivarsPath)
+}
+
+// readLoaderTimes reads the loader variables of the Boot Loader Interface in
+// the efivarfs directory dir.
+func readLoaderTimes(ctx context.Context, dir string) (*BootTimeRecord, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("reading directory %s: %w", efivarsPath, err)
+		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
 	}
 
-	var initPath, execPath string
+	paths := make(map[string]string, 3)
 	for _, e := range entries {
-		name := e.Name()
-		switch {
-		case strings.HasPrefix(name, "LoaderTimeInitUSec-"):
-			initPath = filepath.Join(efivarsPath, name)
-		case strings.HasPrefix(name, "LoaderTimeExecUSec-"):
-			execPath = filepath.Join(efivarsPath, name)
+		name, guid, ok := strings.Cut(e.Name(), "-")
+		if !ok || guid != loaderVendorGUID {
+			continue
 		}
-
-		if initPath != "" && execPath != "" {
-			break
+		switch name {
+		case varLoaderTimeInit, varLoaderTimeMenu, varLoaderTimeExec:
+			paths[name] = filepath.Join(dir, e.Name())
 		}
 	}
 
-	if initPath == "" || execPath == "" {
-		return nil, fmt.Errorf("EFI loader timing variables not found")
+	initPath, execPath := paths[varLoaderTimeInit], paths[varLoaderTimeExec]
+	if initPath == "" {
+		return nil, ErrLoaderTimeInitNotFound
 	}
 
-	initRaw, err := readEFIVarValue(initPath)
+	initTime, initAttributes, err := readEFIVarMicroseconds(initPath)
 	if err != nil {
 		return nil, err
 	}
-	execRaw, err := readEFIVarValue(execPath)
-	if err != nil {
-		return nil, err
+
+	record := &BootTimeRecord{
+		Firmware:    initTime,
+		NonVolatile: initAttributes&attributeNonVolatile != 0,
+	}
+	if execPath == "" {
+		return record, nil
 	}
 
-	initTime, err := parseEFIMicroseconds(initRaw)
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	execTime, err := parseEFIMicroseconds(execRaw)
+
+	execTime, execAttributes, err := readEFIVarMicroseconds(execPath)
 	if err != nil {
 		return nil, err
 	}
+	record.NonVolatile = record.NonVolatile || execAttributes&attributeNonVolatile != 0
 
 	if execTime < initTime {
 		return nil, fmt.Errorf("EFI loader exec time < init time")
 	}
+	record.Loader = execTime - initTime
+
+	menuPath := paths[varLoaderTimeMenu]
+	if menuPath == "" {
+		return record, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	menuTime, menuAttributes, err := readEFIVarMicroseconds(menuPath)
+	if err != nil {
+		return nil, err
+	}
+	record.NonVolatile = record.NonVolatile || menuAttributes&attributeNonVolatile != 0
+
+	// An inconsistent menu time only leaves the breakdown out, the loader
+	// stage itself is still valid.
+	if menuTime >= initTime && menuTime <= execTime {
+		record.LoaderSetup = menuTime - initTime
+		record.LoaderMenu = execTime - menuTime
+	}
+
+	return record, nil
+}
+
+func readEFIVarMicroseconds(path string) (time.Duration, uint32, error) {
+	raw, attributes, err := readEFIVarValue(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	d, err := parseEFIMicroseconds(raw)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing EFI var %s: %w", filepath.Base(path), err)
+	}
 
-	return &BootTimeRecord{
-		Firmware: initTime,
-		Loader:   execTime - initTime,
-	}, nil
+	return d, attributes, nil
 }
 
-func readEFIVarValue(path string) ([]byte, error) {
+// readEFIVarValue returns the value of the variable and its attributes, which
+// efivarfs exposes as the first 4 bytes of the file.
+func readEFIVarValue(path string) ([]byte, uint32, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("reading file %s: %w", path, err)
+		return nil, 0, fmt.Errorf("reading file %s: %w", path, err)
 	}
 	if len(data) < 4 {
-		return nil, errors.New("EFI var too short")
+		return nil, 0, errors.New("EFI var too short")
 	}
-	return data[4:], nil // skip the attributes part
+	return data[4:], binary.LittleEndian.Uint32(data[:4]), nil
 }
 
 func parseEFIMicroseconds(data []byte) (time.Duration, error) {
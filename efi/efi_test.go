@@ -0,0 +1,104 @@
+package efi
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLoaderVar writes the variable name with the vendor GUID to dir, as
+// efivarfs exposes it: the attributes followed by the NUL-terminated UTF-16
+// decimal microseconds.
+func writeLoaderVar(t *testing.T, dir, name, guid string, attributes uint32, us int64) {
+	t.Helper()
+	data := binary.LittleEndian.AppendUint32(nil, attributes)
+	for _, c := range utf16.Encode([]rune(strconv.FormatInt(us, 10) + "\x00")) {
+		data = binary.LittleEndian.AppendUint16(data, c)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+"-"+guid), data, 0o600))
+}
+
+func TestReadLoaderTimes(t *testing.T) {
+	const volatile uint32 = 0x6
+
+	tcs := map[string]struct {
+		setup    func(t *testing.T, dir string)
+		validate func(t *testing.T, record *BootTimeRecord, err error)
+	}{
+		"loader with menu": {
+			setup: func(t *testing.T, dir string) {
+				writeLoaderVar(t, dir, varLoaderTimeInit, loaderVendorGUID, volatile, 1_702_811)
+				writeLoaderVar(t, dir, varLoaderTimeMenu, loaderVendorGUID, volatile, 1_750_000)
+				writeLoaderVar(t, dir, varLoaderTimeExec, loaderVendorGUID, volatile, 1_854_331)
+			},
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &BootTimeRecord{
+					Firmware:    1_702_811 * time.Microsecond,
+					Loader:      151_520 * time.Microsecond,
+					LoaderSetup: 47_189 * time.Microsecond,
+					LoaderMenu:  104_331 * time.Microsecond,
+				}, record)
+			},
+		},
+		"loader without menu has no breakdown": {
+			setup: func(t *testing.T, dir string) {
+				writeLoaderVar(t, dir, varLoaderTimeInit, loaderVendorGUID, volatile, 1_000_000)
+				writeLoaderVar(t, dir, varLoaderTimeExec, loaderVendorGUID, volatile, 1_200_000)
+			},
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &BootTimeRecord{Firmware: time.Second, Loader: 200 * time.Millisecond}, record)
+			},
+		},
+		"menu outside of the loader stage has no breakdown": {
+			setup: func(t *testing.T, dir string) {
+				writeLoaderVar(t, dir, varLoaderTimeInit, loaderVendorGUID, volatile, 1_000_000)
+				writeLoaderVar(t, dir, varLoaderTimeMenu, loaderVendorGUID, volatile, 900_000)
+				writeLoaderVar(t, dir, varLoaderTimeExec, loaderVendorGUID, volatile, 1_200_000)
+			},
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &BootTimeRecord{Firmware: time.Second, Loader: 200 * time.Millisecond}, record)
+			},
+		},
+		"non-volatile menu is reported": {
+			setup: func(t *testing.T, dir string) {
+				writeLoaderVar(t, dir, varLoaderTimeInit, loaderVendorGUID, volatile, 1_000_000)
+				writeLoaderVar(t, dir, varLoaderTimeMenu, loaderVendorGUID, volatile|attributeNonVolatile, 1_100_000)
+				writeLoaderVar(t, dir, varLoaderTimeExec, loaderVendorGUID, volatile, 1_200_000)
+			},
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.True(t, record.NonVolatile)
+			},
+		},
+		"variables of another vendor are ignored": {
+			setup: func(t *testing.T, dir string) {
+				writeLoaderVar(t, dir, varLoaderTimeInit, "8be4df61-93ca-11d2-aa0d-00e098032b8c", volatile, 1_000_000)
+			},
+			validate: func(t *testing.T, record *BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrLoaderTimeInitNotFound)
+				assert.Nil(t, record)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tc.setup(t, dir)
+			record, err := readLoaderTimes(context.Background(), dir)
+			tc.validate(t, record, err)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build arm || arm64
+
+package efi
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// checkPlatform reports whether EFI variables can exist on the host. On ARM,
+// only machines booted through UEFI expose them, while boards such as the
+// Raspberry Pi use their own boot firmware.
+func checkPlatform() error {
+	if _, err := os.Stat(filepath.Clean(pathEFIDir)); err != nil {
+		return ErrUnsupportedPlatform
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !arm && !arm64
+
+package efi
+
+// checkPlatform reports whether EFI variables can exist on the host.
+func checkPlatform() error {
+	return nil
+}
@@ -0,0 +1,182 @@
+package exec
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boreec/boottime/analysis"
+	"github.com/boreec/boottime/model"
+)
+
+// readRecords returns every record of the jsonl file, ignoring a truncated last
+// record with a warning.
+func readRecords(fileName string) ([]*model.BootTimeRecord, []Warning, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening file %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	records, err := model.BootTimeRecordsFromFile(file)
+	warnings, err := truncatedTailWarning(err, fileName, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading boot time records from file: %w", err)
+	}
+	return records, warnings, nil
+}
+
+// PrintMethodAgreement prints, for every stage, how well each pair of methods
+// agree across the records of the jsonl file. Pairs never reported together
+// are omitted.
+func PrintMethodAgreement(fileName string) ([]Warning, error) {
+	records, warnings, err := readRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Stage\tMethod A\tMethod B\tRecords\tMean abs diff\tCorrelation\t")
+
+	methods := (model.Selection{}).Methods()
+	for _, stage := range (model.Selection{}).Stages() {
+		for i, a := range methods {
+			for _, b := range methods[i+1:] {
+				meanAbsDiff, correlation, n := analysis.MethodAgreement(records, stage, a, b)
+				if n == 0 {
+					continue
+				}
+
+				corr := "n/a"
+				if !math.IsNaN(correlation) {
+					corr = fmt.Sprintf("%.3f", correlation)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t\n", stage, a, b, n, meanAbsDiff, corr)
+			}
+		}
+	}
+
+	return warnings, w.Flush()
+}
+
+// PrintTotalTimeHistogram prints how many records of the jsonl file have a
+// total duration, reported by method, in each bucket delimited by bounds.
+func PrintTotalTimeHistogram(fileName string, method model.RetrievalMethod, bounds []time.Duration) ([]Warning, error) {
+	records, warnings, err := readRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := analysis.TotalTimeHistogram(records, method, bounds)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Total (%s)\tRecords\t\n", method)
+	for i, count := range counts {
+		var bucket string
+		switch {
+		case len(bounds) == 0:
+			bucket = "any"
+		case i == 0:
+			bucket = fmt.Sprintf("< %s", bounds[0])
+		case i == len(bounds):
+			bucket = fmt.Sprintf(">= %s", bounds[i-1])
+		default:
+			bucket = fmt.Sprintf("%s - %s", bounds[i-1], bounds[i])
+		}
+		fmt.Fprintf(w, "%s\t%d\t\n", bucket, count)
+	}
+
+	return warnings, w.Flush()
+}
+
+// PrintBottlenecks prints, for every stage, in how many records of the jsonl
+// file it is the longest one, as reported by method. With
+// model.RetrievalMethodCollapsed, the records are first collapsed to the
+// consensus of their methods. Stages never the longest are omitted.
+func PrintBottlenecks(fileName string, method model.RetrievalMethod) ([]Warning, error) {
+	records, warnings, err := readRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == model.RetrievalMethodCollapsed {
+		for i, r := range records {
+			records[i] = r.Collapse(model.CollapseConsensus)
+		}
+	}
+
+	counts := analysis.BottleneckHistogram(records, method)
+
+	counted := 0
+	for _, count := range counts {
+		counted += count
+	}
+
+	stages := model.Selection{}.Stages()
+	slices.SortStableFunc(stages, func(a, b model.BootTimeStage) int {
+		return counts[b] - counts[a]
+	})
+
+	for _, stage := range stages {
+		if counts[stage] == 0 {
+			continue
+		}
+		fmt.Printf("%s is the bottleneck in %d/%d boots\n", stage, counts[stage], counted)
+	}
+
+	return warnings, nil
+}
+
+// PrintDeltas prints, for every record of the jsonl file, how much longer each
+// stage reported by method took than in the reference, which is the average of
+// the records of the refFileName jsonl file. Stages missing from either record
+// are left blank.
+func PrintDeltas(refFileName, fileName string, method model.RetrievalMethod) ([]Warning, error) {
+	ref, err := AverageRecords(refFileName)
+	if err != nil {
+		return nil, fmt.Errorf("averaging reference records: %w", err)
+	}
+	if ref.Count == 0 {
+		return nil, fmt.Errorf("reference file %s has no record", refFileName)
+	}
+	records, warnings, err := readRecords(fileName)
+	if err != nil {
+		return nil, err
+	}
+	warnings = slices.Concat(ref.Warnings, warnings)
+
+	stages := model.Selection{}.Stages()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Record (%s)\t", method)
+	for _, stage := range stages {
+		fmt.Fprintf(w, "%s\t", stage)
+	}
+	fmt.Fprintln(w)
+
+	for i, r := range records {
+		delta := r.DeltaFrom(ref.Record)
+
+		fmt.Fprintf(w, "%d\t", i+1)
+		for _, stage := range stages {
+			if d, ok := delta.Values[stage][method]; ok {
+				fmt.Fprint(w, formatDelta(d))
+			}
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprintln(w)
+	}
+
+	return warnings, w.Flush()
+}
+
+// formatDelta formats d with an explicit sign.
+func formatDelta(d time.Duration) string {
+	if d < 0 {
+		return d.String()
+	}
+	return "+" + d.String()
+}
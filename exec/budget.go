@@ -0,0 +1,35 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/boreec/boottime/budget"
+	"github.com/boreec/boottime/model"
+)
+
+// ErrBudgetExceeded is returned when a record exceeds the budget of the host
+// profile.
+var ErrBudgetExceeded = errors.New("boot time budget exceeded")
+
+// CheckBudget checks the record against the budget of the profile matching the
+// host in the budget file, and returns every violation, failing if there is
+// any.
+func CheckBudget(budgetFile string, record *model.BootTimeRecord) ([]budget.Violation, error) {
+	profiles, err := budget.LoadProfiles(budgetFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading budget profiles: %w", err)
+	}
+
+	b, err := budget.MatchProfile(profiles)
+	if err != nil {
+		return nil, fmt.Errorf("matching budget profile: %w", err)
+	}
+
+	violations := b.Check(record)
+	if len(violations) > 0 {
+		return violations, fmt.Errorf("%w: %d violation(s) for profile %s", ErrBudgetExceeded, len(violations), b.Profile)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/boreec/boottime/systemd"
+)
+
+// PrintCriticalChain prints the critical chain of the boot, the units which
+// gated its completion, with the time each became active and took to start.
+func PrintCriticalChain(w io.Writer) error {
+	entries, err := systemd.RetrieveCriticalChain()
+	if err != nil {
+		return fmt.Errorf("retrieving critical chain: %w", err)
+	}
+
+	return writeCriticalChain(w, entries)
+}
+
+// writeCriticalChain writes the entries as a table, the units being indented
+// by their depth in the chain.
+func writeCriticalChain(w io.Writer, entries []systemd.ChainEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Unit\tStarted in\tActive at")
+	for _, e := range entries {
+		started := ""
+		if e.Duration > 0 {
+			started = e.Duration.String()
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\n", strings.Repeat("  ", e.Depth), e.Name, started, e.ActivatedAt)
+	}
+
+	return tw.Flush()
+}
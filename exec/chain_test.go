@@ -0,0 +1,26 @@
+package exec
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/systemd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCriticalChain(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeCriticalChain(&buf, []systemd.ChainEntry{
+		{Name: "graphical.target", ActivatedAt: 13270 * time.Millisecond},
+		{Name: "docker.service", Depth: 1, ActivatedAt: 10528 * time.Millisecond, Duration: 2741 * time.Millisecond},
+		{Name: "network-online.target", Depth: 2, ActivatedAt: 10525 * time.Millisecond},
+	}))
+
+	assert.Equal(t, `Unit                       Started in  Active at
+graphical.target                       13.27s
+  docker.service           2.741s      10.528s
+    network-online.target              10.525s
+`, buf.String())
+}
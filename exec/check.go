@@ -0,0 +1,25 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+// ErrMethodsDisagree is returned when two methods of a stage of a record
+// differ by more than the tolerated threshold.
+var ErrMethodsDisagree = errors.New("retrieval methods disagree")
+
+// CheckDiscrepancies returns the pairs of methods of the record differing by
+// more than threshold, and fails if there is any, such as when a method is
+// broken on the host.
+func CheckDiscrepancies(record *model.BootTimeRecord, threshold time.Duration) ([]model.Discrepancy, error) {
+	discrepancies := record.Discrepancies(threshold)
+	if len(discrepancies) > 0 {
+		return discrepancies, fmt.Errorf("%w: %d pair(s) of methods differ by more than %s", ErrMethodsDisagree, len(discrepancies), threshold)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,53 @@
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDiscrepancies(t *testing.T) {
+	record := &model.BootTimeRecord{Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {
+			model.RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+			model.RetrievalMethodEFIVar:   1702 * time.Millisecond,
+		},
+	}}
+
+	tcs := map[string]struct {
+		threshold time.Duration
+		validate  func(t *testing.T, discrepancies []model.Discrepancy, err error)
+	}{
+		"methods within the threshold": {
+			threshold: 200 * time.Millisecond,
+			validate: func(t *testing.T, discrepancies []model.Discrepancy, err error) {
+				require.NoError(t, err)
+				assert.Empty(t, discrepancies)
+			},
+		},
+		"methods differing by more than the threshold return error": {
+			threshold: 100 * time.Millisecond,
+			validate: func(t *testing.T, discrepancies []model.Discrepancy, err error) {
+				require.ErrorIs(t, err, ErrMethodsDisagree)
+				assert.ErrorContains(t, err, "1 pair(s)")
+				assert.Equal(t, []model.Discrepancy{{
+					Stage:      model.BootTimeStageFirmware,
+					A:          model.RetrievalMethodACPIFPDT,
+					B:          model.RetrievalMethodEFIVar,
+					Difference: 195 * time.Millisecond,
+				}}, discrepancies)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			discrepancies, err := CheckDiscrepancies(record, tc.threshold)
+			tc.validate(t, discrepancies, err)
+		})
+	}
+}
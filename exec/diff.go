@@ -0,0 +1,154 @@
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+// ANSI escape sequences coloring the regressions of the prettified diff.
+const (
+	colorRed   string = "\x1b[31m"
+	colorReset string = "\x1b[0m"
+)
+
+// diffCell compares a stage/method cell of two averages. Before or After is
+// nil when only one of the files has the cell, in which case there is no
+// change. A change within the tolerance of the diff is zero, without percent.
+type diffCell struct {
+	Before  *model.Duration `json:"before,omitempty"`
+	After   *model.Duration `json:"after,omitempty"`
+	Change  *model.Duration `json:"change,omitempty"`
+	Percent *float64        `json:"change_percent,omitempty"`
+}
+
+// PrintRecordsDiff averages the records of the jsonl files a and b, and prints
+// for every stage/method how much it changed from a to b, as JSON or, with
+// prettify, as a table whose regressions are colored when stdout is a
+// terminal. The cells of a single file are printed without change, and changes
+// within tolerance, such as the jitter of the clocks, are not reported.
+func PrintRecordsDiff(a, b string, prettify bool, tolerance time.Duration) ([]Warning, error) {
+	before, err := AverageRecords(a)
+	if err != nil {
+		return nil, fmt.Errorf("averaging records of %s: %w", a, err)
+	}
+
+	after, err := AverageRecords(b)
+	if err != nil {
+		return nil, fmt.Errorf("averaging records of %s: %w", b, err)
+	}
+	warnings := slices.Concat(before.Warnings, after.Warnings)
+
+	if !prettify {
+		return warnings, json.NewEncoder(os.Stdout).Encode(diffRecords(before.Record, after.Record, tolerance))
+	}
+
+	fmt.Printf("Boot time average of %d records before and %d records after.\n", before.Count, after.Count)
+	return warnings, writeRecordsDiff(os.Stdout, before.Record, after.Record, tolerance, isTerminal(os.Stdout))
+}
+
+// diffRecords compares every stage/method cell of the records, the changes
+// within tolerance being zero.
+func diffRecords(before, after *model.BootTimeRecord, tolerance time.Duration) map[model.BootTimeStage]map[model.RetrievalMethod]diffCell {
+	cells := make(map[model.BootTimeStage]map[model.RetrievalMethod]diffCell)
+	for _, stage := range (model.Selection{}).Stages() {
+		for _, method := range (model.Selection{}).Methods() {
+			x, okBefore := before.Values[stage][method]
+			y, okAfter := after.Values[stage][method]
+			if !okBefore && !okAfter {
+				continue
+			}
+
+			var cell diffCell
+			if okBefore {
+				cell.Before = durationPtr(x)
+			}
+			if okAfter {
+				cell.After = durationPtr(y)
+			}
+			switch {
+			case okBefore && okAfter && max(y-x, x-y) <= tolerance:
+				cell.Change = durationPtr(0)
+			case okBefore && okAfter:
+				cell.Change = durationPtr(y - x)
+				if x != 0 {
+					percent := 100 * float64(y-x) / float64(x)
+					cell.Percent = &percent
+				}
+			}
+
+			if cells[stage] == nil {
+				cells[stage] = make(map[model.RetrievalMethod]diffCell)
+			}
+			cells[stage][method] = cell
+		}
+	}
+
+	return cells
+}
+
+func durationPtr(d time.Duration) *model.Duration {
+	md := model.Duration(d)
+	return &md
+}
+
+// writeRecordsDiff writes the diff of the records as a table, the change being
+// the last column so that its color does not shift the alignment. It is
+// preceded by a note when no cell changed by more than tolerance.
+func writeRecordsDiff(w io.Writer, before, after *model.BootTimeRecord, tolerance time.Duration, color bool) error {
+	if before.EqualWithin(after, tolerance) {
+		fmt.Fprintf(w, "No change beyond %s.\n", tolerance)
+	}
+
+	cells := diffRecords(before, after, tolerance)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Stage\tMethod\tBefore\tAfter\tChange")
+	for _, stage := range (model.Selection{}).Stages() {
+		for _, method := range (model.Selection{}).Methods() {
+			cell, ok := cells[stage][method]
+			if !ok {
+				continue
+			}
+
+			change := "only before"
+			switch {
+			case cell.Before == nil:
+				change = "only after"
+			case cell.Change != nil && *cell.Change == 0:
+				change = "unchanged"
+			case cell.Change != nil:
+				change = formatDelta(time.Duration(*cell.Change))
+				if cell.Percent != nil {
+					change += fmt.Sprintf(" (%+.1f%%)", *cell.Percent)
+				}
+				if color && *cell.Change > 0 {
+					change = colorRed + change + colorReset
+				}
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", stage, method, formatCell(cell.Before), formatCell(cell.After), change)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func formatCell(d *model.Duration) string {
+	if d == nil {
+		return "-"
+	}
+	return time.Duration(*d).String()
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
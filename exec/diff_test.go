@@ -0,0 +1,100 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRecordsDiff(t *testing.T) {
+	before := &model.BootTimeRecord{Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2 * time.Second},
+		model.BootTimeStageKernel: {
+			model.RetrievalMethodSystemdAnalyze: 800 * time.Millisecond,
+			model.RetrievalMethodSystemdJournal: 700 * time.Millisecond,
+		},
+	}}
+	after := &model.BootTimeRecord{Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2100 * time.Millisecond},
+		model.BootTimeStageKernel:   {model.RetrievalMethodSystemdAnalyze: 600 * time.Millisecond},
+		model.BootTimeStageTotal:    {model.RetrievalMethodSystemdAnalyze: 0},
+	}}
+	jittered := &model.BootTimeRecord{Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2*time.Second + 400*time.Microsecond},
+		model.BootTimeStageKernel: {
+			model.RetrievalMethodSystemdAnalyze: 800 * time.Millisecond,
+			model.RetrievalMethodSystemdJournal: 700 * time.Millisecond,
+		},
+	}}
+
+	tcs := map[string]struct {
+		after    *model.BootTimeRecord
+		color    bool
+		expected string
+	}{
+		"plain": {
+			after: after,
+			expected: `Stage     Method           Before  After  Change
+firmware  acpi_fpdt        2s      2.1s   +100ms (+5.0%)
+kernel    systemd_analyze  800ms   600ms  -200ms (-25.0%)
+kernel    systemd_journal  700ms   -      only before
+total     systemd_analyze  -       0s     only after
+`,
+		},
+		"regressions colored": {
+			after: after,
+			color: true,
+			expected: "Stage     Method           Before  After  Change\n" +
+				"firmware  acpi_fpdt        2s      2.1s   \x1b[31m+100ms (+5.0%)\x1b[0m\n" +
+				"kernel    systemd_analyze  800ms   600ms  -200ms (-25.0%)\n" +
+				"kernel    systemd_journal  700ms   -      only before\n" +
+				"total     systemd_analyze  -       0s     only after\n",
+		},
+		"jitter within the tolerance": {
+			after: jittered,
+			expected: `No change beyond 1ms.
+Stage     Method           Before  After    Change
+firmware  acpi_fpdt        2s      2.0004s  unchanged
+kernel    systemd_analyze  800ms   800ms    unchanged
+kernel    systemd_journal  700ms   700ms    unchanged
+`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, writeRecordsDiff(&buf, before, tc.after, time.Millisecond, tc.color))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestDiffRecordsJSON(t *testing.T) {
+	before := &model.BootTimeRecord{Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 2 * time.Second},
+		model.BootTimeStageLoader:   {model.RetrievalMethodEFIVar: 0},
+	}}
+	after := &model.BootTimeRecord{Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 1500 * time.Millisecond},
+		model.BootTimeStageLoader:   {model.RetrievalMethodEFIVar: 100 * time.Millisecond},
+		model.BootTimeStageKernel:   {model.RetrievalMethodSystemdDBUS: 641 * time.Millisecond},
+	}}
+	before.Values[model.BootTimeStageKernel] = map[model.RetrievalMethod]time.Duration{
+		model.RetrievalMethodSystemdDBUS: 641*time.Millisecond + 300*time.Microsecond,
+	}
+
+	data, err := json.Marshal(diffRecords(before, after, time.Millisecond))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"firmware":{"acpi_fpdt":{"before":"2s","after":"1.5s","change":"-500ms","change_percent":-25}},
+		"loader":{"efi_var":{"before":"0s","after":"100ms","change":"100ms"}},
+		"kernel":{"systemd_dbus":{"before":"641.3ms","after":"641ms","change":"0s"}}
+	}`, string(data))
+}
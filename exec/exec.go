@@ -1,97 +1,636 @@
 package exec
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
-	"text/tabwriter"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/boreec/boottime/acpi"
+	"github.com/boreec/boottime/analysis"
+	"github.com/boreec/boottime/bmc"
+	"github.com/boreec/boottime/devicetree"
 	"github.com/boreec/boottime/efi"
 	"github.com/boreec/boottime/model"
 	"github.com/boreec/boottime/systemd"
-	"golang.org/x/sync/errgroup"
 )
 
-func RetrieveBootTimes(fileName string) error {
-	g := new(errgroup.Group)
+// Collector retrieves the boot time stages available with a single retrieval
+// method. A collector returns an error wrapping errors.ErrUnsupported when its
+// method cannot work on the host platform, in which case it is skipped.
+type Collector interface {
+	Method() model.RetrievalMethod
+	Collect() (map[model.BootTimeStage]time.Duration, error)
+}
+
+// ContextCollector is a Collector which can give up once the context of the
+// retrieval is done, such as after WithTimeout. RetrieveBootTimes calls
+// CollectContext instead of Collect for the collectors implementing it.
+type ContextCollector interface {
+	Collector
+	CollectContext(ctx context.Context) (map[model.BootTimeStage]time.Duration, error)
+}
+
+type collectorFunc struct {
+	method  model.RetrievalMethod
+	collect func() (map[model.BootTimeStage]time.Duration, error)
+}
+
+func (c collectorFunc) Method() model.RetrievalMethod {
+	return c.method
+}
+
+func (c collectorFunc) Collect() (map[model.BootTimeStage]time.Duration, error) {
+	return c.collect()
+}
+
+// defaultCollectors returns the collectors of every method but the BMC, which
+// give up once ctx is done.
+func defaultCollectors(ctx context.Context, o *options) []Collector {
+	return []Collector{
+		collectorFunc{method: model.RetrievalMethodACPIFPDT, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectACPIFPDT(ctx, !o.skipACPIChecksum, o.acpiFirmwareBounds)
+		}},
+		collectorFunc{method: model.RetrievalMethodDeviceTree, collect: collectDeviceTree},
+		collectorFunc{method: model.RetrievalMethodEFIVar, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectEFIVars(ctx)
+		}},
+		collectorFunc{method: model.RetrievalMethodSystemdDBUS, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectSystemdDbus(ctx)
+		}},
+		collectorFunc{method: model.RetrievalMethodSystemdAnalyze, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectSystemdAnalyze(ctx, o.analyzeScope)
+		}},
+		collectorFunc{method: model.RetrievalMethodSystemdJournal, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectSystemdJournal(ctx)
+		}},
+	}
+}
+
+// collectACPIFPDT returns the stages of the FPDT, along with an error wrapping
+// ErrSuspectValues if they are out of bounds, or of acpi.DefaultFirmwareBounds
+// if bounds is zero.
+func collectACPIFPDT(ctx context.Context, verifyChecksum bool, bounds acpi.FirmwareBounds) (map[model.BootTimeStage]time.Duration, error) {
+	record, err := acpi.RetrieveBootTimeContext(ctx, verifyChecksum)
+	if err != nil {
+		return nil, fmt.Errorf("reading acpi fpdt table: %w", err)
+	}
+
+	if bounds == (acpi.FirmwareBounds{}) {
+		bounds = acpi.DefaultFirmwareBounds
+	}
+	var suspectErr error
+	if err := record.PlausibleWithin(bounds); err != nil {
+		suspectErr = fmt.Errorf("%w: %w", ErrSuspectValues, err)
+	}
+
+	return map[model.BootTimeStage]time.Duration{
+		model.BootTimeStageFirmware: record.Firmware,
+		model.BootTimeStageLoader:   record.Loader,
+	}, suspectErr
+}
+
+func collectBMC(ctx context.Context) (map[model.BootTimeStage]time.Duration, error) {
+	record, err := bmc.RetrieveBootTimeContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving boot time with bmc: %w", err)
+	}
+
+	return map[model.BootTimeStage]time.Duration{
+		model.BootTimeStageFirmware: record.Firmware,
+	}, nil
+}
+
+func collectDeviceTree() (map[model.BootTimeStage]time.Duration, error) {
+	record, err := devicetree.RetrieveBootTime()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving boot time with device tree: %w", err)
+	}
+
+	stages := map[model.BootTimeStage]time.Duration{
+		model.BootTimeStageFirmware: record.Firmware,
+	}
+	if record.Loader != 0 {
+		stages[model.BootTimeStageLoader] = record.Loader
+	}
+
+	return stages, nil
+}
+
+func collectEFIVars(ctx context.Context) (map[model.BootTimeStage]time.Duration, error) {
+	record, err := efi.RetrieveBootTimeContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving boot time with efi vars: %w", err)
+	}
+
+	// The loader variables are volatile, unless they were written by something
+	// else than the loader of the current boot.
+	var staleErr error
+	if record.NonVolatile {
+		staleErr = fmt.Errorf("efi loader variables are non-volatile: %w", ErrStaleSource)
+	}
+
+	stages := map[model.BootTimeStage]time.Duration{
+		model.BootTimeStageFirmware: record.Firmware,
+	}
+
+	// systemd-boot may not log LoaderTimeExecUSec, in which case only the
+	// firmware stage is known.
+	if record.Loader != 0 {
+		stages[model.BootTimeStageLoader] = record.Loader
+	}
+
+	return stages, staleErr
+}
+
+func collectSystemdDbus(ctx context.Context) (map[model.BootTimeStage]time.Duration, error) {
+	record, err := systemd.RetrieveBootTimeWithDbusContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving boot time with dbus property: %w", err)
+	}
+
+	return systemdStages(record), nil
+}
+
+func collectSystemdAnalyze(ctx context.Context, scope systemd.AnalyzeScope) (map[model.BootTimeStage]time.Duration, error) {
+	record, err := systemd.RetrieveBootTimeWithAnalyzeCommandContext(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving boot time with systemd-analyze: %w", err)
+	}
+
+	return systemdStages(record), nil
+}
+
+func collectSystemdJournal(ctx context.Context) (map[model.BootTimeStage]time.Duration, error) {
+	record, err := systemd.RetrieveBootTimeWithJournalContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving boot time with journal: %w", err)
+	}
+
+	return map[model.BootTimeStage]time.Duration{
+		model.BootTimeStageKernel:    record.Kernel,
+		model.BootTimeStageInitrd:    record.Initrd,
+		model.BootTimeStageUserspace: record.Userspace,
+	}, nil
+}
+
+func systemdStages(record *systemd.BootTimeRecord) map[model.BootTimeStage]time.Duration {
+	return map[model.BootTimeStage]time.Duration{
+		model.BootTimeStageFirmware:  record.Firmware,
+		model.BootTimeStageLoader:    record.Loader,
+		model.BootTimeStageKernel:    record.Kernel,
+		model.BootTimeStageInitrd:    record.Initrd,
+		model.BootTimeStageUserspace: record.Userspace,
+		model.BootTimeStageTotal:     record.Total,
+	}
+}
+
+// ErrNoData is returned when the collected record does not contain any
+// non-zero value, which usually means the host is misconfigured.
+var ErrNoData = errors.New("no boot time data collected")
+
+// ErrStaleSource can be wrapped in the error returned by a collector, along with
+// the collected stages, to signal that they may come from a previous boot.
+// Their values are then dropped from the record instead of failing the
+// retrieval.
+var ErrStaleSource = errors.New("values may come from a previous boot")
+
+// ErrSuspectValues can be wrapped in the error returned by a collector, along
+// with the collected stages, to signal that they look physically impossible.
+// Their values are then kept in the record, with a warning, so that the raw
+// values can still be investigated.
+var ErrSuspectValues = errors.New("values look implausible")
+
+// ErrRunsComplete is returned by RetrieveBootTimes when the series of WithRuns
+// already has all of its records.
+var ErrRunsComplete = errors.New("series of runs already complete")
+
+// runsStateSuffix is appended to the name of the jsonl file to name the file
+// counting the records of the series of WithRuns.
+const runsStateSuffix = ".runs"
+
+type options struct {
+	collectors []Collector
+	allowEmpty bool
+	dryRun     io.Writer
+	output     io.Writer
+	bmc        bool
+	raw        bool
+	collapse   model.CollapseStrategy
+	// extraCollectors run next to collectors.
+	extraCollectors []Collector
+	// analyzeScope is the scope of the default systemd_analyze collector.
+	analyzeScope systemd.AnalyzeScope
+	// skipACPIChecksum disables the checksum verification of the FPDT read by
+	// the default acpi_fpdt collector before reading the table in memory.
+	skipACPIChecksum bool
+	// acpiFirmwareBounds are the bounds of the firmware duration of the
+	// default acpi_fpdt collector, acpi.DefaultFirmwareBounds if zero.
+	acpiFirmwareBounds acpi.FirmwareBounds
+	// sampleRate writes only 1 in sampleRate records, if greater than 1.
+	sampleRate int
+	// methods restricts the collectors to these methods, if not nil.
+	methods []model.RetrievalMethod
+	// stages restricts the record to these stages, if not nil.
+	stages []model.BootTimeStage
+	// runs is the number of records of the series, if positive.
+	runs int
+	// timeout bounds the time taken by the default collectors, if positive.
+	timeout time.Duration
+	// metadata returns the metadata of the running boot, if set.
+	metadata func() (*model.Metadata, []Warning)
+	// rawSystemdDbus returns the raw timestamps stored by WithRaw.
+	rawSystemdDbus func(ctx context.Context) (map[string]time.Duration, error)
+}
+
+// Option configures the boot time retrieval.
+type Option func(*options)
+
+// WithCollectors replaces the collectors used to retrieve the boot times. It
+// is mostly useful to run the pipeline without relying on the host. A nil
+// slice keeps the default collectors.
+func WithCollectors(collectors []Collector) Option {
+	return func(o *options) {
+		o.collectors = collectors
+	}
+}
+
+// WithExtraCollectors runs the collectors next to the default ones, or those
+// of WithCollectors, such as to register a probe of a method this package does
+// not know about.
+func WithExtraCollectors(collectors ...Collector) Option {
+	return func(o *options) {
+		o.extraCollectors = append(o.extraCollectors, collectors...)
+	}
+}
+
+// WithMethods only runs the collectors of the methods, such as to skip a
+// method which always fails on the host. A nil slice runs every collector.
+func WithMethods(methods []model.RetrievalMethod) Option {
+	return func(o *options) {
+		o.methods = methods
+	}
+}
 
-	var recordSystemdAnalyze *systemd.BootTimeRecord
-	g.Go(func() error {
+// WithStages only writes the values of the stages in the record, such as the
+// firmware and loader when tuning the firmware settings. A nil slice writes
+// every stage.
+func WithStages(stages []model.BootTimeStage) Option {
+	return func(o *options) {
+		o.stages = stages
+	}
+}
+
+// WithAllowEmpty writes the record even when it does not contain any non-zero
+// value.
+func WithAllowEmpty(allow bool) Option {
+	return func(o *options) {
+		o.allowEmpty = allow
+	}
+}
+
+// WithBMC also retrieves the firmware duration from the BMC event log. It is
+// not enabled by default since it requires a server with IPMI access.
+func WithBMC(enabled bool) Option {
+	return func(o *options) {
+		o.bmc = enabled
+	}
+}
+
+// WithRaw stores in the record the raw systemd manager timestamps the dbus
+// stages are derived from, so that they can be recomputed with RecomputeFromRaw.
+// If they cannot be retrieved, the record is written without them, with a
+// warning.
+func WithRaw(enabled bool) Option {
+	return func(o *options) {
+		o.raw = enabled
+	}
+}
+
+// WithCollapse reduces every stage of the record to a single duration with the
+// strategy, before writing it. An empty strategy keeps every method.
+func WithCollapse(strategy model.CollapseStrategy) Option {
+	return func(o *options) {
+		o.collapse = strategy
+	}
+}
+
+// WithAnalyzeScope runs systemd-analyze for the service manager of the scope,
+// such as systemd.AnalyzeScopeUser to measure the startup of the user session
+// instead of the boot. The scope is recorded in the metadata when it is not
+// systemd.AnalyzeScopeSystem.
+func WithAnalyzeScope(scope systemd.AnalyzeScope) Option {
+	return func(o *options) {
+		o.analyzeScope = scope
+	}
+}
+
+// WithACPIFirmwareBounds sets the bounds out of which the firmware duration of
+// the default acpi_fpdt collector is reported with a warning, instead of
+// acpi.DefaultFirmwareBounds. The duration is recorded anyway.
+func WithACPIFirmwareBounds(bounds acpi.FirmwareBounds) Option {
+	return func(o *options) {
+		o.acpiFirmwareBounds = bounds
+	}
+}
+
+// WithSkipACPIChecksum disables the checksum verification of the FPDT pointing
+// to the table read from memory, to debug firmware writing broken tables.
+func WithSkipACPIChecksum(skip bool) Option {
+	return func(o *options) {
+		o.skipACPIChecksum = skip
+	}
+}
+
+// WithSampleRate only writes the record of 1 in rate boots, starting with the
+// first one, to bound the growth of the file on a host rebooting constantly.
+// The boots are counted in a state file next to the jsonl file, named after it
+// with a ".sample" suffix. A rate of 1 or less writes every record.
+func WithSampleRate(rate int) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithRuns collects a series of n records, one per boot, counted in a state
+// file next to the jsonl file, named after it with a ".runs" suffix. Once the
+// series has its n records, RetrieveBootTimes fails with ErrRunsComplete
+// without collecting, until the state file is removed. An n of 0 or less
+// collects every boot.
+func WithRuns(n int) Option {
+	return func(o *options) {
+		o.runs = n
+	}
+}
+
+// WithTimeout makes the default collectors give up after d, such as on a hung
+// D-Bus call or systemd-analyze command, in which case their method is left
+// out of the record with a warning. A d of 0 or less waits forever.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithDryRun writes the record to w instead of appending it to the jsonl file,
+// which is then neither created nor opened, as reported in the warnings.
+func WithDryRun(w io.Writer) Option {
+	return func(o *options) {
+		o.dryRun = w
+	}
+}
+
+// WithOutput writes the record to w instead of appending it to the jsonl file,
+// to pipe it into another tool. Unlike WithDryRun, the record is the outcome
+// of the retrieval, so nothing is printed about the jsonl file.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
+
+// Retrieval is the record retrieved from the host.
+type Retrieval struct {
+	Record *model.BootTimeRecord
+	// Warnings are the problems which did not prevent the retrieval, such as
+	// the values dropped from the record.
+	Warnings []Warning
+	// Skipped is set when the record was not written because of
+	// WithSampleRate, which is also reported in Warnings.
+	Skipped bool
+	// RunsLeft is the number of records still to collect in the series of
+	// WithRuns.
+	RunsLeft int
+}
+
+// RetrieveBootTimes runs every collector concurrently, appends the resulting
+// record to the given jsonl file, or writes it to the writer of WithOutput,
+// and returns it. A failing collector is reported as a warning and its method
+// left out of the record, unless every collector failed.
+func RetrieveBootTimes(fileName string, opts ...Option) (*Retrieval, error) {
+	o := options{metadata: collectMetadata, rawSystemdDbus: collectRawSystemdDbus}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	runsStateFile := fileName + runsStateSuffix
+	runs := 0
+	if o.runs > 0 {
 		var err error
-		recordSystemdAnalyze, err = systemd.RetrieveBootTimeWithAnalyzeCommand()
-		if err != nil {
-			return fmt.Errorf("retrieving boot time with systemd-analyze: %w", err)
+		if runs, err = readCount(runsStateFile); err != nil {
+			return nil, err
 		}
-		return nil
-	})
+		if runs >= o.runs {
+			return nil, fmt.Errorf("%w: %d records in %s, remove %s to start a new series", ErrRunsComplete, runs, fileName, runsStateFile)
+		}
+	}
 
-	var recordSystemdDbus *systemd.BootTimeRecord
-	g.Go(func() error {
-		var err error
-		recordSystemdDbus, err = systemd.RetrieveBootTimeWithDbus()
-		if err != nil {
-			return fmt.Errorf("retrieving boot time with dbus property: %w", err)
+	ctx := context.Background()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	if o.collectors == nil {
+		o.collectors = defaultCollectors(ctx, &o)
+	}
+
+	o.collectors = append(slices.Clone(o.collectors), o.extraCollectors...)
+
+	if o.bmc {
+		o.collectors = append(o.collectors, collectorFunc{method: model.RetrievalMethodBMC, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectBMC(ctx)
+		}})
+	}
+
+	if o.methods != nil {
+		o.collectors = slices.DeleteFunc(slices.Clone(o.collectors), func(c Collector) bool {
+			return !slices.Contains(o.methods, c.Method())
+		})
+	}
+
+	var wg sync.WaitGroup
+
+	results := make([]map[model.BootTimeStage]time.Duration, len(o.collectors))
+	stale := make([]error, len(o.collectors))
+	suspect := make([]error, len(o.collectors))
+	failed := make([]error, len(o.collectors))
+	for i, c := range o.collectors {
+		wg.Go(func() {
+			var err error
+			if cc, ok := c.(ContextCollector); ok {
+				results[i], err = cc.CollectContext(ctx)
+			} else {
+				results[i], err = c.Collect()
+			}
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrStaleSource):
+				stale[i] = err
+			case errors.Is(err, ErrSuspectValues):
+				suspect[i] = err
+			case errors.Is(err, errors.ErrUnsupported):
+				results[i] = nil
+			default:
+				results[i] = nil
+				failed[i] = err
+			}
+		})
+	}
+	wg.Wait()
+
+	// A failing method only fails the retrieval if no other method succeeded,
+	// since a partial record is still worth writing.
+	if !slices.ContainsFunc(results, func(r map[model.BootTimeStage]time.Duration) bool { return r != nil }) {
+		var errs []error
+		for i, c := range o.collectors {
+			if failed[i] != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", c.Method(), failed[i]))
+			}
 		}
-		return nil
-	})
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	}
 
-	var recordEFIVars *efi.BootTimeRecord
-	g.Go(func() error {
-		var err error
-		recordEFIVars, err = efi.RetrieveBootTime()
+	record := &model.BootTimeRecord{
+		Values: make(map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration),
+	}
+	var warnings []Warning
+	for i, c := range o.collectors {
+		if failed[i] != nil {
+			warnings = append(warnings, Warning{
+				Method: c.Method(),
+				Err:    fmt.Errorf("method left out: %w", failed[i]),
+			})
+		}
+
+		if stale[i] != nil {
+			record.MarkStale(c.Method())
+			warnings = append(warnings, Warning{
+				Method: c.Method(),
+				Err:    fmt.Errorf("values dropped: %w", stale[i]),
+			})
+		}
+
+		if suspect[i] != nil {
+			warnings = append(warnings, Warning{
+				Method: c.Method(),
+				Err:    fmt.Errorf("values kept: %w", suspect[i]),
+			})
+		}
+
+		for stage, d := range results[i] {
+			record.Set(stage, c.Method(), d)
+		}
+	}
+
+	record.DropStaleSources()
+
+	for _, m := range record.FirmwareExceedsTotal() {
+		warnings = append(warnings, Warning{
+			Method: m,
+			Err: fmt.Errorf("suspect firmware duration %s longer than the total %s, the firmware timer may not start at reset",
+				record.Values[model.BootTimeStageFirmware][m], record.Values[model.BootTimeStageTotal][m]),
+		})
+	}
+
+	if o.raw {
+		// The raw timestamps are only an extra of the record, so failing to
+		// retrieve them does not fail the retrieval.
+		raw, err := o.rawSystemdDbus(ctx)
 		if err != nil {
-			return fmt.Errorf("retrieving boot time with efi vars: %w", err)
+			warnings = append(warnings, Warning{
+				Method: model.RetrievalMethodSystemdDBUS,
+				Err:    fmt.Errorf("raw timestamps left out: %w", err),
+			})
+		} else {
+			record.Raw = map[model.RetrievalMethod]map[string]time.Duration{
+				model.RetrievalMethodSystemdDBUS: raw,
+			}
 		}
-		return nil
-	})
+	}
 
-	var recordACPIFPDT *acpi.BootTimeRecord
-	g.Go(func() error {
-		var err error
-		recordACPIFPDT, err = acpi.RetrieveBootTime()
+	if o.stages != nil {
+		maps.DeleteFunc(record.Values, func(stage model.BootTimeStage, _ map[model.RetrievalMethod]time.Duration) bool {
+			return !slices.Contains(o.stages, stage)
+		})
+	}
+
+	if !o.allowEmpty && !record.HasData() {
+		return nil, ErrNoData
+	}
+
+	if o.metadata != nil {
+		var metadataWarnings []Warning
+		record.Metadata, metadataWarnings = o.metadata()
+		warnings = append(warnings, metadataWarnings...)
+	}
+
+	if o.analyzeScope != "" && o.analyzeScope != systemd.AnalyzeScopeSystem {
+		if record.Metadata == nil {
+			record.Metadata = &model.Metadata{}
+		}
+		record.Metadata.AnalyzeScope = string(o.analyzeScope)
+	}
+
+	if o.collapse != "" {
+		record = record.Collapse(o.collapse)
+	}
+
+	retrieval := &Retrieval{Record: record, Warnings: warnings}
+
+	if o.dryRun != nil {
+		retrieval.Warnings = append(retrieval.Warnings, Warning{Err: fmt.Errorf("dry run: record not written to %s", fileName)})
+		if err := json.NewEncoder(o.dryRun).Encode(record); err != nil {
+			return nil, fmt.Errorf("encoding record: %w", err)
+		}
+		return retrieval, nil
+	}
+
+	if o.output != nil {
+		if err := json.NewEncoder(o.output).Encode(record); err != nil {
+			return nil, fmt.Errorf("writing record: %w", err)
+		}
+		return retrieval, nil
+	}
+
+	if o.sampleRate > 1 {
+		sampled, err := nextSample(fileName+sampleStateSuffix, o.sampleRate)
 		if err != nil {
-			return fmt.Errorf("reading acpi fpdt table: %w", err)
+			return nil, err
 		}
-		return nil
-	})
+		if !sampled {
+			retrieval.Warnings = append(retrieval.Warnings, Warning{Err: fmt.Errorf("sampling: record not written to %s, 1 in %d is", fileName, o.sampleRate)})
+			retrieval.Skipped = true
+			return retrieval, nil
+		}
+	}
 
-	if err := g.Wait(); err != nil {
-		return err
+	if err := AppendRecord(fileName, record); err != nil {
+		return nil, err
 	}
 
-	values := map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
-		model.BootTimeStageFirmware: {
-			model.RetrievalMethodACPIFPDT:       recordACPIFPDT.Firmware,
-			model.RetrievalMethodEFIVar:         recordEFIVars.Firmware,
-			model.RetrievalMethodSystemdAnalyze: recordSystemdAnalyze.Firmware,
-			model.RetrievalMethodSystemdDBUS:    recordSystemdDbus.Firmware,
-		},
-		model.BootTimeStageLoader: {
-			model.RetrievalMethodACPIFPDT:       recordACPIFPDT.Loader,
-			model.RetrievalMethodEFIVar:         recordEFIVars.Loader,
-			model.RetrievalMethodSystemdAnalyze: recordSystemdAnalyze.Loader,
-			model.RetrievalMethodSystemdDBUS:    recordSystemdDbus.Loader,
-		},
-		model.BootTimeStageKernel: {
-			model.RetrievalMethodSystemdAnalyze: recordSystemdAnalyze.Kernel,
-			model.RetrievalMethodSystemdDBUS:    recordSystemdDbus.Kernel,
-		},
-		model.BootTimeStageInitrd: {
-			model.RetrievalMethodSystemdAnalyze: recordSystemdAnalyze.Initrd,
-			model.RetrievalMethodSystemdDBUS:    recordSystemdDbus.Initrd,
-		},
-		model.BootTimeStageUserspace: {
-			model.RetrievalMethodSystemdAnalyze: recordSystemdAnalyze.Userspace,
-			model.RetrievalMethodSystemdDBUS:    recordSystemdDbus.Userspace,
-		},
-		model.BootTimeStageTotal: {
-			model.RetrievalMethodSystemdAnalyze: recordSystemdAnalyze.Total,
-			model.RetrievalMethodSystemdDBUS:    recordSystemdDbus.Total,
-		},
+	if o.runs > 0 {
+		if err := writeCount(runsStateFile, runs+1); err != nil {
+			return nil, err
+		}
+		retrieval.RunsLeft = o.runs - runs - 1
 	}
 
+	return retrieval, nil
+}
+
+// AppendRecord appends the record as a new line of the jsonl file, which is
+// created if it does not exist.
+func AppendRecord(fileName string, record *model.BootTimeRecord) error {
 	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
 	if err != nil {
 		return fmt.Errorf("opening file %s: %w", fileName, err)
@@ -99,56 +638,462 @@ func RetrieveBootTimes(fileName string) error {
 	defer file.Close()
 
 	enc := json.NewEncoder(file)
-	if err := enc.Encode(values); err != nil {
+	if err := enc.Encode(record); err != nil {
 		return fmt.Errorf("encoding analysis results to jsonl file: %w", err)
 	}
 
 	return nil
 }
 
-func PrintRecordsAverage(fileName string, pretiffy bool) error {
+type aggregateOptions struct {
+	maxRecords int
+	selection  model.Selection
+	// tolerateTruncatedTail ignores a truncated last record instead of failing.
+	tolerateTruncatedTail bool
+	// excludePostUpdateBoots leaves out the records marked by
+	// analysis.MarkPostUpdateBoots.
+	excludePostUpdateBoots bool
+	// dedupByBoot leaves out the records measuring the same boot as an
+	// earlier record, as with analysis.DedupByBoot.
+	dedupByBoot bool
+	// coerceSchemas averages files mixing flat and nested records instead of
+	// failing.
+	coerceSchemas bool
+	// skipMalformedLines skips the lines failing to parse, with a warning,
+	// instead of failing.
+	skipMalformedLines bool
+	// median reduces the records to their median instead of their average.
+	median bool
+	// trim is the fraction of the shortest and longest durations of every
+	// stage/method left out of the average.
+	trim float64
+	// percentiles are the percentiles of every stage/method computed next to
+	// the average, if not nil.
+	percentiles []float64
+	// extraFileNames are the jsonl files aggregated after the given one.
+	extraFileNames []string
+}
+
+// AggregateOption configures how records are read and aggregated.
+type AggregateOption func(*aggregateOptions)
+
+// WithMaxRecords stops reading the records after the first n ones. A
+// non-positive n reads the whole file.
+func WithMaxRecords(n int) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.maxRecords = n
+	}
+}
+
+// WithSelection excludes stages and methods from the aggregated record.
+func WithSelection(s model.Selection) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.selection = s
+	}
+}
+
+// WithTolerateTruncatedTail ignores the last record of the file, with a
+// warning, when it is truncated by an interrupted append.
+func WithTolerateTruncatedTail(tolerate bool) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.tolerateTruncatedTail = tolerate
+	}
+}
+
+// WithExcludePostUpdateBoots leaves out of the average the records looking like
+// the first boot after a system update. The records are then retained in
+// memory, to compare each of them with its neighbours.
+func WithExcludePostUpdateBoots(exclude bool) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.excludePostUpdateBoots = exclude
+	}
+}
+
+// WithDedupByBoot leaves out of the average the records measuring the same boot
+// as an earlier record, such as those written when the collection service runs
+// twice during a boot. Only the identity of the boots is retained in memory.
+func WithDedupByBoot(dedup bool) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.dedupByBoot = dedup
+	}
+}
+
+// WithSkipMalformedLines skips the lines of the file failing to parse, with a
+// warning naming each of them, instead of failing and discarding every valid
+// record. A truncated last line is still only tolerated with
+// WithTolerateTruncatedTail.
+func WithSkipMalformedLines(skip bool) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.skipMalformedLines = skip
+	}
+}
+
+// WithCoerceSchemas averages the records of a file mixing flat and nested
+// records, instead of failing with model.ErrMixedSchemas. Flat values are then
+// averaged as the values of model.RetrievalMethodCollapsed, next to the
+// methods of the nested records.
+func WithCoerceSchemas(coerce bool) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.coerceSchemas = coerce
+	}
+}
+
+// WithMedian reduces the records to the median of every stage/method instead of
+// the average, which a single slow boot, such as one running fsck, does not
+// skew. The durations of the records are then retained in memory.
+func WithMedian(median bool) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.median = median
+	}
+}
+
+// WithTrim leaves the trim fraction of the shortest and longest durations of
+// every stage/method out of the average, as with
+// model.BootTimeAccumulator.TrimmedAverage. The durations of the records are
+// then retained in memory. It is ignored with WithMedian.
+func WithTrim(trim float64) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.trim = trim
+	}
+}
+
+// WithPercentiles also computes the percentiles pcts, between 0 and 100, of
+// every stage/method, returned in Average.Percentiles. The durations of the
+// records are then retained in memory.
+func WithPercentiles(pcts []float64) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.percentiles = pcts
+	}
+}
+
+// WithExtraFiles also aggregates the records of the jsonl files, such as those
+// of the other hosts of a fleet, after the records of the given file. Duplicate
+// boots are detected across the files, the first boots after an update within
+// each file, and WithMaxRecords counts the records of all the files.
+func WithExtraFiles(fileNames ...string) AggregateOption {
+	return func(o *aggregateOptions) {
+		o.extraFileNames = append(o.extraFileNames, fileNames...)
+	}
+}
+
+// newAccumulator returns an accumulator retaining the samples required by o.
+func (o aggregateOptions) newAccumulator() *model.BootTimeAccumulator {
+	if o.median || o.trim > 0 || o.percentiles != nil {
+		return model.NewBootTimeAccumulator(model.WithRetainedSamples())
+	}
+	return model.NewBootTimeAccumulator()
+}
+
+// reduce returns the average of the records of btra, their median, or their
+// trimmed average.
+func (o aggregateOptions) reduce(btra *model.BootTimeAccumulator) *model.BootTimeRecord {
+	switch {
+	case o.median:
+		return btra.Median()
+	case o.trim > 0:
+		return btra.TrimmedAverage(o.trim)
+	}
+	return btra.Average()
+}
+
+// average returns the average, or median, the spread and the percentiles of
+// the records of btra, restricted to the selection of o.
+func (o aggregateOptions) average(btra *model.BootTimeAccumulator) (*Average, error) {
+	avg := &Average{
+		Record: o.reduce(btra),
+		Count:  btra.Count(),
+		Min:    btra.Min(),
+		Max:    btra.Max(),
+		StdDev: btra.StdDev(),
+	}
+	for _, r := range []*model.BootTimeRecord{avg.Record, avg.Min, avg.Max, avg.StdDev} {
+		o.selection.Apply(r)
+	}
+
+	if o.percentiles != nil {
+		stats, err := btra.Percentiles(o.percentiles)
+		if err != nil {
+			return nil, err
+		}
+		o.selection.ApplyStatistics(stats)
+		avg.Percentiles = stats
+	}
+
+	return avg, nil
+}
+
+// truncatedTailWarning returns a warning instead of err if err only reports a
+// truncated last record and tolerate is set.
+func truncatedTailWarning(err error, fileName string, tolerate bool) ([]Warning, error) {
+	if tolerate && errors.Is(err, model.ErrTruncatedRecord) {
+		return []Warning{{Err: fmt.Errorf("ignoring truncated last record of %s: %w", fileName, err)}}, nil
+	}
+	return nil, err
+}
+
+// Average is the average of the records of a jsonl file.
+type Average struct {
+	// Record is the average of the records, their median with WithMedian, or
+	// their trimmed average with WithTrim.
+	Record *model.BootTimeRecord
+	// Count is the number of records averaged.
+	Count int
+	// Min, Max and StdDev are the shortest and longest durations and the
+	// standard deviation of every stage/method of the records.
+	Min    *model.BootTimeRecord
+	Max    *model.BootTimeRecord
+	StdDev *model.BootTimeRecord
+	// Percentiles are the percentiles of every stage/method of the records
+	// with WithPercentiles, nil otherwise.
+	Percentiles *model.BootTimeStatistics
+	// Warnings are the problems which did not prevent the averaging.
+	Warnings []Warning
+}
+
+// AverageRecords returns the average of every stage/method of the records in
+// the jsonl file.
+func AverageRecords(fileName string, opts ...AggregateOption) (*Average, error) {
+	var o aggregateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Records are streamed into the accumulator so that memory stays bounded
+	// regardless of the file size, unless the median, the trimmed average or
+	// the percentiles need every duration.
+	btra := o.newAccumulator()
+	warnings, err := forEachAggregatedRecord(fileName, o, btra.Add)
+	if err != nil {
+		return nil, err
+	}
+
+	avg, err := o.average(btra)
+	if err != nil {
+		return nil, err
+	}
+	avg.Warnings = warnings
+
+	return avg, nil
+}
+
+// AverageRecordsByCmdlineParam returns the averages of the records in the jsonl
+// file, grouped by the value of the kernel command line parameter as with
+// analysis.GroupByCmdlineParam.
+func AverageRecordsByCmdlineParam(fileName, param string, opts ...AggregateOption) (map[string]*Average, []Warning, error) {
+	return averageRecordsBy(fileName, func(r *model.BootTimeRecord) string {
+		return analysis.CmdlineParamGroup(r, param)
+	}, opts...)
+}
+
+// AverageRecordsByMetadata returns the averages of the records in the jsonl
+// file, grouped by the value of the metadata field as with
+// analysis.GroupByMetadata.
+func AverageRecordsByMetadata(fileName string, field analysis.MetadataField, opts ...AggregateOption) (map[string]*Average, []Warning, error) {
+	return averageRecordsBy(fileName, func(r *model.BootTimeRecord) string {
+		return analysis.MetadataGroup(r, field)
+	}, opts...)
+}
+
+// averageRecordsBy returns the averages of the records in the jsonl file,
+// grouped by the group returned by groupOf.
+func averageRecordsBy(fileName string, groupOf func(*model.BootTimeRecord) string, opts ...AggregateOption) (map[string]*Average, []Warning, error) {
+	var o aggregateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	accumulators := make(map[string]*model.BootTimeAccumulator)
+	warnings, err := forEachAggregatedRecord(fileName, o, func(r *model.BootTimeRecord) {
+		group := groupOf(r)
+		if accumulators[group] == nil {
+			accumulators[group] = o.newAccumulator()
+		}
+		accumulators[group].Add(r)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make(map[string]*Average, len(accumulators))
+	for group, btra := range accumulators {
+		if groups[group], err = o.average(btra); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return groups, warnings, nil
+}
+
+// forEachAggregatedRecord calls fn for every record of the jsonl file, then of
+// the extra files, to aggregate according to o.
+func forEachAggregatedRecord(fileName string, o aggregateOptions, fn func(*model.BootTimeRecord)) ([]Warning, error) {
+	if o.dedupByBoot {
+		fn = skipDuplicateBoots(fn)
+	}
+
+	var warnings []Warning
+	read := 0
+	for _, name := range append([]string{fileName}, o.extraFileNames...) {
+		limit := 0
+		if o.maxRecords > 0 {
+			if read >= o.maxRecords {
+				break
+			}
+			limit = o.maxRecords - read
+		}
+
+		n, fileWarnings, err := forEachFileRecord(name, o, limit, fn)
+		if err != nil {
+			return nil, err
+		}
+		read += n
+		warnings = append(warnings, fileWarnings...)
+	}
+
+	return warnings, nil
+}
+
+// forEachFileRecord calls fn for the records of the jsonl file to aggregate
+// according to o, up to limit if positive, and returns the number of records
+// read.
+func forEachFileRecord(fileName string, o aggregateOptions, limit int, fn func(*model.BootTimeRecord)) (int, []Warning, error) {
 	file, err := os.Open(fileName)
 	if err != nil {
-		return fmt.Errorf("opening file %s: %w", fileName, err)
+		return 0, nil, fmt.Errorf("opening file %s: %w", fileName, err)
 	}
 	defer file.Close()
 
-	records, err := model.BootTimeRecordsFromFile(file)
+	// Averaging flat and nested records together silently mixes collapsed
+	// values with the values of each method, so the file is scanned first.
+	if !o.coerceSchemas {
+		if _, err := model.CheckSchemas(file); err != nil {
+			return 0, nil, fmt.Errorf("checking record schemas of %s: %w", fileName, err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return 0, nil, fmt.Errorf("rewinding file %s: %w", fileName, err)
+		}
+	}
+
+	read := 0
+	var skipped []model.LineError
+	forEach := func(r io.Reader, fn func(*model.BootTimeRecord) error) error {
+		counted := func(rec *model.BootTimeRecord) error {
+			read++
+			return fn(rec)
+		}
+		if o.skipMalformedLines {
+			var err error
+			skipped, err = model.ForEachBootTimeRecordLenient(r, counted)
+			return err
+		}
+		return model.ForEachBootTimeRecord(r, counted)
+	}
+
+	if o.excludePostUpdateBoots {
+		err = forEachSteadyStateRecord(file, limit, forEach, fn)
+	} else {
+		err = forEach(file, func(rec *model.BootTimeRecord) error {
+			fn(rec)
+			if limit > 0 && read >= limit {
+				return model.SkipRemainingRecords
+			}
+			return nil
+		})
+	}
+
+	warnings, err := truncatedTailWarning(err, fileName, o.tolerateTruncatedTail)
 	if err != nil {
-		return fmt.Errorf("reading boot time records from file: %w", err)
+		return 0, nil, fmt.Errorf("reading boot time records from file: %w", err)
 	}
 
-	btra := model.NewBootTimeAccumulator()
-	for _, r := range records {
-		btra.Add(r)
+	for _, le := range skipped {
+		if errors.Is(le, model.ErrTruncatedRecord) {
+			tail, err := truncatedTailWarning(le.Err, fileName, o.tolerateTruncatedTail)
+			if err != nil {
+				return 0, nil, fmt.Errorf("reading boot time records from file: %w", err)
+			}
+			warnings = append(warnings, tail...)
+			continue
+		}
+		warnings = append(warnings, Warning{Err: fmt.Errorf("skipping malformed line %d of %s: %w", le.Number, fileName, le.Err)})
 	}
 
-	btr := btra.Average()
+	return read, warnings, nil
+}
 
-	if pretiffy {
-		fmt.Printf("Boot time average for %d records.\n", len(records))
-		return printRecordsAveragePrettier(btr)
+// skipDuplicateBoots wraps fn to skip the records measuring the same boot as a
+// record fn was called with.
+func skipDuplicateBoots(fn func(*model.BootTimeRecord)) func(*model.BootTimeRecord) {
+	seen := make(map[string]struct{})
+	return func(r *model.BootTimeRecord) {
+		if key, ok := analysis.BootIdentity(r); ok {
+			if _, dup := seen[key]; dup {
+				return
+			}
+			seen[key] = struct{}{}
+		}
+		fn(r)
 	}
+}
 
-	btrBytes, err := json.Marshal(&btr)
+// forEachSteadyStateRecord calls fn for the records read from r by forEach, up
+// to limit if positive, except those looking like the first boot after an
+// update.
+func forEachSteadyStateRecord(r io.Reader, limit int, forEach func(io.Reader, func(*model.BootTimeRecord) error) error, fn func(*model.BootTimeRecord)) error {
+	var records []*model.BootTimeRecord
+	err := forEach(r, func(rec *model.BootTimeRecord) error {
+		records = append(records, rec)
+		if limit > 0 && len(records) >= limit {
+			return model.SkipRemainingRecords
+		}
+		return nil
+	})
+	// A truncated last record still leaves the previous ones usable.
+	if err != nil && !errors.Is(err, model.ErrTruncatedRecord) {
+		return err
+	}
+
+	for i, postUpdate := range analysis.MarkPostUpdateBoots(records) {
+		if !postUpdate {
+			fn(records[i])
+		}
+	}
+
+	return err
+}
+
+// SummarizeFile returns the statistics of every stage/method of the records in
+// the jsonl file, along with the warning about a truncated last record.
+func SummarizeFile(path string) (*model.StatsSummary, []Warning, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("marshalling averaged results to json: %w", err)
+		return nil, nil, fmt.Errorf("opening file %s: %w", path, err)
 	}
-	fmt.Printf("%s\n", string(btrBytes))
+	defer file.Close()
 
-	return nil
+	btra := model.NewBootTimeAccumulator(model.WithRetainedSamples())
+	_, err = btra.AddFromReader(file, 0)
+	warnings, err := truncatedTailWarning(err, path, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading boot time records from file: %w", err)
+	}
+
+	return btra.Summary(), warnings, nil
 }
 
-func printRecordsAveragePrettier(btr *model.BootTimeRecord) error {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+// PrintStatsSummary prints the statistics of the records in the jsonl file as
+// JSON.
+func PrintStatsSummary(fileName string) ([]Warning, error) {
+	summary, warnings, err := SummarizeFile(fileName)
+	if err != nil {
+		return nil, err
+	}
 
-	rows := btr.ToTable()
-	for _, row := range rows {
-		for _, cell := range row {
-			fmt.Fprint(w, cell, "\t")
-		}
-		fmt.Fprintln(w)
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling statistics to json: %w", err)
 	}
+	fmt.Printf("%s\n", string(summaryBytes))
 
-	return w.Flush()
+	return warnings, nil
 }
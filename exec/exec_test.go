@@ -0,0 +1,660 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/acpi"
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/systemd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCollector struct {
+	method model.RetrievalMethod
+	stages map[model.BootTimeStage]time.Duration
+	err    error
+}
+
+func (c fakeCollector) Method() model.RetrievalMethod {
+	return c.method
+}
+
+func (c fakeCollector) Collect() (map[model.BootTimeStage]time.Duration, error) {
+	return c.stages, c.err
+}
+
+// contextCollector waits for the context of the retrieval to be done.
+type contextCollector struct {
+	fakeCollector
+}
+
+func (c contextCollector) CollectContext(ctx context.Context) (map[model.BootTimeStage]time.Duration, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// withoutHostMetadata keeps the records of the tests independent of the host.
+func withoutHostMetadata(o *options) {
+	o.metadata = nil
+}
+
+func TestRetrieveBootTimesDryRun(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "results.jsonl")
+	collectors := []Collector{
+		fakeCollector{
+			method: model.RetrievalMethodSystemdDBUS,
+			stages: map[model.BootTimeStage]time.Duration{
+				model.BootTimeStageKernel: 718 * time.Millisecond,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	res, err := RetrieveBootTimes(fileName, WithCollectors(collectors), withoutHostMetadata, WithDryRun(&buf))
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"}}`, buf.String())
+	assert.NoFileExists(t, fileName)
+	require.Len(t, res.Warnings, 1)
+	assert.ErrorContains(t, res.Warnings[0].Err, "dry run: record not written to "+fileName)
+}
+
+func TestRetrieveBootTimesOutput(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "results.jsonl")
+	collectors := []Collector{
+		fakeCollector{
+			method: model.RetrievalMethodSystemdDBUS,
+			stages: map[model.BootTimeStage]time.Duration{
+				model.BootTimeStageKernel: 718 * time.Millisecond,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	res, err := RetrieveBootTimes(fileName, WithCollectors(collectors), withoutHostMetadata, WithOutput(&buf))
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"}}`, buf.String())
+	assert.NoFileExists(t, fileName)
+}
+
+func TestRetrieveBootTimes(t *testing.T) {
+	tcs := map[string]struct {
+		collectors []Collector
+		opts       []Option
+		validate   func(t *testing.T, res *Retrieval, err error, fileName string)
+	}{
+		"write the record of every collector": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1897 * time.Millisecond,
+						model.BootTimeStageLoader:   1715 * time.Millisecond,
+					},
+				},
+				fakeCollector{
+					method: model.RetrievalMethodSystemdAnalyze,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1900 * time.Millisecond,
+						model.BootTimeStageKernel:   718 * time.Millisecond,
+					},
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Equal(t, map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageFirmware: {
+						model.RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+						model.RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					},
+					model.BootTimeStageLoader: {
+						model.RetrievalMethodACPIFPDT: 1715 * time.Millisecond,
+					},
+					model.BootTimeStageKernel: {
+						model.RetrievalMethodSystemdAnalyze: 718 * time.Millisecond,
+					},
+				}, res.Record.Values)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":{"acpi_fpdt":"1.897s","systemd_analyze":"1.9s"},"loader":{"acpi_fpdt":"1.715s"},"kernel":{"systemd_analyze":"718ms"}}`, string(data))
+			},
+		},
+		"only the selected methods are run": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1897 * time.Millisecond,
+					},
+				},
+				fakeCollector{
+					method: model.RetrievalMethodEFIVar,
+					err:    errors.New("efi variables not found"),
+				},
+			},
+			opts: []Option{WithMethods([]model.RetrievalMethod{model.RetrievalMethodACPIFPDT})},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Empty(t, res.Warnings)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":{"acpi_fpdt":"1.897s"}}`, string(data))
+			},
+		},
+		"only the selected stages are written": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdAnalyze,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1900 * time.Millisecond,
+						model.BootTimeStageLoader:   267 * time.Millisecond,
+						model.BootTimeStageKernel:   718 * time.Millisecond,
+					},
+				},
+			},
+			opts: []Option{WithStages([]model.BootTimeStage{model.BootTimeStageFirmware, model.BootTimeStageLoader})},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":{"systemd_analyze":"1.9s"},"loader":{"systemd_analyze":"267ms"}}`, string(data))
+			},
+		},
+		"record without the selected stages returns error": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdAnalyze,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: 718 * time.Millisecond,
+					},
+				},
+			},
+			opts: []Option{WithStages([]model.BootTimeStage{model.BootTimeStageFirmware})},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.ErrorIs(t, err, ErrNoData)
+				require.Nil(t, res)
+				assert.NoFileExists(t, fileName)
+			},
+		},
+		"collapsed record is written flat": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1800 * time.Millisecond,
+					},
+				},
+				fakeCollector{
+					method: model.RetrievalMethodSystemdAnalyze,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 2 * time.Second,
+						model.BootTimeStageKernel:   718 * time.Millisecond,
+					},
+				},
+			},
+			opts: []Option{WithCollapse(model.CollapseMean)},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":"1.9s","kernel":"718ms"}`, string(data))
+			},
+		},
+		"metadata is written with the record": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: 718 * time.Millisecond,
+					},
+				},
+			},
+			opts: []Option{func(o *options) {
+				o.metadata = func() (*model.Metadata, []Warning) {
+					return &model.Metadata{KernelCmdline: "quiet"}, []Warning{{Err: errors.New("partial metadata")}}
+				}
+			}},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Len(t, res.Warnings, 1)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"},"metadata":{"kernel_cmdline":"quiet"}}`, string(data))
+			},
+		},
+		"raw timestamps are written with the record": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: 718 * time.Millisecond,
+					},
+				},
+			},
+			opts: []Option{WithRaw(true), func(o *options) {
+				o.rawSystemdDbus = func(context.Context) (map[string]time.Duration, error) {
+					return map[string]time.Duration{"finish": 4 * time.Second}, nil
+				}
+			}},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				assert.Empty(t, res.Warnings)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"},"raw":{"systemd_dbus":{"finish":"4s"}}}`, string(data))
+			},
+		},
+		"failing raw timestamps are left out with a warning": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: 718 * time.Millisecond,
+					},
+				},
+			},
+			opts: []Option{WithRaw(true), func(o *options) {
+				o.rawSystemdDbus = func(context.Context) (map[string]time.Duration, error) {
+					return nil, errors.New("dbus unavailable")
+				}
+			}},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				require.Len(t, res.Warnings, 1)
+				assert.Equal(t, model.RetrievalMethodSystemdDBUS, res.Warnings[0].Method)
+				assert.ErrorContains(t, res.Warnings[0].Err, "dbus unavailable")
+				assert.Nil(t, res.Record.Raw)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"}}`, string(data))
+			},
+		},
+		"user analyze scope is written in metadata": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdAnalyze,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageUserspace: 2 * time.Second,
+					},
+				},
+			},
+			opts: []Option{WithAnalyzeScope(systemd.AnalyzeScopeUser)},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"userspace":{"systemd_analyze":"2s"},"metadata":{"analyze_scope":"user"}}`, string(data))
+			},
+		},
+		"record without data returns error": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 0,
+						model.BootTimeStageLoader:   0,
+					},
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.ErrorIs(t, err, ErrNoData)
+				require.Nil(t, res)
+				assert.NoFileExists(t, fileName)
+			},
+		},
+		"record without data is written when allowed": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 0,
+					},
+				},
+			},
+			opts: []Option{WithAllowEmpty(true)},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":{"systemd_dbus":"0s"}}`, string(data))
+			},
+		},
+		"stale collector values are dropped": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1897 * time.Millisecond,
+					},
+				},
+				fakeCollector{
+					method: model.RetrievalMethodEFIVar,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 3 * time.Second,
+						model.BootTimeStageLoader:   time.Second,
+					},
+					err: ErrStaleSource,
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":{"acpi_fpdt":"1.897s"}}`, string(data))
+
+				require.Len(t, res.Warnings, 1)
+				assert.Equal(t, model.RetrievalMethodEFIVar, res.Warnings[0].Method)
+				assert.ErrorIs(t, res.Warnings[0].Err, ErrStaleSource)
+			},
+		},
+		"stale custom collector values are dropped": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 1897 * time.Millisecond,
+					},
+				},
+				fakeCollector{
+					method: "custom",
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: time.Second,
+					},
+					err: ErrStaleSource,
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"firmware":{"acpi_fpdt":"1.897s"}}`, string(data))
+
+				require.Len(t, res.Warnings, 1)
+				assert.ErrorIs(t, res.Warnings[0].Err, ErrStaleSource)
+			},
+		},
+		"firmware longer than total is a warning": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageFirmware: 25 * time.Second,
+						model.BootTimeStageTotal:    10 * time.Second,
+					},
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				require.Len(t, res.Warnings, 1)
+				assert.Equal(t, model.RetrievalMethodSystemdDBUS, res.Warnings[0].Method)
+				assert.ErrorContains(t, res.Warnings[0].Err, "suspect firmware duration")
+			},
+		},
+		"unsupported collector is skipped": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: 718 * time.Millisecond,
+					},
+				},
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					err:    fmt.Errorf("acpi: %w", errors.ErrUnsupported),
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"}}`, string(data))
+			},
+		},
+		"collector failure does not prevent the others": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{
+						model.BootTimeStageKernel: 718 * time.Millisecond,
+					},
+				},
+				fakeCollector{method: model.RetrievalMethodEFIVar, err: errors.New("no efi vars")},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+
+				data, err := os.ReadFile(fileName)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"}}`, string(data))
+
+				require.Len(t, res.Warnings, 1)
+				assert.Equal(t, model.RetrievalMethodEFIVar, res.Warnings[0].Method)
+				assert.ErrorContains(t, res.Warnings[0].Err, "no efi vars")
+			},
+		},
+		"every collector failing returns every error": {
+			collectors: []Collector{
+				fakeCollector{method: model.RetrievalMethodSystemdDBUS, err: errors.New("no system bus")},
+				fakeCollector{method: model.RetrievalMethodEFIVar, err: errors.New("no efi vars")},
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					err:    fmt.Errorf("acpi: %w", errors.ErrUnsupported),
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.ErrorContains(t, err, "no system bus")
+				require.ErrorContains(t, err, "no efi vars")
+				require.Nil(t, res)
+				assert.NoFileExists(t, fileName)
+			},
+		},
+		"extra collectors run next to the others": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{model.BootTimeStageFirmware: 1897 * time.Millisecond},
+				},
+			},
+			opts: []Option{WithExtraCollectors(fakeCollector{
+				method: "custom_probe",
+				stages: map[model.BootTimeStage]time.Duration{model.BootTimeStageFirmware: 1890 * time.Millisecond},
+			})},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				assert.Equal(t, map[model.RetrievalMethod]time.Duration{
+					model.RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+					"custom_probe":                1890 * time.Millisecond,
+				}, res.Record.Values[model.BootTimeStageFirmware])
+			},
+		},
+		"context collectors give up on timeout": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{model.BootTimeStageFirmware: 1897 * time.Millisecond},
+				},
+				contextCollector{fakeCollector{
+					method: model.RetrievalMethodSystemdDBUS,
+					stages: map[model.BootTimeStage]time.Duration{model.BootTimeStageFirmware: 1900 * time.Millisecond},
+				}},
+			},
+			opts: []Option{WithTimeout(10 * time.Millisecond)},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				assert.Equal(t, map[model.RetrievalMethod]time.Duration{
+					model.RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+				}, res.Record.Values[model.BootTimeStageFirmware])
+				require.Len(t, res.Warnings, 1)
+				assert.ErrorIs(t, res.Warnings[0].Err, context.DeadlineExceeded)
+			},
+		},
+		"suspect values are kept with a warning": {
+			collectors: []Collector{
+				fakeCollector{
+					method: model.RetrievalMethodACPIFPDT,
+					stages: map[model.BootTimeStage]time.Duration{model.BootTimeStageFirmware: 4 * time.Microsecond},
+					err:    fmt.Errorf("%w: firmware too short", ErrSuspectValues),
+				},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.NoError(t, err)
+				assert.Equal(t, 4*time.Microsecond, res.Record.Values[model.BootTimeStageFirmware][model.RetrievalMethodACPIFPDT])
+				require.Len(t, res.Warnings, 1)
+				assert.Equal(t, model.RetrievalMethodACPIFPDT, res.Warnings[0].Method)
+				assert.ErrorIs(t, res.Warnings[0].Err, ErrSuspectValues)
+			},
+		},
+		"collector failure returns error": {
+			collectors: []Collector{
+				fakeCollector{method: model.RetrievalMethodEFIVar, err: errors.New("no efi vars")},
+			},
+			validate: func(t *testing.T, res *Retrieval, err error, fileName string) {
+				require.Error(t, err)
+				require.Nil(t, res)
+				assert.NoFileExists(t, fileName)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fileName := filepath.Join(t.TempDir(), "results.jsonl")
+			opts := append([]Option{WithCollectors(tc.collectors), withoutHostMetadata}, tc.opts...)
+			res, err := RetrieveBootTimes(fileName, opts...)
+			tc.validate(t, res, err, fileName)
+		})
+	}
+}
+
+func TestRetrieveBootTimesSampleRate(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "results.jsonl")
+	collectors := []Collector{
+		fakeCollector{
+			method: model.RetrievalMethodSystemdDBUS,
+			stages: map[model.BootTimeStage]time.Duration{
+				model.BootTimeStageKernel: 718 * time.Millisecond,
+			},
+		},
+	}
+
+	var skipped []bool
+	for range 7 {
+		res, err := RetrieveBootTimes(fileName, WithCollectors(collectors), withoutHostMetadata, WithSampleRate(3))
+		require.NoError(t, err)
+		require.NotNil(t, res.Record)
+		skipped = append(skipped, res.Skipped)
+		if res.Skipped {
+			require.Len(t, res.Warnings, 1)
+			assert.ErrorContains(t, res.Warnings[0].Err, "record not written to "+fileName+", 1 in 3 is")
+		} else {
+			assert.Empty(t, res.Warnings)
+		}
+	}
+	assert.Equal(t, []bool{false, true, true, false, true, true, false}, skipped)
+
+	file, err := os.Open(fileName)
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := model.BootTimeRecordsFromFile(file)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	state, err := os.ReadFile(fileName + sampleStateSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", string(state))
+}
+
+func TestRetrieveBootTimesRuns(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "results.jsonl")
+	collectors := []Collector{
+		fakeCollector{
+			method: model.RetrievalMethodSystemdDBUS,
+			stages: map[model.BootTimeStage]time.Duration{
+				model.BootTimeStageKernel: 718 * time.Millisecond,
+			},
+		},
+	}
+
+	var left []int
+	for range 3 {
+		res, err := RetrieveBootTimes(fileName, WithCollectors(collectors), withoutHostMetadata, WithRuns(3))
+		require.NoError(t, err)
+		left = append(left, res.RunsLeft)
+	}
+	assert.Equal(t, []int{2, 1, 0}, left)
+
+	res, err := RetrieveBootTimes(fileName, WithCollectors(collectors), withoutHostMetadata, WithRuns(3))
+	require.ErrorIs(t, err, ErrRunsComplete)
+	assert.Nil(t, res)
+
+	file, err := os.Open(fileName)
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := model.BootTimeRecordsFromFile(file)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	require.NoError(t, os.Remove(fileName+runsStateSuffix))
+	res, err = RetrieveBootTimes(fileName, WithCollectors(collectors), withoutHostMetadata, WithRuns(3))
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.RunsLeft)
+}
+
+func TestDefaultCollectorsGiveUpWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tcs := map[string]struct {
+		collect func() (map[model.BootTimeStage]time.Duration, error)
+	}{
+		"acpi fpdt": {collect: func() (map[model.BootTimeStage]time.Duration, error) {
+			return collectACPIFPDT(ctx, true, acpi.FirmwareBounds{})
+		}},
+		"efi vars": {collect: func() (map[model.BootTimeStage]time.Duration, error) { return collectEFIVars(ctx) }},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			stages, err := tc.collect()
+			require.ErrorIs(t, err, context.Canceled)
+			assert.Nil(t, stages)
+		})
+	}
+}
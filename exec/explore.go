@@ -0,0 +1,209 @@
+package exec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/boreec/boottime/analysis"
+	"github.com/boreec/boottime/model"
+)
+
+const exploreHelp string = `commands:
+  avg             print the average of every stage and method
+  median          print the median of every stage and method
+  p99 <stage>     print the 99th percentile of every method for the stage
+  filter last <n> only keep the last n records
+  filter since <duration>
+                  only keep the records collected within the duration, such as 24h
+  group-by kernel print the average of every stage and method per kernel version
+  reset           keep every record again
+  count           print the number of records kept
+  help            print this help
+  quit            leave the explorer
+`
+
+// explorer is an interactive session over the records of a jsonl file.
+type explorer struct {
+	all     []*model.BootTimeRecord
+	records []*model.BootTimeRecord
+	out     io.Writer
+	// now returns the current time, from which filter since counts.
+	now func() time.Time
+}
+
+// Explore loads the records of the jsonl file and runs the commands read from
+// in, one per line, printing their results to out, until in is exhausted or
+// the quit command. The warnings about the file are printed to out first.
+func Explore(fileName string, in io.Reader, out io.Writer) error {
+	records, warnings, err := readRecords(fileName)
+	if err != nil {
+		return err
+	}
+	PrintWarnings(out, warnings)
+
+	e := &explorer{all: records, records: records, out: out, now: time.Now}
+	fmt.Fprintf(out, "%d records loaded, type help for the commands\n", len(records))
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+
+		if err := e.run(fields[0], fields[1:]); err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+func (e *explorer) run(name string, args []string) error {
+	switch name {
+	case "avg":
+		return e.printTable(e.reduce(func(s model.Stats) time.Duration { return s.Mean }))
+	case "median":
+		return e.printTable(e.reduce(func(s model.Stats) time.Duration { return s.Median }))
+	case "p99":
+		if len(args) != 1 {
+			return fmt.Errorf("expected 1 arg for stage, found %d", len(args))
+		}
+		stage, err := model.ParseBootTimeStage(args[0])
+		if err != nil {
+			return err
+		}
+		return e.printP99(stage)
+	case "filter":
+		return e.filter(args)
+	case "group-by":
+		if len(args) != 1 || args[0] != "kernel" {
+			return errors.New("expected group-by kernel")
+		}
+		return e.printGroups(analysis.MetadataFieldKernelVersion)
+	case "reset":
+		e.records = e.all
+		fmt.Fprintf(e.out, "%d records kept\n", len(e.records))
+		return nil
+	case "count":
+		fmt.Fprintf(e.out, "%d records kept\n", len(e.records))
+		return nil
+	case "help":
+		fmt.Fprint(e.out, exploreHelp)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, type help for the commands", name)
+	}
+}
+
+func (e *explorer) summary() *model.StatsSummary {
+	btra := model.NewBootTimeAccumulator(model.WithRetainedSamples())
+	for _, r := range e.records {
+		btra.Add(r)
+	}
+	return btra.Summary()
+}
+
+// reduce returns a record with the statistic picked by fn for every cell.
+func (e *explorer) reduce(fn func(s model.Stats) time.Duration) *model.BootTimeRecord {
+	btr := &model.BootTimeRecord{
+		Values: make(map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration),
+	}
+	for stage, methods := range e.summary().Stages {
+		btr.Values[stage] = make(map[model.RetrievalMethod]time.Duration)
+		for method, stats := range methods {
+			btr.Values[stage][method] = fn(stats)
+		}
+	}
+	return btr
+}
+
+func (e *explorer) printTable(btr *model.BootTimeRecord) error {
+	w := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+	for _, row := range btr.ToTable() {
+		for _, cell := range row {
+			fmt.Fprint(w, cell, "\t")
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+// printGroups prints the average table of the records kept for every value of
+// the metadata field, in lexical order.
+func (e *explorer) printGroups(field analysis.MetadataField) error {
+	groups := analysis.GroupByMetadata(e.records, field)
+	for i, group := range slices.Sorted(maps.Keys(groups)) {
+		if i > 0 {
+			fmt.Fprintln(e.out)
+		}
+		btra := model.NewBootTimeAccumulator()
+		for _, r := range groups[group] {
+			btra.Add(r)
+		}
+		fmt.Fprintf(e.out, "%s=%s: boot time average for %d records.\n", field, group, len(groups[group]))
+		if err := e.printTable(btra.Average()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *explorer) printP99(stage model.BootTimeStage) error {
+	methods := e.summary().Stages[stage]
+
+	w := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Method\tp99\t")
+	for _, method := range (model.Selection{}).Methods() {
+		if stats, ok := methods[method]; ok {
+			fmt.Fprintf(w, "%s\t%s\t\n", method, stats.P99)
+		}
+	}
+	return w.Flush()
+}
+
+func (e *explorer) filter(args []string) error {
+	if len(args) != 2 {
+		return errors.New("expected filter last <n> or filter since <duration>")
+	}
+
+	switch args[0] {
+	case "last":
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid number of records %q", args[1])
+		}
+		e.records = e.records[max(0, len(e.records)-n):]
+	case "since":
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid duration %q", args[1])
+		}
+		// Records without timestamp cannot be placed in time, so they are
+		// left out.
+		from := e.now().Add(-d)
+		e.records = slices.DeleteFunc(slices.Clone(e.records), func(r *model.BootTimeRecord) bool {
+			return r.Metadata == nil || r.Metadata.Timestamp.IsZero() || r.Metadata.Timestamp.Before(from)
+		})
+	default:
+		return errors.New("expected filter last <n> or filter since <duration>")
+	}
+
+	fmt.Fprintf(e.out, "%d records kept\n", len(e.records))
+	return nil
+}
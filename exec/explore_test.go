@@ -0,0 +1,88 @@
+package exec
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplore(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "records.jsonl")
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	require.NoError(t, os.WriteFile(fileName, []byte(`{"kernel":{"systemd_analyze":"1s"},"metadata":{"kernel_version":"6.8.0","timestamp":"2020-01-01T00:00:00Z"}}
+{"kernel":{"systemd_analyze":"2s"},"metadata":{"kernel_version":"6.9.1","timestamp":"`+recent+`"}}
+{"kernel":{"systemd_analyze":"6s"},"metadata":{"kernel_version":"6.9.1","timestamp":"`+recent+`"}}
+`), 0o644))
+
+	tcs := map[string]struct {
+		commands string
+		validate func(t *testing.T, out string, err error)
+	}{
+		"average": {
+			commands: "avg\n",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, "3 records loaded")
+				assert.Regexp(t, `kernel\s+3s`, out)
+			},
+		},
+		"median of the last records": {
+			commands: "filter last 2\nmedian\n",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, "2 records kept")
+				assert.Regexp(t, `kernel\s+4s`, out)
+			},
+		},
+		"p99 of a stage": {
+			commands: "p99 kernel\nquit\navg\n",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `systemd_analyze\s+5.92s`, out)
+				assert.NotContains(t, out, "Stage")
+			},
+		},
+		"average of the recent records": {
+			commands: "filter since 24h\navg\n",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, "2 records kept")
+				assert.Regexp(t, `kernel\s+4s`, out)
+			},
+		},
+		"average per kernel": {
+			commands: "group-by kernel\n",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Regexp(t, `kernel_version=6.8.0: boot time average for 1 records.\n(.*\n)*kernel\s+1s`, out)
+				assert.Regexp(t, `kernel_version=6.9.1: boot time average for 2 records.\n(.*\n)*kernel\s+4s`, out)
+			},
+		},
+		"invalid commands do not stop the session": {
+			commands: "p99 boot\nfoo\nfilter since 1d\ngroup-by host\ncount\n",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, `invalid duration "1d"`)
+				assert.Contains(t, out, "expected group-by kernel")
+				assert.Contains(t, out, `unknown boot time stage "boot"`)
+				assert.Contains(t, out, `unknown command "foo"`)
+				assert.Contains(t, out, "3 records kept")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			err := Explore(fileName, strings.NewReader(tc.commands), &out)
+			tc.validate(t, out.String(), err)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+// ErrNotLegacyRecord is returned by ConvertLegacyRecord for a line which is not
+// a legacy bootprobe record.
+var ErrNotLegacyRecord = errors.New("not a legacy bootprobe record")
+
+// legacyStages maps the fields of the records written by bootprobe, the
+// systemd.BootTimeRecord encoded without JSON tags, to their stage.
+var legacyStages = map[string]model.BootTimeStage{
+	"Firmware":  model.BootTimeStageFirmware,
+	"Loader":    model.BootTimeStageLoader,
+	"Kernel":    model.BootTimeStageKernel,
+	"Initrd":    model.BootTimeStageInitrd,
+	"Userspace": model.BootTimeStageUserspace,
+	"Total":     model.BootTimeStageTotal,
+}
+
+// ConvertLegacyRecord returns the record of a line written by bootprobe, whose
+// flat fields, such as {"Firmware":1897000000}, are the durations measured by
+// systemd-analyze. A line is recognized as legacy when all of its fields are
+// bootprobe field names, otherwise ErrNotLegacyRecord is returned.
+func ConvertLegacyRecord(line []byte) (*model.BootTimeRecord, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, fmt.Errorf("decoding record: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrNotLegacyRecord
+	}
+	for name := range fields {
+		if _, ok := legacyStages[name]; !ok {
+			return nil, ErrNotLegacyRecord
+		}
+	}
+
+	record := &model.BootTimeRecord{
+		Values: make(map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration, len(fields)),
+	}
+	for name, value := range fields {
+		var d model.Duration
+		if err := json.Unmarshal(value, &d); err != nil {
+			return nil, fmt.Errorf("decoding field %s: %w", name, err)
+		}
+		record.Values[legacyStages[name]] = map[model.RetrievalMethod]time.Duration{
+			model.RetrievalMethodSystemdAnalyze: time.Duration(d),
+		}
+	}
+
+	return record, nil
+}
+
+// Import is the outcome of importing a jsonl file written by bootprobe.
+type Import struct {
+	// Converted is the number of legacy records converted.
+	Converted int
+	// Copied is the number of records already in the current format.
+	Copied int
+}
+
+// ImportLegacyFile writes the records of the jsonl file in, written by
+// bootprobe, to the jsonl file out, converting them with ConvertLegacyRecord.
+// Records already in the current format are copied, so that a file appended to
+// by both tools can be imported. out is overwritten.
+func ImportLegacyFile(in, out string) (*Import, error) {
+	inFile, err := os.Open(in)
+	if err != nil {
+		return nil, fmt.Errorf("opening file %s: %w", in, err)
+	}
+	defer inFile.Close()
+
+	outFile, err := os.Create(out)
+	if err != nil {
+		return nil, fmt.Errorf("creating file %s: %w", out, err)
+	}
+	defer outFile.Close()
+
+	var imp Import
+	r := bufio.NewReader(inFile)
+	w := bufio.NewWriter(outFile)
+	enc := json.NewEncoder(w)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading file %s: %w", in, err)
+		}
+		eof := err != nil
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			record, err := ConvertLegacyRecord(line)
+			switch {
+			case err == nil:
+				imp.Converted++
+			case errors.Is(err, ErrNotLegacyRecord):
+				record = &model.BootTimeRecord{}
+				if err := model.UnmarshalBootTimeRecord(line, record); err != nil {
+					return nil, fmt.Errorf("line %d of %s: %w", n, in, err)
+				}
+				imp.Copied++
+			default:
+				return nil, fmt.Errorf("line %d of %s: %w", n, in, err)
+			}
+
+			if err := enc.Encode(record); err != nil {
+				return nil, fmt.Errorf("encoding record to jsonl file: %w", err)
+			}
+		}
+
+		if eof {
+			break
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("writing file %s: %w", out, err)
+	}
+
+	if err := outFile.Close(); err != nil {
+		return nil, fmt.Errorf("closing file %s: %w", out, err)
+	}
+
+	return &imp, nil
+}
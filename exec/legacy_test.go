@@ -0,0 +1,93 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertLegacyRecord(t *testing.T) {
+	tcs := map[string]struct {
+		line     string
+		validate func(t *testing.T, r *model.BootTimeRecord, err error)
+	}{
+		"nanoseconds are systemd_analyze durations": {
+			line: `{"Firmware":1897000000,"Loader":267000000,"Kernel":641000000,"Initrd":0,"Userspace":1787000000,"Total":4592000000}`,
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Len(t, r.Values, 6)
+				assert.Equal(t, 1897*time.Millisecond, r.Values[model.BootTimeStageFirmware][model.RetrievalMethodSystemdAnalyze])
+				assert.Equal(t, time.Duration(0), r.Values[model.BootTimeStageInitrd][model.RetrievalMethodSystemdAnalyze])
+				assert.Equal(t, 4592*time.Millisecond, r.Values[model.BootTimeStageTotal][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"missing fields are left out": {
+			line: `{"Kernel":"641ms"}`,
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageKernel: {model.RetrievalMethodSystemdAnalyze: 641 * time.Millisecond},
+				}, r.Values)
+			},
+		},
+		"flat record is not legacy": {
+			line: `{"firmware":"1.9s"}`,
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrNotLegacyRecord)
+			},
+		},
+		"nested record is not legacy": {
+			line: `{"kernel":{"systemd_analyze":"641ms"}}`,
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrNotLegacyRecord)
+			},
+		},
+		"empty record is not legacy": {
+			line: `{}`,
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrNotLegacyRecord)
+			},
+		},
+		"invalid duration returns error": {
+			line: `{"Kernel":"potatoes"}`,
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "decoding field Kernel")
+				assert.Nil(t, r)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			r, err := ConvertLegacyRecord([]byte(tc.line))
+			tc.validate(t, r, err)
+		})
+	}
+}
+
+func TestImportLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.jsonl")
+	out := filepath.Join(dir, "out.jsonl")
+	require.NoError(t, os.WriteFile(in, []byte(`{"Firmware":1897000000,"Total":4592000000}
+
+{"kernel":{"systemd_dbus":"1s"}}
+{"Kernel":641000000}`), 0o644))
+
+	imp, err := ImportLegacyFile(in, out)
+	require.NoError(t, err)
+	assert.Equal(t, &Import{Converted: 2, Copied: 1}, imp)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"firmware":{"systemd_analyze":"1.897s"},"total":{"systemd_analyze":"4.592s"}}
+{"kernel":{"systemd_dbus":"1s"}}
+{"kernel":{"systemd_analyze":"641ms"}}
+`, string(data))
+}
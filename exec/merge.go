@@ -0,0 +1,112 @@
+package exec
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/boreec/boottime/model"
+)
+
+// Merge is the outcome of merging jsonl files.
+type Merge struct {
+	// Written is the number of records written to the output file.
+	Written int
+	// Duplicates is the number of records left out as duplicates.
+	Duplicates int
+	// Warnings are the problems which did not prevent the merge.
+	Warnings []Warning
+}
+
+// MergeFiles concatenates the records of the input jsonl files into the out
+// file, streaming them so that memory stays bounded by the number of distinct
+// records rather than their size. With dedup, a record identical to an earlier
+// one, in any input, is left out. Records are compared once re-encoded, so
+// that the key order or spacing of their lines does not matter.
+//
+// A truncated last record of an input is ignored with a warning.
+func MergeFiles(out string, dedup bool, inputs ...string) (*Merge, error) {
+	outPath, err := filepath.Abs(out)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path %s: %w", out, err)
+	}
+	for _, in := range inputs {
+		inPath, err := filepath.Abs(in)
+		if err != nil {
+			return nil, fmt.Errorf("resolving path %s: %w", in, err)
+		}
+		if inPath == outPath {
+			return nil, fmt.Errorf("output file %s is also an input", out)
+		}
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, fmt.Errorf("creating file %s: %w", out, err)
+	}
+	defer file.Close()
+
+	var merge Merge
+	seen := make(map[[sha256.Size]byte]struct{})
+	w := bufio.NewWriter(file)
+
+	for _, in := range inputs {
+		warnings, err := mergeFile(in, func(line []byte) error {
+			if dedup {
+				sum := sha256.Sum256(line)
+				if _, ok := seen[sum]; ok {
+					merge.Duplicates++
+					return nil
+				}
+				seen[sum] = struct{}{}
+			}
+
+			if _, err := w.Write(line); err != nil {
+				return fmt.Errorf("writing file %s: %w", out, err)
+			}
+			merge.Written++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		merge.Warnings = append(merge.Warnings, warnings...)
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("writing file %s: %w", out, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("closing file %s: %w", out, err)
+	}
+
+	return &merge, nil
+}
+
+// mergeFile calls fn with the re-encoded line of every record of the jsonl
+// file.
+func mergeFile(fileName string, fn func(line []byte) error) ([]Warning, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("opening file %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	err = model.ForEachBootTimeRecord(file, func(r *model.BootTimeRecord) error {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("encoding record of %s: %w", fileName, err)
+		}
+		return fn(append(line, '\n'))
+	})
+	warnings, err := truncatedTailWarning(err, fileName, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading boot time records from %s: %w", fileName, err)
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,105 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeFiles(t *testing.T) {
+	tcs := map[string]struct {
+		inputs   []string
+		dedup    bool
+		validate func(t *testing.T, merge *Merge, err error, out string)
+	}{
+		"records are concatenated in order": {
+			inputs: []string{
+				`{"kernel":{"systemd_dbus":"1s"}}` + "\n" + `{"kernel":{"systemd_dbus":"2s"}}` + "\n",
+				`{"kernel":{"systemd_dbus":"1s"}}` + "\n",
+			},
+			validate: func(t *testing.T, merge *Merge, err error, out string) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, merge.Written)
+				assert.Zero(t, merge.Duplicates)
+				assert.Len(t, readMergedRecords(t, out), 3)
+			},
+		},
+		"identical records are deduplicated across files": {
+			inputs: []string{
+				`{"kernel":{"systemd_dbus":"1s","systemd_analyze":"1s"}}` + "\n" + `{"kernel":{"systemd_dbus":"2s"}}` + "\n",
+				`{"kernel":{"systemd_analyze":"1s", "systemd_dbus":"1s"}}` + "\n",
+			},
+			dedup: true,
+			validate: func(t *testing.T, merge *Merge, err error, out string) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, merge.Written)
+				assert.Equal(t, 1, merge.Duplicates)
+				assert.Len(t, readMergedRecords(t, out), 2)
+			},
+		},
+		"truncated last record is ignored with a warning": {
+			inputs: []string{
+				`{"kernel":{"systemd_dbus":"1s"}}` + "\n" + `{"kernel":{"syst`,
+				`{"kernel":{"systemd_dbus":"2s"}}` + "\n",
+			},
+			validate: func(t *testing.T, merge *Merge, err error, out string) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, merge.Written)
+				assert.Len(t, merge.Warnings, 1)
+			},
+		},
+		"invalid record fails": {
+			inputs: []string{"{\n" + `{"kernel":{"systemd_dbus":"1s"}}` + "\n"},
+			validate: func(t *testing.T, merge *Merge, err error, out string) {
+				require.Error(t, err)
+				assert.Nil(t, merge)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+
+			var inputs []string
+			for i, content := range tc.inputs {
+				in := filepath.Join(dir, "in"+string(rune('a'+i))+".jsonl")
+				require.NoError(t, os.WriteFile(in, []byte(content), 0o644))
+				inputs = append(inputs, in)
+			}
+
+			out := filepath.Join(dir, "out.jsonl")
+			merge, err := MergeFiles(out, tc.dedup, inputs...)
+			tc.validate(t, merge, err, out)
+		})
+	}
+}
+
+func TestMergeFilesRejectsOutputAsInput(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "records.jsonl")
+	require.NoError(t, os.WriteFile(in, []byte(`{"kernel":{"systemd_dbus":"1s"}}`+"\n"), 0o644))
+
+	_, err := MergeFiles(in, false, in)
+	require.Error(t, err)
+
+	content, err := os.ReadFile(in)
+	require.NoError(t, err)
+	assert.NotEmpty(t, content)
+}
+
+func readMergedRecords(t *testing.T, fileName string) []*model.BootTimeRecord {
+	t.Helper()
+
+	file, err := os.Open(fileName)
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, err := model.BootTimeRecordsFromFile(file)
+	require.NoError(t, err)
+	return records
+}
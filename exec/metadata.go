@@ -0,0 +1,73 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+const (
+	pathProcCmdline string = "/proc/cmdline"
+	pathOSRelease   string = "/proc/sys/kernel/osrelease"
+	pathMachineID   string = "/etc/machine-id"
+	pathBIOSVersion string = "/sys/class/dmi/id/bios_version"
+	pathBIOSDate    string = "/sys/class/dmi/id/bios_date"
+)
+
+// collectMetadata returns the metadata of the running boot. Metadata which
+// cannot be read is left empty, with a warning, since the boot times are still
+// worth recording.
+func collectMetadata() (*model.Metadata, []Warning) {
+	metadata := model.Metadata{Timestamp: time.Now().UTC().Truncate(time.Second)}
+	var warnings []Warning
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		warnings = append(warnings, Warning{Err: fmt.Errorf("reading hostname: %w", err)})
+	} else {
+		metadata.Hostname = hostname
+	}
+
+	osRelease, err := os.ReadFile(pathOSRelease)
+	if err != nil {
+		warnings = append(warnings, Warning{Err: fmt.Errorf("reading kernel version: %w", err)})
+	} else {
+		metadata.KernelVersion = strings.TrimSpace(string(osRelease))
+	}
+
+	cmdline, err := os.ReadFile(pathProcCmdline)
+	if err != nil {
+		warnings = append(warnings, Warning{Err: fmt.Errorf("reading kernel command line: %w", err)})
+	} else {
+		metadata.KernelCmdline = strings.TrimSpace(string(cmdline))
+	}
+
+	machineID, err := os.ReadFile(pathMachineID)
+	if err != nil {
+		warnings = append(warnings, Warning{Err: fmt.Errorf("reading machine id: %w", err)})
+	} else {
+		metadata.MachineID = strings.TrimSpace(string(machineID))
+	}
+
+	// Hosts without DMI, such as containers or ARM boards, have no BIOS.
+	for path, dest := range map[string]*string{
+		pathBIOSVersion: &metadata.BIOSVersion,
+		pathBIOSDate:    &metadata.BIOSDate,
+	} {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			warnings = append(warnings, Warning{Err: fmt.Errorf("reading %s: %w", path, err)})
+		default:
+			*dest = strings.TrimSpace(string(data))
+		}
+	}
+
+	return &metadata, warnings
+}
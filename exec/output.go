@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// outputDateLayout is the layout of the date in the names of the files of
+// OutputFileName.
+const outputDateLayout = "2006-01-02"
+
+// OutputFileName returns the jsonl file "<dir>/<hostname>-<date>.jsonl" of the
+// host for today, so that a fleet can collect into a shared directory without
+// computing the path. The directory is left untouched, see CreateOutputDir.
+func OutputFileName(dir string) (string, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("determining hostname: %w", err)
+	}
+
+	return outputFileName(dir, host, time.Now())
+}
+
+func outputFileName(dir, host string, now time.Time) (string, error) {
+	if host == "" || strings.ContainsRune(host, filepath.Separator) {
+		return "", fmt.Errorf("hostname %q cannot name a file", host)
+	}
+
+	return filepath.Join(dir, host+"-"+now.Format(outputDateLayout)+".jsonl"), nil
+}
+
+// CreateOutputDir creates the directory of OutputFileName if needed, and
+// checks that it is writable.
+func CreateOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".boottime-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %w", dir, err)
+	}
+	if err := errors.Join(probe.Close(), os.Remove(probe.Name())); err != nil {
+		return fmt.Errorf("removing %s: %w", probe.Name(), err)
+	}
+
+	return nil
+}
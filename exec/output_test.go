@@ -0,0 +1,93 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputFileName(t *testing.T) {
+	now := time.Date(2026, time.March, 4, 23, 59, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		dir      func(t *testing.T) string
+		host     string
+		validate func(t *testing.T, dir, fileName string, err error)
+	}{
+		"named after host and date": {
+			dir:  func(t *testing.T) string { return t.TempDir() },
+			host: "node-1",
+			validate: func(t *testing.T, dir, fileName string, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, filepath.Join(dir, "node-1-2026-03-04.jsonl"), fileName)
+				entries, err := os.ReadDir(dir)
+				require.NoError(t, err)
+				assert.Empty(t, entries)
+			},
+		},
+		"missing directory is not created": {
+			dir:  func(t *testing.T) string { return filepath.Join(t.TempDir(), "var", "log", "boottime") },
+			host: "node-1",
+			validate: func(t *testing.T, dir, fileName string, err error) {
+				require.NoError(t, err)
+				assert.NoDirExists(t, dir)
+				assert.Equal(t, filepath.Join(dir, "node-1-2026-03-04.jsonl"), fileName)
+			},
+		},
+		"empty hostname returns error": {
+			dir: func(t *testing.T) string { return t.TempDir() },
+			validate: func(t *testing.T, dir, fileName string, err error) {
+				require.ErrorContains(t, err, "cannot name a file")
+				assert.Empty(t, fileName)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := tc.dir(t)
+			fileName, err := outputFileName(dir, tc.host, now)
+			tc.validate(t, dir, fileName, err)
+		})
+	}
+}
+
+func TestCreateOutputDir(t *testing.T) {
+	tcs := map[string]struct {
+		dir      func(t *testing.T) string
+		validate func(t *testing.T, dir string, err error)
+	}{
+		"missing directory is created": {
+			dir: func(t *testing.T) string { return filepath.Join(t.TempDir(), "var", "log", "boottime") },
+			validate: func(t *testing.T, dir string, err error) {
+				require.NoError(t, err)
+				entries, err := os.ReadDir(dir)
+				require.NoError(t, err)
+				assert.Empty(t, entries)
+			},
+		},
+		"directory is a file returns error": {
+			dir: func(t *testing.T) string {
+				fileName := filepath.Join(t.TempDir(), "file")
+				require.NoError(t, os.WriteFile(fileName, nil, 0o644))
+				return fileName
+			},
+			validate: func(t *testing.T, dir string, err error) {
+				require.ErrorContains(t, err, "creating output directory")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			dir := tc.dir(t)
+			tc.validate(t, dir, CreateOutputDir(dir))
+		})
+	}
+}
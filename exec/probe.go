@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/platform"
+)
+
+// CheckMethods runs every default collector, without writing any record, and
+// returns the error of each method, nil if it can retrieve boot times on the
+// host.
+func CheckMethods(ctx context.Context) map[model.RetrievalMethod]error {
+	collectors := defaultCollectors(ctx, &options{})
+	errs := make(map[model.RetrievalMethod]error, len(collectors))
+	for _, c := range collectors {
+		_, errs[c.Method()] = c.Collect()
+	}
+
+	return errs
+}
+
+// Probe writes the platform of the host and whether each default collector
+// can retrieve boot times on it, as checked by CheckMethods. It helps to
+// understand why a method is missing from the records.
+func Probe(w io.Writer) error {
+	fmt.Fprintf(w, "platform: %s\n", platform.Detect())
+
+	errs := CheckMethods(context.Background())
+	for _, method := range (model.Selection{}).Methods() {
+		err, ok := errs[method]
+		if !ok {
+			continue
+		}
+
+		var status string
+		switch {
+		case err == nil:
+			status = "ok"
+		case errors.Is(err, errors.ErrUnsupported):
+			status = fmt.Sprintf("unsupported (%s)", oneLine(err))
+		case errors.Is(err, ErrStaleSource):
+			status = fmt.Sprintf("stale (%s)", oneLine(err))
+		case errors.Is(err, ErrSuspectValues):
+			status = fmt.Sprintf("suspect (%s)", oneLine(err))
+		default:
+			status = fmt.Sprintf("failed (%s)", oneLine(err))
+		}
+		fmt.Fprintf(w, "%s: %s\n", method, status)
+	}
+
+	return nil
+}
+
+// oneLine returns the message of err on a single line, since joined errors
+// span several lines.
+func oneLine(err error) string {
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
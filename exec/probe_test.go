@@ -0,0 +1,33 @@
+package exec
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMethods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errs := CheckMethods(ctx)
+
+	var methods []model.RetrievalMethod
+	for method := range errs {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+	assert.Equal(t, []model.RetrievalMethod{
+		model.RetrievalMethodACPIFPDT,
+		model.RetrievalMethodDeviceTree,
+		model.RetrievalMethodEFIVar,
+		model.RetrievalMethodSystemdAnalyze,
+		model.RetrievalMethodSystemdDBUS,
+		model.RetrievalMethodSystemdJournal,
+	}, methods)
+	assert.ErrorIs(t, errs[model.RetrievalMethodACPIFPDT], context.Canceled)
+	assert.ErrorIs(t, errs[model.RetrievalMethodEFIVar], context.Canceled)
+}
@@ -0,0 +1,112 @@
+package exec
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/systemd"
+)
+
+// Names of the raw systemd manager timestamps stored in records.
+const (
+	rawFirmware  string = "firmware"
+	rawLoader    string = "loader"
+	rawInitRD    string = "initrd"
+	rawUserspace string = "userspace"
+	rawFinish    string = "finish"
+
+	rawGeneratorsStart  string = "generators_start"
+	rawGeneratorsFinish string = "generators_finish"
+	rawUnitsLoadStart   string = "units_load_start"
+	rawUnitsLoadFinish  string = "units_load_finish"
+)
+
+// collectRawSystemdDbus returns the systemd manager timestamps the dbus stages
+// are derived from, along with those of the generators and units load.
+func collectRawSystemdDbus(ctx context.Context) (map[string]time.Duration, error) {
+	ts, err := systemd.RetrieveMonotonicTimestampsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving raw timestamps with dbus property: %w", err)
+	}
+
+	return map[string]time.Duration{
+		rawFirmware:  time.Duration(ts.Firmware) * time.Microsecond,
+		rawLoader:    time.Duration(ts.Loader) * time.Microsecond,
+		rawInitRD:    time.Duration(ts.InitRD) * time.Microsecond,
+		rawUserspace: time.Duration(ts.Userspace) * time.Microsecond,
+		rawFinish:    time.Duration(ts.Finish) * time.Microsecond,
+
+		rawGeneratorsStart:  time.Duration(ts.GeneratorsStart) * time.Microsecond,
+		rawGeneratorsFinish: time.Duration(ts.GeneratorsFinish) * time.Microsecond,
+		rawUnitsLoadStart:   time.Duration(ts.UnitsLoadStart) * time.Microsecond,
+		rawUnitsLoadFinish:  time.Duration(ts.UnitsLoadFinish) * time.Microsecond,
+	}, nil
+}
+
+// RecomputeFromRaw replaces the stages derived from raw timestamps, for the
+// records carrying them, with the stages computed by the current algorithm.
+// Records without raw timestamps are left unchanged.
+func RecomputeFromRaw(records []*model.BootTimeRecord) error {
+	for i, r := range records {
+		raw, ok := r.Raw[model.RetrievalMethodSystemdDBUS]
+		if !ok {
+			continue
+		}
+
+		btr, err := systemd.BootTimeRecordFromTimestamps(systemd.MonotonicTimestamps{
+			Firmware:  uint64(raw[rawFirmware].Microseconds()),
+			Loader:    uint64(raw[rawLoader].Microseconds()),
+			InitRD:    uint64(raw[rawInitRD].Microseconds()),
+			Userspace: uint64(raw[rawUserspace].Microseconds()),
+			Finish:    uint64(raw[rawFinish].Microseconds()),
+		})
+		if err != nil {
+			return fmt.Errorf("recomputing record %d: %w", i, err)
+		}
+
+		for stage, d := range systemdStages(btr) {
+			r.Set(stage, model.RetrievalMethodSystemdDBUS, d)
+		}
+	}
+
+	return nil
+}
+
+// RecomputeFile writes the records of the jsonl file in to the jsonl file out,
+// after recomputing them with RecomputeFromRaw. out is overwritten. A
+// truncated last record of in is left out with a warning.
+func RecomputeFile(in, out string) ([]Warning, error) {
+	records, warnings, err := readRecords(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RecomputeFromRaw(records); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, fmt.Errorf("creating file %s: %w", out, err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return nil, fmt.Errorf("encoding record to jsonl file: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("writing file %s: %w", out, err)
+	}
+
+	return warnings, file.Close()
+}
@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecomputeFromRaw(t *testing.T) {
+	tcs := map[string]struct {
+		record   *model.BootTimeRecord
+		validate func(t *testing.T, r *model.BootTimeRecord, err error)
+	}{
+		"stages are recomputed from raw timestamps": {
+			record: &model.BootTimeRecord{
+				Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageKernel: {
+						model.RetrievalMethodSystemdDBUS:    time.Hour,
+						model.RetrievalMethodSystemdAnalyze: 700 * time.Millisecond,
+					},
+				},
+				Raw: map[model.RetrievalMethod]map[string]time.Duration{
+					model.RetrievalMethodSystemdDBUS: {
+						rawFirmware:  3 * time.Second,
+						rawLoader:    time.Second,
+						rawInitRD:    700 * time.Millisecond,
+						rawUserspace: 900 * time.Millisecond,
+						rawFinish:    4 * time.Second,
+					},
+				},
+			},
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 700*time.Millisecond, r.Values[model.BootTimeStageKernel][model.RetrievalMethodSystemdDBUS])
+				assert.Equal(t, 200*time.Millisecond, r.Values[model.BootTimeStageInitrd][model.RetrievalMethodSystemdDBUS])
+				assert.Equal(t, 3100*time.Millisecond, r.Values[model.BootTimeStageUserspace][model.RetrievalMethodSystemdDBUS])
+				assert.Equal(t, 700*time.Millisecond, r.Values[model.BootTimeStageKernel][model.RetrievalMethodSystemdAnalyze])
+			},
+		},
+		"record without raw timestamps is unchanged": {
+			record: &model.BootTimeRecord{
+				Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+					model.BootTimeStageKernel: {
+						model.RetrievalMethodSystemdDBUS: time.Hour,
+					},
+				},
+			},
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, time.Hour, r.Values[model.BootTimeStageKernel][model.RetrievalMethodSystemdDBUS])
+			},
+		},
+		"unfinished boot fails": {
+			record: &model.BootTimeRecord{
+				Raw: map[model.RetrievalMethod]map[string]time.Duration{
+					model.RetrievalMethodSystemdDBUS: {
+						rawUserspace: 900 * time.Millisecond,
+					},
+				},
+			},
+			validate: func(t *testing.T, r *model.BootTimeRecord, err error) {
+				assert.ErrorContains(t, err, "recomputing record 0")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := RecomputeFromRaw([]*model.BootTimeRecord{tc.record})
+			tc.validate(t, tc.record, err)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/boreec/boottime/model"
+	"github.com/boreec/boottime/remote"
+)
+
+// CollectRemoteRecords listens on addr for records pushed by hosts with
+// remote.Send, and appends each of them to the jsonl file, logging every
+// record received or failing to be written to w. It only returns on failure.
+func CollectRemoteRecords(addr, fileName string, w io.Writer) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	defer ln.Close()
+
+	var mu sync.Mutex
+	return remote.Serve(ln, func(host string, r *model.BootTimeRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := AppendRecord(fileName, r); err != nil {
+			fmt.Fprintf(w, "writing record from %s: %v\n", host, err)
+			return
+		}
+		fmt.Fprintf(w, "received record from %s\n", host)
+	})
+}
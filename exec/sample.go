@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sampleStateSuffix is appended to the name of the jsonl file to name the file
+// counting the boots collected with WithSampleRate.
+const sampleStateSuffix = ".sample"
+
+// nextSample counts a collected boot in the state file and reports whether its
+// record is one of the 1 in rate to write, starting with the first one. The
+// count is kept modulo rate, so that the state file stays small.
+func nextSample(stateFile string, rate int) (bool, error) {
+	count, err := readCount(stateFile)
+	if err != nil {
+		return false, err
+	}
+
+	if err := writeCount(stateFile, (count+1)%rate); err != nil {
+		return false, err
+	}
+
+	return count%rate == 0, nil
+}
+
+// readCount returns the count of the state file, which is zero if the file
+// does not exist.
+func readCount(stateFile string) (int, error) {
+	data, err := os.ReadFile(filepath.Clean(stateFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("reading state file %s: %w", stateFile, err)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("parsing state file %s: %w", stateFile, err)
+	}
+
+	return count, nil
+}
+
+// writeCount replaces the count of the state file.
+func writeCount(stateFile string, count int) error {
+	// The host may be reset at any time, so the count is replaced atomically.
+	tmp := stateFile + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(count)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("writing state file %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, stateFile); err != nil {
+		return fmt.Errorf("replacing state file %s: %w", stateFile, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package exec
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/boreec/boottime/model"
+)
+
+// StripFile writes the records of the in jsonl file to the out jsonl file
+// without the values of the methods, such as a method found unreliable on the
+// host after the records were collected. Records are streamed, and records
+// left without any value are still written to keep one line per boot.
+//
+// A truncated last record of in is ignored with a warning.
+func StripFile(in, out string, methods []model.RetrievalMethod) ([]Warning, error) {
+	inFile, err := os.Open(in)
+	if err != nil {
+		return nil, fmt.Errorf("opening file %s: %w", in, err)
+	}
+	defer inFile.Close()
+
+	outFile, err := os.Create(out)
+	if err != nil {
+		return nil, fmt.Errorf("creating file %s: %w", out, err)
+	}
+	defer outFile.Close()
+
+	w := bufio.NewWriter(outFile)
+	enc := json.NewEncoder(w)
+	err = model.ForEachBootTimeRecord(inFile, func(r *model.BootTimeRecord) error {
+		for _, m := range methods {
+			r.RemoveMethod(m)
+		}
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("encoding record to jsonl file: %w", err)
+		}
+		return nil
+	})
+	warnings, err := truncatedTailWarning(err, in, true)
+	if err != nil {
+		return nil, fmt.Errorf("reading boot time records from file: %w", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("writing file %s: %w", out, err)
+	}
+
+	return warnings, outFile.Close()
+}
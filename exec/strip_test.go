@@ -0,0 +1,31 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.jsonl")
+	out := filepath.Join(dir, "out.jsonl")
+	require.NoError(t, os.WriteFile(in, []byte(`{"kernel":{"systemd_dbus":"1s","systemd_analyze":"1s"},"total":{"systemd_dbus":"1h"}}
+{"total":{"systemd_dbus":"1h"}}
+{"total":{"systemd_`), 0o644))
+
+	warnings, err := StripFile(in, out, []model.RetrievalMethod{model.RetrievalMethodSystemdDBUS})
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.ErrorIs(t, warnings[0].Err, model.ErrTruncatedRecord)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"kernel":{"systemd_analyze":"1s"}}
+{}
+`, string(data))
+}
@@ -0,0 +1,30 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/boreec/boottime/model"
+)
+
+// Warning is a problem which did not prevent collecting or reading records,
+// but which callers may want to surface, such as values dropped from a record.
+type Warning struct {
+	// Method is the retrieval method concerned, if any.
+	Method model.RetrievalMethod
+	Err    error
+}
+
+func (w Warning) String() string {
+	if w.Method == "" {
+		return w.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", w.Method, w.Err)
+}
+
+// PrintWarnings writes every warning to w, one per line.
+func PrintWarnings(w io.Writer, warnings []Warning) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,91 @@
+package exec
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+// fixedStages are the stages derived from timestamps taken before userspace
+// started, which cannot change within a boot.
+var fixedStages = []model.BootTimeStage{
+	model.BootTimeStageFirmware,
+	model.BootTimeStageLoader,
+	model.BootTimeStageKernel,
+	model.BootTimeStageInitrd,
+}
+
+// Watch is the records retrieved several times within the running boot.
+type Watch struct {
+	Records []*model.BootTimeRecord
+	// Warnings are the distinct warnings of the retrievals, and the stages
+	// which changed within the boot.
+	Warnings []Warning
+}
+
+// WatchBootTimes retrieves the boot times samples times within the running
+// boot, sleeping interval between the retrievals, and appends every record to
+// the jsonl file, to measure the noise of the methods. Only the userspace and
+// total stages are expected to differ between the samples, a method reporting
+// another stage with a different value is warned about, but every stage is
+// recorded. It stops at the first failing retrieval.
+func WatchBootTimes(fileName string, interval time.Duration, samples int, opts ...Option) (*Watch, error) {
+	watch := &Watch{}
+	seen := make(map[string]bool)
+	for i := range samples {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+
+		retrieval, err := RetrieveBootTimes(fileName, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving sample %d: %w", i+1, err)
+		}
+
+		for _, w := range retrieval.Warnings {
+			if !seen[w.String()] {
+				seen[w.String()] = true
+				watch.Warnings = append(watch.Warnings, w)
+			}
+		}
+		watch.Records = append(watch.Records, retrieval.Record)
+	}
+
+	watch.Warnings = append(watch.Warnings, fixedStageChanges(watch.Records)...)
+
+	return watch, nil
+}
+
+// fixedStageChanges returns a warning for every method reporting a fixed stage
+// with a value different from the first record.
+func fixedStageChanges(records []*model.BootTimeRecord) []Warning {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var warnings []Warning
+	first := records[0]
+	for _, stage := range fixedStages {
+		for _, method := range (model.Selection{}).Methods() {
+			want, ok := first.Values[stage][method]
+			if !ok {
+				continue
+			}
+			i := slices.IndexFunc(records[1:], func(r *model.BootTimeRecord) bool {
+				got, ok := r.Values[stage][method]
+				return ok && got != want
+			})
+			if i < 0 {
+				continue
+			}
+			warnings = append(warnings, Warning{
+				Method: method,
+				Err:    fmt.Errorf("%s changed within the boot, from %s to %s in sample %d", stage, want, records[i+1].Values[stage][method], i+2),
+			})
+		}
+	}
+
+	return warnings
+}
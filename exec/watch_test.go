@@ -0,0 +1,64 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchBootTimes(t *testing.T) {
+	tcs := map[string]struct {
+		kernel   []time.Duration
+		validate func(t *testing.T, watch *Watch, err error, fileName string)
+	}{
+		"every sample is written": {
+			kernel: []time.Duration{718 * time.Millisecond, 718 * time.Millisecond, 718 * time.Millisecond},
+			validate: func(t *testing.T, watch *Watch, err error, fileName string) {
+				require.NoError(t, err)
+				require.Len(t, watch.Records, 3)
+				assert.Equal(t, 1787*time.Millisecond, watch.Records[0].Values[model.BootTimeStageUserspace][model.RetrievalMethodSystemdAnalyze])
+				assert.Equal(t, 1789*time.Millisecond, watch.Records[2].Values[model.BootTimeStageUserspace][model.RetrievalMethodSystemdAnalyze])
+				assert.Empty(t, watch.Warnings)
+
+				file, err := os.Open(fileName)
+				require.NoError(t, err)
+				defer file.Close()
+				records, err := model.BootTimeRecordsFromFile(file)
+				require.NoError(t, err)
+				assert.Len(t, records, 3)
+			},
+		},
+		"fixed stage changing within the boot is a warning": {
+			kernel: []time.Duration{718 * time.Millisecond, 718 * time.Millisecond, 720 * time.Millisecond},
+			validate: func(t *testing.T, watch *Watch, err error, fileName string) {
+				require.NoError(t, err)
+				require.Len(t, watch.Warnings, 1)
+				assert.Equal(t, "systemd_analyze: kernel changed within the boot, from 718ms to 720ms in sample 3", watch.Warnings[0].String())
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fileName := filepath.Join(t.TempDir(), "results.jsonl")
+			sample := 0
+			collector := collectorFunc{method: model.RetrievalMethodSystemdAnalyze, collect: func() (map[model.BootTimeStage]time.Duration, error) {
+				stages := map[model.BootTimeStage]time.Duration{
+					model.BootTimeStageKernel:    tc.kernel[sample],
+					model.BootTimeStageUserspace: 1787*time.Millisecond + time.Duration(sample)*time.Millisecond,
+				}
+				sample++
+				return stages, nil
+			}}
+
+			watch, err := WatchBootTimes(fileName, time.Millisecond, len(tc.kernel), WithCollectors([]Collector{collector}), withoutHostMetadata)
+			tc.validate(t, watch, err, fileName)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"io"
+	"math"
+	"time"
+)
+
+// cellAccumulator aggregates the durations of a single stage/method cell.
+type cellAccumulator struct {
+	sum   time.Duration
+	count int
+	min   time.Duration
+	max   time.Duration
+	// mean and m2 are the running mean and sum of squared distances to the
+	// mean, in nanoseconds, updated with Welford's algorithm.
+	mean float64
+	m2   float64
+	// samples are only retained when the accumulator is created with
+	// WithRetainedSamples.
+	samples []time.Duration
+}
+
+func (c *cellAccumulator) add(d time.Duration, retainSamples bool) {
+	if c.count == 0 || d < c.min {
+		c.min = d
+	}
+	if c.count == 0 || d > c.max {
+		c.max = d
+	}
+
+	c.sum += d
+	c.count++
+
+	delta := float64(d) - c.mean
+	c.mean += delta / float64(c.count)
+	c.m2 += delta * (float64(d) - c.mean)
+
+	if retainSamples {
+		c.samples = append(c.samples, d)
+	}
+}
+
+// stdDev returns the population standard deviation of the durations.
+func (c *cellAccumulator) stdDev() time.Duration {
+	if c.count == 0 {
+		return 0
+	}
+	return time.Duration(math.Sqrt(c.m2 / float64(c.count)))
+}
+
+type BootTimeAccumulator struct {
+	cells         map[BootTimeStage]map[RetrievalMethod]*cellAccumulator
+	records       int
+	retainSamples bool
+}
+
+// AccumulatorOption configures a BootTimeAccumulator.
+type AccumulatorOption func(*BootTimeAccumulator)
+
+// WithRetainedSamples keeps every duration added to the accumulator, which is
+// required to compute order statistics such as the median. Memory then grows
+// with the number of records.
+func WithRetainedSamples() AccumulatorOption {
+	return func(a *BootTimeAccumulator) {
+		a.retainSamples = true
+	}
+}
+
+func NewBootTimeAccumulator(opts ...AccumulatorOption) *BootTimeAccumulator {
+	a := &BootTimeAccumulator{
+		cells: make(map[BootTimeStage]map[RetrievalMethod]*cellAccumulator),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func (a *BootTimeAccumulator) Add(r *BootTimeRecord) {
+	a.records++
+	for stage, methods := range r.Values {
+		if a.cells[stage] == nil {
+			a.cells[stage] = make(map[RetrievalMethod]*cellAccumulator)
+		}
+
+		for method, d := range methods {
+			c := a.cells[stage][method]
+			if c == nil {
+				c = &cellAccumulator{}
+				a.cells[stage][method] = c
+			}
+			c.add(d, a.retainSamples)
+		}
+	}
+}
+
+// Count returns the number of records added to the accumulator.
+func (a *BootTimeAccumulator) Count() int {
+	return a.records
+}
+
+// AddFromReader reads the jsonl records from r line by line and adds them to
+// the accumulator without retaining them. It stops after limit records if limit
+// is positive, and returns the number of records added.
+func (a *BootTimeAccumulator) AddFromReader(r io.Reader, limit int) (int, error) {
+	added := 0
+	err := ForEachBootTimeRecord(r, func(rec *BootTimeRecord) error {
+		a.Add(rec)
+		added++
+		if limit > 0 && added >= limit {
+			return SkipRemainingRecords
+		}
+		return nil
+	})
+
+	return added, err
+}
+
+// AccumulateFromReader returns an accumulator filled with every jsonl record
+// read from r. Unlike BootTimeRecordsFromFile, the records are not retained, so
+// memory does not grow with the number of records.
+func AccumulateFromReader(r io.Reader) (*BootTimeAccumulator, error) {
+	a := NewBootTimeAccumulator()
+	if _, err := a.AddFromReader(r, 0); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *BootTimeAccumulator) Average() *BootTimeRecord {
+	return a.reduce(func(c *cellAccumulator) time.Duration {
+		return c.sum / time.Duration(c.count)
+	})
+}
+
+// Min returns a record with the shortest duration of every accumulated cell.
+func (a *BootTimeAccumulator) Min() *BootTimeRecord {
+	return a.reduce(func(c *cellAccumulator) time.Duration {
+		return c.min
+	})
+}
+
+// Max returns a record with the longest duration of every accumulated cell.
+func (a *BootTimeAccumulator) Max() *BootTimeRecord {
+	return a.reduce(func(c *cellAccumulator) time.Duration {
+		return c.max
+	})
+}
+
+// StdDev returns a record with the population standard deviation of every
+// accumulated cell. Like the minimum and maximum, it is tracked as durations
+// are added, without retaining them.
+func (a *BootTimeAccumulator) StdDev() *BootTimeRecord {
+	return a.reduce((*cellAccumulator).stdDev)
+}
+
+// reduce returns a record with a single duration computed by fn for every
+// accumulated cell.
+func (a *BootTimeAccumulator) reduce(fn func(c *cellAccumulator) time.Duration) *BootTimeRecord {
+	out := &BootTimeRecord{
+		Values: make(map[BootTimeStage]map[RetrievalMethod]time.Duration),
+	}
+
+	for stage, methods := range a.cells {
+		for method, c := range methods {
+			out.Set(stage, method, fn(c))
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RetrievalMethodCollapsed holds the single duration of a stage once its
+// methods are collapsed with a CollapseStrategy. Records only made of collapsed
+// values are encoded as a flat object of stages.
+const RetrievalMethodCollapsed RetrievalMethod = "collapsed"
+
+// recordMethods are the methods the cells of a record can hold: the retrieval
+// methods, then RetrievalMethodCollapsed. The latter is not a retrieval method,
+// so it is only handled where records are decoded, validated or written.
+var recordMethods = append(slices.Clone(allRetrievalMethods), RetrievalMethodCollapsed)
+
+// CollapseStrategy is how the durations of the methods of a stage are reduced
+// to a single duration.
+type CollapseStrategy string
+
+const (
+	// CollapseConsensus takes the median of the methods, which ignores a
+	// single method disagreeing with the others. Unlike
+	// BootTimeRecord.Consensus, which picks a single method by priority, the
+	// methods are mixed.
+	CollapseConsensus CollapseStrategy = "consensus"
+	// CollapseMean takes the mean of the methods.
+	CollapseMean CollapseStrategy = "mean"
+	// CollapseBest takes the method preferred by DefaultPreferences.
+	CollapseBest CollapseStrategy = "best"
+)
+
+var allCollapseStrategies = []CollapseStrategy{
+	CollapseConsensus,
+	CollapseMean,
+	CollapseBest,
+}
+
+// ParseCollapseStrategy returns the collapse strategy named s, or an error
+// listing the valid strategies.
+func ParseCollapseStrategy(s string) (CollapseStrategy, error) {
+	strategy := CollapseStrategy(s)
+	if !slices.Contains(allCollapseStrategies, strategy) {
+		return "", fmt.Errorf("unknown collapse strategy %q, expected one of %s", s, joinNames(allCollapseStrategies))
+	}
+
+	return strategy, nil
+}
+
+// Collapse returns a record with a single duration per stage, under
+// RetrievalMethodCollapsed, reduced from the methods of the stage with the
+// strategy. The raw timestamps and metadata are kept.
+func (r BootTimeRecord) Collapse(strategy CollapseStrategy) *BootTimeRecord {
+	out := &BootTimeRecord{
+		Values:   make(map[BootTimeStage]map[RetrievalMethod]time.Duration),
+		Raw:      r.Raw,
+		Metadata: r.Metadata,
+	}
+
+	var best map[BootTimeStage]time.Duration
+	if strategy == CollapseBest {
+		best = r.BestOfBreed(DefaultPreferences())
+	}
+
+	for stage, methods := range r.Values {
+		if len(methods) == 0 {
+			continue
+		}
+
+		var d time.Duration
+		switch strategy {
+		case CollapseBest:
+			var ok bool
+			if d, ok = best[stage]; !ok {
+				continue
+			}
+		case CollapseMean:
+			var sum time.Duration
+			for _, v := range methods {
+				sum += v
+			}
+			d = sum / time.Duration(len(methods))
+		default:
+			values := make([]time.Duration, 0, len(methods))
+			for _, v := range methods {
+				values = append(values, v)
+			}
+			slices.Sort(values)
+			d = percentile(values, 50)
+		}
+
+		out.Values[stage] = map[RetrievalMethod]time.Duration{RetrievalMethodCollapsed: d}
+	}
+
+	return out
+}
+
+// hasMethod reports whether at least one stage of the record has a value of
+// the method.
+func (r BootTimeRecord) hasMethod(method RetrievalMethod) bool {
+	for _, methods := range r.Values {
+		if _, ok := methods[method]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isCollapsed reports whether every value of the record is collapsed.
+func (r BootTimeRecord) isCollapsed() bool {
+	if len(r.Values) == 0 {
+		return false
+	}
+
+	for _, methods := range r.Values {
+		if _, ok := methods[RetrievalMethodCollapsed]; !ok || len(methods) != 1 {
+			return false
+		}
+	}
+
+	return true
+}
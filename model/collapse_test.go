@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeRecordCollapse(t *testing.T) {
+	record := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:       2 * time.Second,
+				RetrievalMethodEFIVar:         2100 * time.Millisecond,
+				RetrievalMethodSystemdAnalyze: 5 * time.Second,
+			},
+			BootTimeStageKernel: {
+				RetrievalMethodSystemdDBUS: time.Second,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		strategy CollapseStrategy
+		expected map[BootTimeStage]map[RetrievalMethod]time.Duration
+	}{
+		"consensus ignores the outlier": {
+			strategy: CollapseConsensus,
+			expected: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageFirmware: {RetrievalMethodCollapsed: 2100 * time.Millisecond},
+				BootTimeStageKernel:   {RetrievalMethodCollapsed: time.Second},
+			},
+		},
+		"mean": {
+			strategy: CollapseMean,
+			expected: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageFirmware: {RetrievalMethodCollapsed: 3033333333 * time.Nanosecond},
+				BootTimeStageKernel:   {RetrievalMethodCollapsed: time.Second},
+			},
+		},
+		"best omits stages without the preferred method": {
+			strategy: CollapseBest,
+			expected: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageFirmware: {RetrievalMethodCollapsed: 2 * time.Second},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, record.Collapse(tc.strategy).Values, name)
+		})
+	}
+}
+
+func TestCollapsedBootTimeRecordJSON(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {RetrievalMethodCollapsed: 2 * time.Second},
+			BootTimeStageKernel:   {RetrievalMethodCollapsed: 718 * time.Millisecond},
+		},
+	}
+
+	data, err := json.Marshal(btr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"firmware":"2s","kernel":"718ms"}`, string(data))
+
+	var out BootTimeRecord
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, btr.Values, out.Values)
+
+	// Legacy integer durations are accepted in the flat shape as well.
+	require.NoError(t, json.Unmarshal([]byte(`{"total":4000000000}`), &out))
+	assert.Equal(t, 4*time.Second, out.Values[BootTimeStageTotal][RetrievalMethodCollapsed])
+}
+
+func TestCollapsedIsNotARetrievalMethod(t *testing.T) {
+	btr := &BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageTotal: {RetrievalMethodCollapsed: 4 * time.Second},
+		},
+	}
+
+	assert.NotContains(t, Selection{}.Methods(), RetrievalMethodCollapsed)
+	assert.NotContains(t, CSVColumns(true), CSVColumn{Stage: BootTimeStageTotal, Method: RetrievalMethodCollapsed})
+
+	// Records holding collapsed values still have them written.
+	assert.Contains(t, CSVColumns(true, btr), CSVColumn{Stage: BootTimeStageTotal, Method: RetrievalMethodCollapsed})
+	assert.Equal(t, RetrievalMethodCollapsed, RetrievalMethod(btr.ToTable()[0][len(allRetrievalMethods)+1]))
+	assert.NotContains(t, (&BootTimeRecord{}).ToTable()[0], string(RetrievalMethodCollapsed))
+}
+
+func TestParseCollapseStrategy(t *testing.T) {
+	strategy, err := ParseCollapseStrategy("mean")
+	require.NoError(t, err)
+	assert.Equal(t, CollapseMean, strategy)
+
+	_, err = ParseCollapseStrategy("median")
+	assert.ErrorContains(t, err, "expected one of consensus, mean, best")
+}
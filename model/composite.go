@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// DefaultPreferences returns the most accurate method for every stage: the
+// firmware and loader durations measured by the firmware itself with ACPI FPDT,
+// and the remaining stages measured by systemd.
+func DefaultPreferences() map[BootTimeStage]RetrievalMethod {
+	return map[BootTimeStage]RetrievalMethod{
+		BootTimeStageFirmware:  RetrievalMethodACPIFPDT,
+		BootTimeStageLoader:    RetrievalMethodACPIFPDT,
+		BootTimeStageKernel:    RetrievalMethodSystemdAnalyze,
+		BootTimeStageInitrd:    RetrievalMethodSystemdAnalyze,
+		BootTimeStageUserspace: RetrievalMethodSystemdAnalyze,
+		BootTimeStageTotal:     RetrievalMethodSystemdAnalyze,
+	}
+}
+
+// BestOfBreed returns a single duration per stage, taken from the method
+// preferred for that stage in prefs. Stages without a preference, or whose
+// preferred method did not report them, are omitted.
+func (r BootTimeRecord) BestOfBreed(prefs map[BootTimeStage]RetrievalMethod) map[BootTimeStage]time.Duration {
+	out := make(map[BootTimeStage]time.Duration, len(prefs))
+	for stage, method := range prefs {
+		if d, ok := r.Values[stage][method]; ok {
+			out[stage] = d
+		}
+	}
+
+	return out
+}
+
+// consensusFallback is the order in which Consensus falls back to the other
+// methods of a stage when its method of DefaultPreferences did not report it,
+// from the most to the least trustworthy. The firmware measures its own stages
+// best, then the loader through the EFI variables, which systemd D-Bus and
+// systemd-analyze only relay, the latter rounded. The journal only estimates
+// the kernel duration, and the device tree and the BMC depend on the board.
+// Collapsed values, which mix methods, come last.
+var consensusFallback = []RetrievalMethod{
+	RetrievalMethodACPIFPDT,
+	RetrievalMethodEFIVar,
+	RetrievalMethodSystemdDBUS,
+	RetrievalMethodSystemdAnalyze,
+	RetrievalMethodSystemdJournal,
+	RetrievalMethodDeviceTree,
+	RetrievalMethodBMC,
+	RetrievalMethodCollapsed,
+}
+
+// ConsensusPriority returns the methods Consensus picks the duration of the
+// stage from, the most trustworthy first: the method of DefaultPreferences,
+// then every other method reporting the stage, as told by Confidence, in the
+// fallback order.
+func ConsensusPriority(stage BootTimeStage) []RetrievalMethod {
+	preferred := DefaultPreferences()[stage]
+	priority := []RetrievalMethod{preferred}
+	for _, method := range consensusFallback {
+		if method != preferred && Confidence(method, stage) != ConfidenceNone {
+			priority = append(priority, method)
+		}
+	}
+
+	return priority
+}
+
+// Consensus returns the best estimate of the duration of the stage, from the
+// first method of ConsensusPriority the record has a value of, along with that
+// method. Unlike BestOfBreed, a stage missing from the preferred method falls
+// back to the next one, and unlike CollapseConsensus, the methods are not
+// mixed. It returns an empty method when no method reported the stage.
+func (r BootTimeRecord) Consensus(stage BootTimeStage) (time.Duration, RetrievalMethod) {
+	for _, method := range ConsensusPriority(stage) {
+		if d, ok := r.Get(stage, method); ok {
+			return d, method
+		}
+	}
+
+	return 0, ""
+}
+
+// ConsensusMethods returns the method Consensus picks for every stage the
+// record has a value of, in the shape of the preferences of BestOfBreed.
+func (r BootTimeRecord) ConsensusMethods() map[BootTimeStage]RetrievalMethod {
+	methods := make(map[BootTimeStage]RetrievalMethod)
+	for _, stage := range allBootTimeStages {
+		if _, method := r.Consensus(stage); method != "" {
+			methods[stage] = method
+		}
+	}
+
+	return methods
+}
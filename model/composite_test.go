@@ -0,0 +1,178 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeRecordBestOfBreed(t *testing.T) {
+	record := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:       2 * time.Second,
+				RetrievalMethodSystemdAnalyze: 3 * time.Second,
+			},
+			BootTimeStageKernel: {
+				RetrievalMethodSystemdAnalyze: time.Second,
+			},
+			BootTimeStageUserspace: {
+				RetrievalMethodSystemdDBUS: 4 * time.Second,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		prefs    map[BootTimeStage]RetrievalMethod
+		expected map[BootTimeStage]time.Duration
+	}{
+		"default preferences skip stages missing the preferred method": {
+			prefs: DefaultPreferences(),
+			expected: map[BootTimeStage]time.Duration{
+				BootTimeStageFirmware: 2 * time.Second,
+				BootTimeStageKernel:   time.Second,
+			},
+		},
+		"custom preferences": {
+			prefs: map[BootTimeStage]RetrievalMethod{
+				BootTimeStageFirmware:  RetrievalMethodSystemdAnalyze,
+				BootTimeStageUserspace: RetrievalMethodSystemdDBUS,
+			},
+			expected: map[BootTimeStage]time.Duration{
+				BootTimeStageFirmware:  3 * time.Second,
+				BootTimeStageUserspace: 4 * time.Second,
+			},
+		},
+		"no preferences": {
+			prefs:    nil,
+			expected: map[BootTimeStage]time.Duration{},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, record.BestOfBreed(tc.prefs), name)
+		})
+	}
+}
+
+func TestBootTimeRecordConsensus(t *testing.T) {
+	tcs := map[string]struct {
+		record         BootTimeRecord
+		stage          BootTimeStage
+		expected       time.Duration
+		expectedMethod RetrievalMethod
+	}{
+		"most trustworthy method": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageFirmware: {
+					RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					RetrievalMethodEFIVar:         1702 * time.Millisecond,
+					RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+				},
+			}},
+			stage:          BootTimeStageFirmware,
+			expected:       1897 * time.Millisecond,
+			expectedMethod: RetrievalMethodACPIFPDT,
+		},
+		"falls back to the next method": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageFirmware: {
+					RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					RetrievalMethodSystemdDBUS:    1899 * time.Millisecond,
+				},
+			}},
+			stage:          BootTimeStageFirmware,
+			expected:       1899 * time.Millisecond,
+			expectedMethod: RetrievalMethodSystemdDBUS,
+		},
+		"total prefers systemd-analyze to systemd dbus": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageTotal: {
+					RetrievalMethodSystemdDBUS:    7 * time.Second,
+					RetrievalMethodSystemdAnalyze: 4605 * time.Millisecond,
+				},
+			}},
+			stage:          BootTimeStageTotal,
+			expected:       4605 * time.Millisecond,
+			expectedMethod: RetrievalMethodSystemdAnalyze,
+		},
+		"kernel prefers the default preference": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {
+					RetrievalMethodSystemdDBUS:    641300 * time.Microsecond,
+					RetrievalMethodSystemdAnalyze: 641 * time.Millisecond,
+				},
+			}},
+			stage:          BootTimeStageKernel,
+			expected:       641 * time.Millisecond,
+			expectedMethod: RetrievalMethodSystemdAnalyze,
+		},
+		"total prefers systemd dbus to collapsed": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageTotal: {
+					RetrievalMethodCollapsed:   4610 * time.Millisecond,
+					RetrievalMethodSystemdDBUS: 4605 * time.Millisecond,
+				},
+			}},
+			stage:          BootTimeStageTotal,
+			expected:       4605 * time.Millisecond,
+			expectedMethod: RetrievalMethodSystemdDBUS,
+		},
+		"collapsed record": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {RetrievalMethodCollapsed: 641 * time.Millisecond},
+			}},
+			stage:          BootTimeStageKernel,
+			expected:       641 * time.Millisecond,
+			expectedMethod: RetrievalMethodCollapsed,
+		},
+		"missing stage": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {RetrievalMethodSystemdDBUS: 641 * time.Millisecond},
+			}},
+			stage: BootTimeStageInitrd,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, method := tc.record.Consensus(tc.stage)
+			assert.Equal(t, tc.expected, d)
+			assert.Equal(t, tc.expectedMethod, method)
+		})
+	}
+}
+
+func TestBootTimeRecordConsensusMethods(t *testing.T) {
+	record := BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodEFIVar:         1702 * time.Millisecond,
+			RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+		},
+		BootTimeStageKernel: {RetrievalMethodSystemdAnalyze: 641 * time.Millisecond},
+	}}
+
+	assert.Equal(t, map[BootTimeStage]RetrievalMethod{
+		BootTimeStageFirmware: RetrievalMethodEFIVar,
+		BootTimeStageKernel:   RetrievalMethodSystemdAnalyze,
+	}, record.ConsensusMethods())
+}
+
+func TestConsensusPriorityCoversEveryStage(t *testing.T) {
+	for _, stage := range allBootTimeStages {
+		priority := ConsensusPriority(stage)
+		require.NotEmpty(t, priority, stage)
+		assert.Equal(t, DefaultPreferences()[stage], priority[0], stage)
+		assert.Equal(t, RetrievalMethodCollapsed, priority[len(priority)-1], stage)
+		for _, method := range allRetrievalMethods {
+			if Confidence(method, stage) != ConfidenceNone {
+				assert.Contains(t, priority, method, "%s of %s", method, stage)
+			}
+		}
+	}
+}
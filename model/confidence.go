@@ -0,0 +1,93 @@
+package model
+
+// ConfidenceLevel is how much a retrieval method can be trusted for a stage.
+type ConfidenceLevel int
+
+const (
+	// ConfidenceNone is for stages a method does not report.
+	ConfidenceNone ConfidenceLevel = iota
+	// ConfidenceLow is for values known to be coarse or wrong.
+	ConfidenceLow
+	// ConfidenceMedium is for values which are derived, estimated, or rounded.
+	ConfidenceMedium
+	// ConfidenceHigh is for values measured directly with a microsecond
+	// resolution.
+	ConfidenceHigh
+)
+
+func (c ConfidenceLevel) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// confidences lists the known reliability of every method. Stages missing for a
+// method are not reported by it.
+var confidences = map[RetrievalMethod]map[BootTimeStage]ConfidenceLevel{
+	RetrievalMethodACPIFPDT: {
+		BootTimeStageFirmware: ConfidenceHigh,
+		BootTimeStageLoader:   ConfidenceHigh,
+	},
+	// The system event log has a one second resolution.
+	RetrievalMethodBMC: {
+		BootTimeStageFirmware: ConfidenceLow,
+	},
+	// The bootstage marks are chosen by the board U-Boot configuration.
+	RetrievalMethodDeviceTree: {
+		BootTimeStageFirmware: ConfidenceMedium,
+		BootTimeStageLoader:   ConfidenceMedium,
+	},
+	// The loader duration is only known when the loader logs its exec time.
+	RetrievalMethodEFIVar: {
+		BootTimeStageFirmware: ConfidenceHigh,
+		BootTimeStageLoader:   ConfidenceMedium,
+	},
+	// The total adds the firmware timestamp, which spans the firmware and the
+	// loader, to the finish timestamp, both measured in microseconds.
+	RetrievalMethodSystemdDBUS: {
+		BootTimeStageFirmware:  ConfidenceHigh,
+		BootTimeStageLoader:    ConfidenceHigh,
+		BootTimeStageKernel:    ConfidenceHigh,
+		BootTimeStageInitrd:    ConfidenceHigh,
+		BootTimeStageUserspace: ConfidenceHigh,
+		BootTimeStageTotal:     ConfidenceHigh,
+	},
+	// systemd-analyze rounds its output to the millisecond.
+	RetrievalMethodSystemdAnalyze: {
+		BootTimeStageFirmware:  ConfidenceMedium,
+		BootTimeStageLoader:    ConfidenceMedium,
+		BootTimeStageKernel:    ConfidenceMedium,
+		BootTimeStageInitrd:    ConfidenceMedium,
+		BootTimeStageUserspace: ConfidenceMedium,
+		BootTimeStageTotal:     ConfidenceMedium,
+	},
+	// The kernel duration is estimated from the first userspace journal entry,
+	// which the userspace duration depends on.
+	RetrievalMethodSystemdJournal: {
+		BootTimeStageKernel:    ConfidenceMedium,
+		BootTimeStageInitrd:    ConfidenceHigh,
+		BootTimeStageUserspace: ConfidenceMedium,
+	},
+	// Collapsed values mix methods of different confidences.
+	RetrievalMethodCollapsed: {
+		BootTimeStageFirmware:  ConfidenceMedium,
+		BootTimeStageLoader:    ConfidenceMedium,
+		BootTimeStageKernel:    ConfidenceMedium,
+		BootTimeStageInitrd:    ConfidenceMedium,
+		BootTimeStageUserspace: ConfidenceMedium,
+		BootTimeStageTotal:     ConfidenceMedium,
+	},
+}
+
+// Confidence returns how much the values of the method can be trusted for the
+// stage.
+func Confidence(method RetrievalMethod, stage BootTimeStage) ConfidenceLevel {
+	return confidences[method][stage]
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfidenceCoversEveryMethod(t *testing.T) {
+	for _, method := range allRetrievalMethods {
+		assert.Contains(t, confidences, method, string(method))
+	}
+}
+
+func TestBootTimeRecordToTableWithConfidence(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+			},
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdDBUS: 7 * time.Second,
+			},
+		},
+	}
+
+	selection := Selection{
+		ExcludedStages: []BootTimeStage{
+			BootTimeStageLoader,
+			BootTimeStageKernel,
+			BootTimeStageInitrd,
+			BootTimeStageUserspace,
+		},
+		ExcludedMethods: methodsExcept(
+			RetrievalMethodACPIFPDT,
+			RetrievalMethodSystemdDBUS,
+		),
+	}
+
+	assert.Equal(t, [][]string{
+		{"Stage", "acpi_fpdt", "systemd_dbus"},
+		{"firmware", "1.897s (high)", ""},
+		{"total", "", "7s (high)"},
+	}, btr.ToTable(WithSelection(selection), WithConfidence()))
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVColumn is a stage/method cell flattened into a column of a CSV row.
+type CSVColumn struct {
+	Stage  BootTimeStage
+	Method RetrievalMethod
+}
+
+// String returns the name of the column, such as "firmware_acpi_fpdt".
+func (c CSVColumn) String() string {
+	return string(c.Stage) + "_" + string(c.Method)
+}
+
+// CSVColumns returns the columns of the records, ordered by stage then by
+// method. With all, every stage/retrieval method column is returned regardless
+// of the records, so that the header stays the same across hosts with different
+// available methods. Otherwise, only the columns with a value in at least one
+// record are returned. Collapsed columns are only returned for records holding
+// collapsed values.
+func CSVColumns(all bool, records ...*BootTimeRecord) []CSVColumn {
+	var columns []CSVColumn
+	for _, stage := range allBootTimeStages {
+		for _, method := range recordMethods {
+			if (all && method != RetrievalMethodCollapsed) || hasCell(records, stage, method) {
+				columns = append(columns, CSVColumn{Stage: stage, Method: method})
+			}
+		}
+	}
+	return columns
+}
+
+func hasCell(records []*BootTimeRecord, stage BootTimeStage, method RetrievalMethod) bool {
+	for _, r := range records {
+		if _, ok := r.Values[stage][method]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CSVRow returns the values of the record in the columns, in seconds. Columns
+// without value are empty.
+func (r BootTimeRecord) CSVRow(columns []CSVColumn) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		if d, ok := r.Values[c.Stage][c.Method]; ok {
+			row[i] = formatSeconds(d)
+		}
+	}
+	return row
+}
+
+// ToCSV writes the record to w as a CSV header of the columns with a value, as
+// returned by CSVColumns, and a single row of their values in seconds.
+func (r BootTimeRecord) ToCSV(w io.Writer) error {
+	columns := CSVColumns(false, &r)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.String()
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("writing csv header: %w", err)
+	}
+	if err := cw.Write(r.CSVRow(columns)); err != nil {
+		return fmt.Errorf("writing csv row: %w", err)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVColumns(t *testing.T) {
+	btr := &BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdAnalyze: 19656 * time.Millisecond,
+			},
+			BootTimeStageFirmware: {
+				RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+				RetrievalMethodACPIFPDT:    1897 * time.Millisecond,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		all      bool
+		validate func(t *testing.T, columns []CSVColumn)
+	}{
+		"columns with data": {
+			validate: func(t *testing.T, columns []CSVColumn) {
+				assert.Equal(t, []CSVColumn{
+					{Stage: BootTimeStageFirmware, Method: RetrievalMethodACPIFPDT},
+					{Stage: BootTimeStageFirmware, Method: RetrievalMethodSystemdDBUS},
+					{Stage: BootTimeStageTotal, Method: RetrievalMethodSystemdAnalyze},
+				}, columns)
+				assert.Equal(t, []string{"1.897", "1.9", "19.656"}, btr.CSVRow(columns))
+			},
+		},
+		"all columns": {
+			all: true,
+			validate: func(t *testing.T, columns []CSVColumn) {
+				assert.Len(t, columns, len(allBootTimeStages)*len(allRetrievalMethods))
+				assert.Equal(t, "firmware_acpi_fpdt", columns[0].String())
+
+				row := btr.CSVRow(columns)
+				assert.Equal(t, "1.897", row[0])
+				assert.Empty(t, row[1])
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tc.validate(t, CSVColumns(tc.all, btr))
+		})
+	}
+}
+
+func TestBootTimeRecordToCSV(t *testing.T) {
+	tcs := map[string]struct {
+		btr      BootTimeRecord
+		expected string
+	}{
+		"header and row": {
+			btr: BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageFirmware: {
+						RetrievalMethodACPIFPDT:    1897 * time.Millisecond,
+						RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+					},
+					BootTimeStageKernel: {
+						RetrievalMethodSystemdDBUS: 718 * time.Millisecond,
+					},
+				},
+			},
+			expected: "firmware_acpi_fpdt,firmware_systemd_dbus,kernel_systemd_dbus\n1.897,1.9,0.718\n",
+		},
+		"zero duration is kept": {
+			btr: BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageInitrd: {RetrievalMethodSystemdAnalyze: 0},
+				},
+			},
+			expected: "initrd_systemd_analyze\n0\n",
+		},
+		"empty record": {
+			expected: "\n\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, tc.btr.ToCSV(&buf))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
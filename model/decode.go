@@ -0,0 +1,294 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf8"
+)
+
+// internedNames maps the known stage and method names to a shared string, so
+// that decoding them does not allocate.
+var internedNames = func() map[string]string {
+	names := make(map[string]string, len(allBootTimeStages)+len(recordMethods))
+	for _, s := range allBootTimeStages {
+		names[string(s)] = string(s)
+	}
+	for _, m := range recordMethods {
+		names[string(m)] = string(m)
+	}
+	return names
+}()
+
+func intern(b []byte) string {
+	if s, ok := internedNames[string(b)]; ok {
+		return s
+	}
+	return string(b)
+}
+
+// recordDecoder decodes the usual shape of a jsonl record without reflection:
+// stages holding a duration or an object of method durations, and the raw
+// timestamps and metadata, which are delegated to encoding/json. Any other
+// input, including invalid JSON and escaped strings, makes it give up, leaving
+// the generic decoding to report the same values or errors as before.
+type recordDecoder struct {
+	data []byte
+	pos  int
+}
+
+// decodeBootTimeRecord decodes line into out, and returns false without
+// modifying out when line is not handled by recordDecoder.
+func decodeBootTimeRecord(line []byte, out *BootTimeRecord) bool {
+	d := recordDecoder{data: line}
+	values := make(map[BootTimeStage]map[RetrievalMethod]time.Duration)
+	var raw map[RetrievalMethod]map[string]time.Duration
+	var metadata *Metadata
+
+	if !d.consume('{') {
+		return false
+	}
+
+	for first := true; !d.consume('}'); first = false {
+		if !first && !d.consume(',') {
+			return false
+		}
+
+		key, ok := d.string()
+		if !ok || !d.consume(':') {
+			return false
+		}
+
+		switch string(key) {
+		case rawKey:
+			value, ok := d.skipValue()
+			if !ok {
+				return false
+			}
+			var err error
+			if raw, err = unmarshalRaw(value); err != nil {
+				return false
+			}
+		case metadataKey:
+			value, ok := d.skipValue()
+			if !ok {
+				return false
+			}
+			metadata = &Metadata{}
+			if err := json.Unmarshal(value, metadata); err != nil {
+				return false
+			}
+		default:
+			stage := BootTimeStage(intern(key))
+			if d.peek() != '{' {
+				v, ok := d.duration()
+				if !ok {
+					return false
+				}
+				values[stage] = map[RetrievalMethod]time.Duration{RetrievalMethodCollapsed: v}
+				continue
+			}
+
+			methods, ok := d.methods()
+			if !ok {
+				return false
+			}
+			values[stage] = methods
+		}
+	}
+
+	d.skipSpace()
+	if d.pos != len(d.data) {
+		return false
+	}
+
+	out.Values = values
+	out.Raw = raw
+	out.Metadata = metadata
+	return true
+}
+
+// methods decodes an object of method durations.
+func (d *recordDecoder) methods() (map[RetrievalMethod]time.Duration, bool) {
+	if !d.consume('{') {
+		return nil, false
+	}
+
+	methods := make(map[RetrievalMethod]time.Duration)
+	for first := true; !d.consume('}'); first = false {
+		if !first && !d.consume(',') {
+			return nil, false
+		}
+
+		key, ok := d.string()
+		if !ok || !d.consume(':') {
+			return nil, false
+		}
+
+		v, ok := d.duration()
+		if !ok {
+			return nil, false
+		}
+		methods[RetrievalMethod(intern(key))] = v
+	}
+
+	return methods, true
+}
+
+// duration decodes a Duration, either a string or an integer number of
+// nanoseconds.
+func (d *recordDecoder) duration() (time.Duration, bool) {
+	if d.peek() == '"' {
+		s, ok := d.string()
+		if !ok {
+			return 0, false
+		}
+		v, err := time.ParseDuration(string(s))
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	}
+
+	return d.integer()
+}
+
+// integer decodes a JSON integer fitting in an int64.
+func (d *recordDecoder) integer() (time.Duration, bool) {
+	d.skipSpace()
+	negative := d.pos < len(d.data) && d.data[d.pos] == '-'
+	if negative {
+		d.pos++
+	}
+
+	start := d.pos
+	var n int64
+	for d.pos < len(d.data) && d.data[d.pos] >= '0' && d.data[d.pos] <= '9' {
+		digit := int64(d.data[d.pos] - '0')
+		if n > (1<<63-1-digit)/10 {
+			return 0, false
+		}
+		n = n*10 + digit
+		d.pos++
+	}
+
+	digits := d.pos - start
+	if digits == 0 || (digits > 1 && d.data[start] == '0') {
+		return 0, false
+	}
+	// Fractions and exponents are rejected by encoding/json for an int64.
+	if d.pos < len(d.data) {
+		if c := d.data[d.pos]; c == '.' || c == 'e' || c == 'E' {
+			return 0, false
+		}
+	}
+
+	if negative {
+		n = -n
+	}
+	return time.Duration(n), true
+}
+
+// string decodes a JSON string without escape sequences and returns its
+// content, which is only valid until the line is modified.
+func (d *recordDecoder) string() ([]byte, bool) {
+	if !d.consume('"') {
+		return nil, false
+	}
+
+	start := d.pos
+	for ; d.pos < len(d.data); d.pos++ {
+		switch c := d.data[d.pos]; {
+		case c == '"':
+			s := d.data[start:d.pos]
+			d.pos++
+			// encoding/json replaces invalid UTF-8, leave it to it.
+			return s, utf8.Valid(s)
+		case c == '\\' || c < 0x20:
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+// skipValue skips any JSON value and returns it. The value is only checked to
+// be balanced, encoding/json validates it when decoding it.
+func (d *recordDecoder) skipValue() ([]byte, bool) {
+	d.skipSpace()
+	start := d.pos
+	depth := 0
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case '"':
+			if !d.skipString() {
+				return nil, false
+			}
+			if depth == 0 {
+				return d.data[start:d.pos], true
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return d.data[start:d.pos], d.pos > start
+			}
+			depth--
+			if depth == 0 {
+				d.pos++
+				return d.data[start:d.pos], true
+			}
+		case ',':
+			if depth == 0 {
+				return d.data[start:d.pos], d.pos > start
+			}
+		}
+		d.pos++
+	}
+
+	return nil, false
+}
+
+// skipString skips a JSON string, including its escape sequences.
+func (d *recordDecoder) skipString() bool {
+	for d.pos++; d.pos < len(d.data); d.pos++ {
+		switch d.data[d.pos] {
+		case '\\':
+			d.pos++
+		case '"':
+			d.pos++
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *recordDecoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next byte which is not a space, or 0 at the end of data.
+func (d *recordDecoder) peek() byte {
+	d.skipSpace()
+	if d.pos == len(d.data) {
+		return 0
+	}
+	return d.data[d.pos]
+}
+
+// consume skips the next byte which is not a space if it is c.
+func (d *recordDecoder) consume(c byte) bool {
+	if d.peek() != c {
+		return false
+	}
+	d.pos++
+	return true
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkRecordLine = `{"firmware":{"efi_var":"1.702811s","systemd_analyze":"1.708s","systemd_dbus":"1.708265s"},"initrd":{"systemd_analyze":"200ms","systemd_dbus":"200.3ms"},"kernel":{"systemd_analyze":"641ms","systemd_dbus":"641.348ms"},"loader":{"efi_var":"151.52ms","systemd_analyze":"267ms","systemd_dbus":"267.711ms"},"total":{"systemd_analyze":"4.605s","systemd_dbus":"4.605013s"},"userspace":{"systemd_analyze":"1.787s","systemd_dbus":"1.787389s"},"metadata":{"kernel_cmdline":"quiet splash","machine_id":"a"}}`
+
+func TestDecodeBootTimeRecordMatchesGeneric(t *testing.T) {
+	tcs := map[string]struct {
+		line string
+		// fast is whether the line is decoded without encoding/json.
+		fast bool
+	}{
+		"nested record":                {line: benchmarkRecordLine, fast: true},
+		"flat record":                  {line: `{"firmware":"1.9s", "kernel" : "718µs"}`, fast: true},
+		"legacy nanoseconds":           {line: `{"firmware":{"acpi_fpdt":1897000000,"efi_var":-0},"total":0}`, fast: true},
+		"raw timestamps":               {line: `{"kernel":{"systemd_dbus":"718ms"},"raw":{"systemd_dbus":{"KernelTimestamp":"1s","Escaped\"Name":2}}}`, fast: true},
+		"null raw timestamps":          {line: `{"raw":null}`, fast: true},
+		"null metadata":                {line: `{"metadata":null}`, fast: true},
+		"timestamped metadata":         {line: `{"metadata":{"timestamp":"2026-03-04T05:06:07Z","hostname":"node-1","kernel_version":"6.8.0"}}`, fast: true},
+		"empty record":                 {line: `{}`, fast: true},
+		"empty stage":                  {line: `{"firmware":{}}`, fast: true},
+		"unknown names":                {line: `{"potatoes":{"tomatoes":"1s"}}`, fast: true},
+		"duplicate stage":              {line: `{"firmware":{"acpi_fpdt":"1s"},"firmware":"2s"}`, fast: true},
+		"escaped stage":                {line: `{"fi\u0072mware":{"acpi_fpdt":"1s"}}`},
+		"null stage":                   {line: `{"firmware":null}`},
+		"float nanoseconds":            {line: `{"firmware":{"acpi_fpdt":1.5}}`},
+		"exponent nanoseconds":         {line: `{"firmware":{"acpi_fpdt":1e9}}`},
+		"leading zero nanoseconds":     {line: `{"firmware":{"acpi_fpdt":01}}`},
+		"overflowing nanoseconds":      {line: `{"firmware":{"acpi_fpdt":9223372036854775808}}`},
+		"invalid duration":             {line: `{"firmware":{"acpi_fpdt":"potatoes"}}`},
+		"invalid metadata":             {line: `{"metadata":{"machine_id":1}}`},
+		"trailing comma":               {line: `{"firmware":"1s",}`},
+		"trailing data":                {line: `{"firmware":"1s"} {}`},
+		"truncated":                    {line: `{"firmware":{"acpi_f`},
+		"invalid utf-8":                {line: "{\"firmware\xff\":\"1s\"}"},
+		"not an object":                {line: `null`},
+		"array":                        {line: `[]`},
+		"unterminated metadata string": {line: `{"metadata":{"machine_id":"a}}`},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var fast BootTimeRecord
+			assert.Equal(t, tc.fast, decodeBootTimeRecord([]byte(tc.line), &fast))
+
+			var generic BootTimeRecord
+			genericErr := unmarshalBootTimeRecordGeneric([]byte(tc.line), &generic)
+
+			var got BootTimeRecord
+			err := UnmarshalBootTimeRecord([]byte(tc.line), &got)
+			if genericErr != nil {
+				assert.EqualError(t, err, genericErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, generic, got)
+		})
+	}
+}
+
+func TestDecodeBootTimeRecordRoundTrip(t *testing.T) {
+	record := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+				RetrievalMethodEFIVar:   1702811 * time.Microsecond,
+			},
+			BootTimeStageKernel: {
+				RetrievalMethodSystemdDBUS: 718123 * time.Nanosecond,
+			},
+		},
+		Raw: map[RetrievalMethod]map[string]time.Duration{
+			RetrievalMethodSystemdDBUS: {"KernelTimestamp": 5 * time.Second},
+		},
+		Metadata: &Metadata{
+			KernelCmdline: `quiet "root=/dev/sda1"`,
+			MachineID:     "a",
+			Timestamp:     time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC),
+			Hostname:      "node-1",
+			KernelVersion: "6.8.0-45-generic",
+		},
+	}
+
+	line, err := json.Marshal(record)
+	require.NoError(t, err)
+
+	var got BootTimeRecord
+	require.True(t, decodeBootTimeRecord(line, &got))
+	assert.Equal(t, record, got)
+}
+
+func BenchmarkUnmarshalBootTimeRecord(b *testing.B) {
+	line := []byte(benchmarkRecordLine)
+
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var r BootTimeRecord
+			if !decodeBootTimeRecord(line, &r) {
+				b.Fatal("line not decoded")
+			}
+		}
+	})
+
+	b.Run("generic", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var r BootTimeRecord
+			require.NoError(b, unmarshalBootTimeRecordGeneric(line, &r))
+		}
+	})
+}
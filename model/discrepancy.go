@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Discrepancy is a pair of methods of the same stage whose values differ by
+// more than expected, one of them likely measuring something else.
+type Discrepancy struct {
+	Stage      BootTimeStage
+	A, B       RetrievalMethod
+	Difference time.Duration
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%s (%s, %s) differ by %s", d.Stage, d.A, d.B, d.Difference)
+}
+
+// Discrepancies returns, for every stage, the pairs of methods whose values
+// differ by more than threshold, in canonical order.
+func (r BootTimeRecord) Discrepancies(threshold time.Duration) []Discrepancy {
+	var discrepancies []Discrepancy
+	for _, stage := range allBootTimeStages {
+		methods := r.Values[stage]
+		for i, a := range allRetrievalMethods {
+			x, ok := methods[a]
+			if !ok {
+				continue
+			}
+			for _, b := range allRetrievalMethods[i+1:] {
+				y, ok := methods[b]
+				if !ok {
+					continue
+				}
+				if diff := (x - y).Abs(); diff > threshold {
+					discrepancies = append(discrepancies, Discrepancy{Stage: stage, A: a, B: b, Difference: diff})
+				}
+			}
+		}
+	}
+
+	return discrepancies
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootTimeRecordDiscrepancies(t *testing.T) {
+	record := BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+			RetrievalMethodEFIVar:         1702 * time.Millisecond,
+			RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+		},
+		BootTimeStageKernel: {
+			RetrievalMethodSystemdAnalyze: 641 * time.Millisecond,
+			RetrievalMethodSystemdDBUS:    718 * time.Millisecond,
+		},
+		BootTimeStageLoader: {
+			RetrievalMethodEFIVar: 151 * time.Millisecond,
+		},
+	}}
+
+	tcs := map[string]struct {
+		threshold time.Duration
+		expected  []Discrepancy
+	}{
+		"pairs over the threshold": {
+			threshold: 50 * time.Millisecond,
+			expected: []Discrepancy{
+				{Stage: BootTimeStageFirmware, A: RetrievalMethodACPIFPDT, B: RetrievalMethodEFIVar, Difference: 195 * time.Millisecond},
+				{Stage: BootTimeStageFirmware, A: RetrievalMethodEFIVar, B: RetrievalMethodSystemdAnalyze, Difference: 198 * time.Millisecond},
+				{Stage: BootTimeStageKernel, A: RetrievalMethodSystemdDBUS, B: RetrievalMethodSystemdAnalyze, Difference: 77 * time.Millisecond},
+			},
+		},
+		"difference equal to the threshold is tolerated": {
+			threshold: 198 * time.Millisecond,
+		},
+		"zero threshold reports any difference": {
+			threshold: 0,
+			expected: []Discrepancy{
+				{Stage: BootTimeStageFirmware, A: RetrievalMethodACPIFPDT, B: RetrievalMethodEFIVar, Difference: 195 * time.Millisecond},
+				{Stage: BootTimeStageFirmware, A: RetrievalMethodACPIFPDT, B: RetrievalMethodSystemdAnalyze, Difference: 3 * time.Millisecond},
+				{Stage: BootTimeStageFirmware, A: RetrievalMethodEFIVar, B: RetrievalMethodSystemdAnalyze, Difference: 198 * time.Millisecond},
+				{Stage: BootTimeStageKernel, A: RetrievalMethodSystemdDBUS, B: RetrievalMethodSystemdAnalyze, Difference: 77 * time.Millisecond},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, record.Discrepancies(tc.threshold))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration serialized in JSON as a human readable string,
+// such as "1.897s". When unmarshalling, it also accepts an integer number of
+// nanoseconds, which is how previous versions stored durations. Fractional
+// numbers are rejected rather than read in another unit, which would make a
+// bare number ambiguous.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("parsing duration %q: %w", s, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var ns int64
+	if err := json.Unmarshal(data, &ns); err != nil {
+		return fmt.Errorf("parsing duration %s: %w", data, err)
+	}
+	*d = Duration(ns)
+
+	return nil
+}
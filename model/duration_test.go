@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationJSON(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, d Duration, err error, name string)
+	}{
+		"unmarshal human readable string": {
+			input: `"1.897s"`,
+			validate: func(t *testing.T, d Duration, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, Duration(1897*time.Millisecond), d, name)
+			},
+		},
+		"unmarshal milliseconds string": {
+			input: `"1897.5ms"`,
+			validate: func(t *testing.T, d Duration, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, Duration(1897500*time.Microsecond), d, name)
+			},
+		},
+		"unmarshal legacy nanoseconds": {
+			input: `1897000000`,
+			validate: func(t *testing.T, d Duration, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, Duration(1897*time.Millisecond), d, name)
+			},
+		},
+		"unmarshal fractional number returns error": {
+			input: `1897.5`,
+			validate: func(t *testing.T, d Duration, err error, name string) {
+				require.Error(t, err, name)
+			},
+		},
+		"unmarshal invalid string returns error": {
+			input: `"potatoes"`,
+			validate: func(t *testing.T, d Duration, err error, name string) {
+				require.Error(t, err, name)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var d Duration
+			err := json.Unmarshal([]byte(tc.input), &d)
+			tc.validate(t, d, err, name)
+		})
+	}
+}
+
+func TestBootTimeRecordJSONRoundTrip(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdAnalyze: 65998 * time.Millisecond,
+			},
+		},
+	}
+
+	data, err := json.Marshal(btr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"total":{"systemd_analyze":"1m5.998s"}}`, string(data))
+
+	var out BootTimeRecord
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, btr.Values, out.Values)
+}
+
+func TestBootTimeRecordJSONRoundTripWithRawAndMetadata(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageKernel: {
+				RetrievalMethodSystemdDBUS: 718 * time.Millisecond,
+			},
+		},
+		Raw: map[RetrievalMethod]map[string]time.Duration{
+			RetrievalMethodSystemdDBUS: {
+				"finish": 4 * time.Second,
+			},
+		},
+		Metadata: &Metadata{KernelCmdline: "quiet mitigations=off"},
+	}
+
+	data, err := json.Marshal(btr)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kernel":{"systemd_dbus":"718ms"},"raw":{"systemd_dbus":{"finish":"4s"}},"metadata":{"kernel_cmdline":"quiet mitigations=off"}}`, string(data))
+
+	var out BootTimeRecord
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, btr.Values, out.Values)
+	assert.Equal(t, btr.Raw, out.Raw)
+	assert.Equal(t, btr.Metadata, out.Metadata)
+}
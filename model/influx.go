@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InfluxMeasurement is the measurement of the records written in the InfluxDB
+// line protocol by the command line.
+const InfluxMeasurement string = "boottime"
+
+var (
+	// influxMeasurementEscaper escapes a measurement, where only commas and
+	// spaces are special.
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	// influxKeyEscaper escapes a tag key, a tag value or a field key.
+	influxKeyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// WriteInfluxLineProtocol writes the record to w as a single line of the
+// InfluxDB line protocol: the measurement, the tags in lexical order of their
+// keys, a float field per stage/method cell named like the CSV columns, such as
+// "firmware_acpi_fpdt", with the duration in seconds, and the timestamp of the
+// metadata in nanoseconds. Without timestamp, the time of the write is used by
+// InfluxDB. Since a line needs a field, nothing is written for a record
+// without cell.
+func (r BootTimeRecord) WriteInfluxLineProtocol(w io.Writer, measurement string, tags map[string]string) error {
+	if measurement == "" {
+		return errors.New("empty influx measurement")
+	}
+
+	var fields []string
+	for _, stage := range allBootTimeStages {
+		for _, method := range recordMethods {
+			d, ok := r.Values[stage][method]
+			if !ok {
+				continue
+			}
+			column := CSVColumn{Stage: stage, Method: method}
+			fields = append(fields, influxKeyEscaper.Replace(column.String())+"="+formatSeconds(d))
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(influxMeasurementEscaper.Replace(measurement))
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		// Empty tag keys and values are rejected by InfluxDB.
+		if key == "" || tags[key] == "" {
+			continue
+		}
+		b.WriteString("," + influxKeyEscaper.Replace(key) + "=" + influxKeyEscaper.Replace(tags[key]))
+	}
+	b.WriteString(" " + strings.Join(fields, ","))
+	if r.Metadata != nil && !r.Metadata.Timestamp.IsZero() {
+		b.WriteString(" " + strconv.FormatInt(r.Metadata.Timestamp.UnixNano(), 10))
+	}
+	b.WriteString("\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing influx line protocol: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeRecordWriteInfluxLineProtocol(t *testing.T) {
+	values := map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageKernel: {RetrievalMethodSystemdDBUS: 718 * time.Millisecond},
+		BootTimeStageFirmware: {
+			RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+			RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+		},
+	}
+
+	tcs := map[string]struct {
+		record      BootTimeRecord
+		measurement string
+		tags        map[string]string
+		validate    func(t *testing.T, out string, err error)
+	}{
+		"fields in canonical order": {
+			record:      BootTimeRecord{Values: values},
+			measurement: "boottime",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "boottime firmware_acpi_fpdt=1.897,firmware_systemd_analyze=1.9,kernel_systemd_dbus=0.718\n", out)
+			},
+		},
+		"sorted tags and nanosecond timestamp": {
+			record: BootTimeRecord{
+				Values:   map[BootTimeStage]map[RetrievalMethod]time.Duration{BootTimeStageTotal: {RetrievalMethodCollapsed: 4605 * time.Millisecond}},
+				Metadata: &Metadata{Timestamp: time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC)},
+			},
+			measurement: "boottime",
+			tags:        map[string]string{"machine_id": "a", "host": "node-1"},
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "boottime,host=node-1,machine_id=a total_collapsed=4.605 1772600767000000008\n", out)
+			},
+		},
+		"escaped measurement and tags": {
+			record:      BootTimeRecord{Values: values},
+			measurement: "boot time,x=y",
+			tags:        map[string]string{"kernel cmdline": "quiet splash,root=/dev/sda1"},
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, `boot\ time\,x=y,kernel\ cmdline=quiet\ splash\,root\=/dev/sda1 firmware_acpi_fpdt=1.897,firmware_systemd_analyze=1.9,kernel_systemd_dbus=0.718`+"\n", out)
+			},
+		},
+		"empty tags are left out": {
+			record:      BootTimeRecord{Values: values},
+			measurement: "boottime",
+			tags:        map[string]string{"host": "", "": "a"},
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "boottime firmware_acpi_fpdt=1.897,firmware_systemd_analyze=1.9,kernel_systemd_dbus=0.718\n", out)
+			},
+		},
+		"empty record writes nothing": {
+			record:      BootTimeRecord{},
+			measurement: "boottime",
+			validate: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				assert.Empty(t, out)
+			},
+		},
+		"empty measurement returns error": {
+			record: BootTimeRecord{Values: values},
+			validate: func(t *testing.T, out string, err error) {
+				require.ErrorContains(t, err, "empty influx measurement")
+				assert.Empty(t, out)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := tc.record.WriteInfluxLineProtocol(&buf, tc.measurement, tc.tags)
+			tc.validate(t, buf.String(), err)
+		})
+	}
+}
@@ -2,9 +2,14 @@ package model
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
 	"time"
 )
 
@@ -12,16 +17,22 @@ type RetrievalMethod string
 
 const (
 	RetrievalMethodACPIFPDT       RetrievalMethod = "acpi_fpdt"
+	RetrievalMethodBMC            RetrievalMethod = "bmc"
+	RetrievalMethodDeviceTree     RetrievalMethod = "devicetree"
 	RetrievalMethodEFIVar         RetrievalMethod = "efi_var"
 	RetrievalMethodSystemdDBUS    RetrievalMethod = "systemd_dbus"
 	RetrievalMethodSystemdAnalyze RetrievalMethod = "systemd_analyze"
+	RetrievalMethodSystemdJournal RetrievalMethod = "systemd_journal"
 )
 
 var allRetrievalMethods = []RetrievalMethod{
 	RetrievalMethodACPIFPDT,
+	RetrievalMethodBMC,
+	RetrievalMethodDeviceTree,
 	RetrievalMethodEFIVar,
 	RetrievalMethodSystemdDBUS,
 	RetrievalMethodSystemdAnalyze,
+	RetrievalMethodSystemdJournal,
 }
 
 type BootTimeStage string
@@ -46,31 +57,169 @@ var allBootTimeStages = []BootTimeStage{
 
 type BootTimeRecord struct {
 	Values map[BootTimeStage]map[RetrievalMethod]time.Duration
+
+	// Raw are the timestamps some methods derive their stages from, keyed by
+	// name, so that the stages can be recomputed if the derivation changes.
+	Raw map[RetrievalMethod]map[string]time.Duration
+
+	// Metadata describes the boot, or is nil if unknown.
+	Metadata *Metadata
+
+	// stale are the methods whose values may come from a previous boot.
+	stale map[RetrievalMethod]bool
 }
 
-func (r BootTimeRecord) ToTable() [][]string {
-	rows := make([][]string, 0, len(allBootTimeStages)+1)
+// Get returns the duration of the stage measured by the method, and whether the
+// record has it.
+func (r BootTimeRecord) Get(stage BootTimeStage, method RetrievalMethod) (time.Duration, bool) {
+	d, ok := r.Values[stage][method]
+	return d, ok
+}
 
-	header := make([]string, 0, len(allRetrievalMethods)+1)
+// Set sets the duration of the stage measured by the method, creating the maps
+// of the record as needed.
+func (r *BootTimeRecord) Set(stage BootTimeStage, method RetrievalMethod, d time.Duration) {
+	if r.Values == nil {
+		r.Values = make(map[BootTimeStage]map[RetrievalMethod]time.Duration)
+	}
+	if r.Values[stage] == nil {
+		r.Values[stage] = make(map[RetrievalMethod]time.Duration)
+	}
+	r.Values[stage][method] = d
+}
+
+// MarkStale flags the values of the method as possibly coming from a previous
+// boot, so that DropStaleSources removes them.
+func (r *BootTimeRecord) MarkStale(m RetrievalMethod) {
+	if r.stale == nil {
+		r.stale = make(map[RetrievalMethod]bool)
+	}
+	r.stale[m] = true
+}
+
+// DropStaleSources removes the values of every method flagged by MarkStale,
+// so that a record never mixes data from two different boots, including the
+// methods of custom collectors. It returns the dropped methods in lexical
+// order.
+func (r *BootTimeRecord) DropStaleSources() []RetrievalMethod {
+	var dropped []RetrievalMethod
+	for _, m := range slices.Sorted(maps.Keys(r.stale)) {
+		for stage, methods := range r.Values {
+			delete(methods, m)
+			if len(methods) == 0 {
+				delete(r.Values, stage)
+			}
+		}
+		dropped = append(dropped, m)
+	}
+	r.stale = nil
+
+	return dropped
+}
+
+type tableOptions struct {
+	selection      Selection
+	uniformUnits   bool
+	withConfidence bool
+	withShares     bool
+	rangeMin       *BootTimeRecord
+	rangeMax       *BootTimeRecord
+}
+
+// TableOption configures the rendering of ToTable.
+type TableOption func(*tableOptions)
+
+// WithSelection only renders the stages and methods selected by s.
+func WithSelection(s Selection) TableOption {
+	return func(o *tableOptions) {
+		o.selection = s
+	}
+}
+
+// WithUniformUnits renders every duration of a stage with the same unit,
+// picked from the largest duration of the stage: seconds with two decimals from
+// one second, otherwise whole milliseconds.
+func WithUniformUnits() TableOption {
+	return func(o *tableOptions) {
+		o.uniformUnits = true
+	}
+}
+
+// WithShares annotates every duration with its share of the total of its
+// method, as computed by ShareOfTotal. A duration longer than the total is
+// annotated as suspect instead of exceeding 100%.
+func WithShares() TableOption {
+	return func(o *tableOptions) {
+		o.withShares = true
+	}
+}
+
+// WithConfidence annotates every duration with the confidence level of its
+// method for the stage.
+func WithConfidence() TableOption {
+	return func(o *tableOptions) {
+		o.withConfidence = true
+	}
+}
+
+// WithRange annotates every duration with the range of its stage and method,
+// from the shortest to the longest, such as "1.7s (1.6s–1.8s)". Durations
+// missing from shortest or longest are not annotated.
+func WithRange(shortest, longest *BootTimeRecord) TableOption {
+	return func(o *tableOptions) {
+		o.rangeMin = shortest
+		o.rangeMax = longest
+	}
+}
+
+func (r BootTimeRecord) ToTable(opts ...TableOption) [][]string {
+	var o tableOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	stages := o.selection.Stages()
+	retrievalMethods := o.selection.Methods()
+	if r.hasMethod(RetrievalMethodCollapsed) {
+		retrievalMethods = append(retrievalMethods, RetrievalMethodCollapsed)
+	}
+
+	rows := make([][]string, 0, len(stages)+1)
+
+	header := make([]string, 0, len(retrievalMethods)+1)
 	header = append(header, "Stage")
-	for _, m := range allRetrievalMethods {
+	for _, m := range retrievalMethods {
 		header = append(header, string(m))
 	}
 	rows = append(rows, header)
 
-	for _, stage := range allBootTimeStages {
-		row := make([]string, 0, len(allRetrievalMethods)+1)
+	for _, stage := range stages {
+		row := make([]string, 0, len(retrievalMethods)+1)
 		row = append(row, string(stage))
 
-		methods, ok := r.Values[stage]
-		for _, method := range allRetrievalMethods {
-			if ok {
-				if d, exists := methods[method]; exists {
-					row = append(row, d.String())
-					continue
-				}
+		format := time.Duration.String
+		if o.uniformUnits {
+			format = uniformUnitFormatter(r.Values[stage])
+		}
+
+		for _, method := range retrievalMethods {
+			d, ok := r.Get(stage, method)
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+
+			cell := format(d)
+			if lo, hi, ok := o.cellRange(stage, method); ok {
+				cell += " (" + format(lo) + "–" + format(hi) + ")"
 			}
-			row = append(row, "")
+			if o.withShares && stage != BootTimeStageTotal {
+				cell += shareAnnotation(r, stage, method)
+			}
+			if o.withConfidence {
+				cell += " (" + Confidence(method, stage).String() + ")"
+			}
+			row = append(row, cell)
 		}
 		rows = append(rows, row)
 	}
@@ -78,83 +227,494 @@ func (r BootTimeRecord) ToTable() [][]string {
 	return rows
 }
 
-type BootTimeAccumulator struct {
-	sum   map[BootTimeStage]map[RetrievalMethod]time.Duration
-	count map[BootTimeStage]map[RetrievalMethod]int
+// cellRange returns the range of the stage and method set by WithRange, if
+// any.
+func (o tableOptions) cellRange(stage BootTimeStage, method RetrievalMethod) (lo, hi time.Duration, ok bool) {
+	if o.rangeMin == nil || o.rangeMax == nil {
+		return 0, 0, false
+	}
+	lo, okMin := o.rangeMin.Get(stage, method)
+	hi, okMax := o.rangeMax.Get(stage, method)
+	return lo, hi, okMin && okMax
 }
 
-func NewBootTimeAccumulator() *BootTimeAccumulator {
-	return &BootTimeAccumulator{
-		sum:   make(map[BootTimeStage]map[RetrievalMethod]time.Duration),
-		count: make(map[BootTimeStage]map[RetrievalMethod]int),
+// shareAnnotation returns the share of the stage in the total of the method,
+// or nothing if the method has no total.
+func shareAnnotation(r BootTimeRecord, stage BootTimeStage, method RetrievalMethod) string {
+	share, ok := r.ShareOfTotal(stage, method)
+	if !ok {
+		return ""
 	}
+	if r.exceedsTotal(stage, method) {
+		return " [100%, suspect]"
+	}
+	return fmt.Sprintf(" [%.0f%%]", share*100)
 }
 
-func (a *BootTimeAccumulator) Add(r *BootTimeRecord) {
-	for stage, methods := range r.Values {
-		if a.sum[stage] == nil {
-			a.sum[stage] = make(map[RetrievalMethod]time.Duration)
-			a.count[stage] = make(map[RetrievalMethod]int)
+func uniformUnitFormatter(methods map[RetrievalMethod]time.Duration) func(time.Duration) string {
+	var largest time.Duration
+	for _, d := range methods {
+		largest = max(largest, d)
+	}
+
+	if largest >= time.Second {
+		return func(d time.Duration) string {
+			return fmt.Sprintf("%.2fs", d.Seconds())
+		}
+	}
+
+	return func(d time.Duration) string {
+		return fmt.Sprintf("%.0fms", float64(d)/float64(time.Millisecond))
+	}
+}
+
+// ToTriples returns every value of the record as a [stage, method, seconds]
+// triple, a flatter shape than the JSON encoding for columnar tools. Triples
+// are ordered by stage, then by method.
+func (r BootTimeRecord) ToTriples() [][3]any {
+	var triples [][3]any
+	for _, stage := range allBootTimeStages {
+		methods, ok := r.Values[stage]
+		if !ok {
+			continue
 		}
+		for _, method := range recordMethods {
+			if d, ok := methods[method]; ok {
+				triples = append(triples, [3]any{string(stage), string(method), d.Seconds()})
+			}
+		}
+	}
+	return triples
+}
+
+// HasData reports whether at least one cell of the record is non-zero.
+func (r BootTimeRecord) HasData() bool {
+	for _, methods := range r.Values {
+		for _, d := range methods {
+			if d != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EqualWithin reports whether both records contain the same stage/method cells
+// and whether every pair of values differs by at most tolerance. A cell present
+// in only one of the records makes them unequal.
+func (r BootTimeRecord) EqualWithin(other *BootTimeRecord, tolerance time.Duration) bool {
+	if other == nil {
+		return false
+	}
+
+	if countCells(r.Values) != countCells(other.Values) {
+		return false
+	}
 
+	for stage, methods := range r.Values {
 		for method, d := range methods {
-			a.sum[stage][method] += d
-			a.count[stage][method]++
+			o, ok := other.Values[stage][method]
+			if !ok {
+				return false
+			}
+
+			diff := d - o
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > tolerance {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// RemoveMethod deletes the values and raw timestamps of the method from the
+// record. Stages left without any value are deleted as well.
+func (r *BootTimeRecord) RemoveMethod(m RetrievalMethod) {
+	for stage, methods := range r.Values {
+		delete(methods, m)
+		if len(methods) == 0 {
+			delete(r.Values, stage)
 		}
 	}
+
+	delete(r.Raw, m)
+	if len(r.Raw) == 0 {
+		r.Raw = nil
+	}
 }
 
-func (a *BootTimeAccumulator) Average() *BootTimeRecord {
-	out := &BootTimeRecord{
+// DeltaFrom returns a record holding, for every stage/method cell present in
+// both records, how much longer the cell of r is than the cell of ref. A
+// negative duration means r is faster than ref.
+func (r BootTimeRecord) DeltaFrom(ref *BootTimeRecord) *BootTimeRecord {
+	delta := &BootTimeRecord{
 		Values: make(map[BootTimeStage]map[RetrievalMethod]time.Duration),
 	}
 
-	for stage, methods := range a.sum {
-		out.Values[stage] = make(map[RetrievalMethod]time.Duration)
+	for stage, methods := range r.Values {
+		for method, d := range methods {
+			o, ok := ref.Get(stage, method)
+			if !ok {
+				continue
+			}
+			delta.Set(stage, method, d-o)
+		}
+	}
+
+	return delta
+}
 
-		for method, total := range methods {
-			out.Values[stage][method] = total / time.Duration(a.count[stage][method])
+func countCells(values map[BootTimeStage]map[RetrievalMethod]time.Duration) int {
+	n := 0
+	for _, methods := range values {
+		n += len(methods)
+	}
+	return n
+}
+
+// utf8BOM is the byte order mark some Windows tools write at the start of UTF-8
+// files.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// SkipRemainingRecords can be returned by the callback of
+// ForEachBootTimeRecord to stop reading without failing.
+var SkipRemainingRecords = errors.New("skip remaining records")
+
+// ErrTruncatedRecord is returned when the last line of the records, not
+// terminated by a newline, cannot be parsed. This happens when appending a
+// record was interrupted, for instance by a power loss, and every record before
+// it has been read successfully.
+var ErrTruncatedRecord = errors.New("last record is truncated")
+
+// ReadOption configures BootTimeRecordsFromFile.
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	strict bool
+}
+
+// WithStrictValidation checks every record with the rules of
+// ValidateRecordLine, and prefixes the errors with the number of the offending
+// line, blank lines included, as shown by an editor.
+func WithStrictValidation() ReadOption {
+	return func(o *readOptions) {
+		o.strict = true
+	}
+}
+
+// BootTimeRecordsFromFile returns every record of the jsonl file. On
+// ErrTruncatedRecord, the records before the truncated one are returned along
+// with the error.
+func BootTimeRecordsFromFile(file *os.File, opts ...ReadOption) ([]*BootTimeRecord, error) {
+	var o readOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	records := []*BootTimeRecord{}
+	err := forEachLine(file, func(n int, line []byte, last bool) error {
+		rec, err := unmarshalLine(line, last)
+		if err == nil && o.strict {
+			err = validateRecord(rec)
+		}
+		if err != nil && o.strict {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if err != nil {
+			return err
 		}
+
+		records = append(records, rec)
+		return nil
+	})
+	if errors.Is(err, ErrTruncatedRecord) {
+		return records, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return out
+	return records, nil
+}
+
+// ForEachBootTimeRecord reads the jsonl records from r line by line and calls
+// fn for each of them, without retaining them.
+//
+// Lines are read without any length limit, since records carrying metadata can
+// be longer than the default token size of a bufio.Scanner. To read files
+// written on Windows or by other tools, a UTF-8 BOM at the start, surrounding
+// whitespace including CRLF line endings, and a trailing comma are ignored, and
+// blank lines are skipped. A last line without newline failing to parse returns
+// an error wrapping ErrTruncatedRecord.
+func ForEachBootTimeRecord(r io.Reader, fn func(*BootTimeRecord) error) error {
+	return forEachLine(r, func(_ int, line []byte, last bool) error {
+		rec, err := unmarshalLine(line, last)
+		if err != nil {
+			return err
+		}
+
+		return fn(rec)
+	})
+}
+
+// LineError is a line of a jsonl file which could not be parsed.
+type LineError struct {
+	// Number is the number of the line in the file, starting at 1, blank
+	// lines included, as in the errors of CheckSchemas.
+	Number int
+	Err    error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Number, e.Err)
+}
+
+func (e LineError) Unwrap() error {
+	return e.Err
+}
+
+// ForEachBootTimeRecordLenient is ForEachBootTimeRecord skipping the lines
+// failing to parse, including a truncated last line, and returning them
+// instead. The error is only about reading r, or returned by fn.
+func ForEachBootTimeRecordLenient(r io.Reader, fn func(*BootTimeRecord) error) ([]LineError, error) {
+	var skipped []LineError
+	err := forEachLine(r, func(n int, line []byte, last bool) error {
+		rec, err := unmarshalLine(line, last)
+		if err != nil {
+			skipped = append(skipped, LineError{Number: n, Err: err})
+			return nil
+		}
+
+		return fn(rec)
+	})
+
+	return skipped, err
 }
 
-func BootTimeRecordsFromFile(file *os.File) ([]*BootTimeRecord, error) {
+// BootTimeRecordsFromFileLenient returns every record of the jsonl file which
+// parses, along with the lines which do not, so that a corrupted line does not
+// discard the whole file.
+func BootTimeRecordsFromFileLenient(file *os.File) ([]*BootTimeRecord, []LineError, error) {
 	records := []*BootTimeRecord{}
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
+	skipped, err := ForEachBootTimeRecordLenient(file, func(rec *BootTimeRecord) error {
+		records = append(records, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return records, skipped, nil
+}
 
-		var rec BootTimeRecord
-		if err := UnmarshalBootTimeRecord(line, &rec); err != nil {
-			return nil, fmt.Errorf("unmarshalling boot time record from line: %w", err)
+// unmarshalLine decodes the record of a jsonl line, the error wrapping
+// ErrTruncatedRecord for the last line of the file.
+func unmarshalLine(line []byte, last bool) (*BootTimeRecord, error) {
+	var rec BootTimeRecord
+	if err := UnmarshalBootTimeRecord(line, &rec); err != nil {
+		if last {
+			return nil, fmt.Errorf("%w: %w", ErrTruncatedRecord, err)
 		}
-		records = append(records, &rec)
+		return nil, fmt.Errorf("unmarshalling boot time record from line: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return &rec, nil
+}
+
+// forEachLine calls fn with every non-blank jsonl line of r, cleaned up as
+// described by ForEachBootTimeRecord, and its number n in r, starting at 1,
+// blank lines included, as shown by an editor. last is set for a line without
+// newline at the end of r. fn returning SkipRemainingRecords stops the
+// iteration without error.
+func forEachLine(r io.Reader, fn func(n int, line []byte, last bool) error) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		first := n == 1
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+
+		if first {
+			line = bytes.TrimPrefix(line, utf8BOM)
+		}
+		line = bytes.TrimSpace(line)
+		// Records dumped from a JSON array keep their separating comma.
+		line = bytes.TrimSpace(bytes.TrimSuffix(line, []byte(",")))
+		if len(line) == 0 {
+			if readErr != nil {
+				return nil
+			}
+			continue
+		}
+
+		if err := fn(n, line, readErr != nil); err != nil {
+			if errors.Is(err, SkipRemainingRecords) {
+				return nil
+			}
+			return err
+		}
+
+		if readErr != nil {
+			return nil
+		}
 	}
+}
 
-	return records, nil
+// Metadata describes the boot a record was collected from, to correlate boot
+// times with the configuration of the host.
+type Metadata struct {
+	// KernelCmdline is the command line of the booted kernel.
+	KernelCmdline string `json:"kernel_cmdline,omitempty"`
+	// MachineID is the machine-id(5) of the host.
+	MachineID string `json:"machine_id,omitempty"`
+	// AnalyzeScope is the scope of the systemd_analyze values, when it is not
+	// the system service manager, such as "user" for the user session.
+	AnalyzeScope string `json:"analyze_scope,omitempty"`
+	// BIOSVersion is the version of the firmware, which the firmware stage
+	// depends on.
+	BIOSVersion string `json:"bios_version,omitempty"`
+	// BIOSDate is the release date of the firmware.
+	BIOSDate string `json:"bios_date,omitempty"`
+	// Timestamp is when the record was collected.
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	// Hostname is the name of the host.
+	Hostname string `json:"hostname,omitempty"`
+	// KernelVersion is the release of the booted kernel, such as
+	// "6.8.0-45-generic".
+	KernelVersion string `json:"kernel_version,omitempty"`
+}
+
+// Keys of the JSON encoding of a record, next to the stages.
+const (
+	rawKey      string = "raw"
+	metadataKey string = "metadata"
+)
+
+// MarshalJSON encodes the record as a jsonl line: an object of stages, each
+// being an object of methods with Duration values, and the raw timestamps if
+// any. A collapsed record has a Duration value per stage instead.
+func (r BootTimeRecord) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(r.Values)+1)
+	collapsed := r.isCollapsed()
+	for stage, methods := range r.Values {
+		if collapsed {
+			out[string(stage)] = Duration(methods[RetrievalMethodCollapsed])
+			continue
+		}
+
+		durations := make(map[RetrievalMethod]Duration, len(methods))
+		for method, d := range methods {
+			durations[method] = Duration(d)
+		}
+		out[string(stage)] = durations
+	}
+
+	if len(r.Raw) > 0 {
+		raw := make(map[RetrievalMethod]map[string]Duration, len(r.Raw))
+		for method, timestamps := range r.Raw {
+			raw[method] = make(map[string]Duration, len(timestamps))
+			for name, ts := range timestamps {
+				raw[method][name] = Duration(ts)
+			}
+		}
+		out[rawKey] = raw
+	}
+
+	if r.Metadata != nil {
+		out[metadataKey] = r.Metadata
+	}
+
+	return json.Marshal(out)
+}
+
+func (r *BootTimeRecord) UnmarshalJSON(data []byte) error {
+	return UnmarshalBootTimeRecord(data, r)
 }
 
+// UnmarshalBootTimeRecord decodes the jsonl line into out. The usual shape of
+// the records is decoded without reflection, which allocates much less when
+// reading large files, and the other lines by encoding/json.
 func UnmarshalBootTimeRecord(line []byte, out *BootTimeRecord) error {
-	var raw map[BootTimeStage]map[RetrievalMethod]time.Duration
-	if err := json.Unmarshal(line, &raw); err != nil {
+	if decodeBootTimeRecord(line, out) {
+		return nil
+	}
+
+	return unmarshalBootTimeRecordGeneric(line, out)
+}
+
+func unmarshalBootTimeRecordGeneric(line []byte, out *BootTimeRecord) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
 		return fmt.Errorf("unmarshalling from json: %w", err)
 	}
 
 	out.Values = make(map[BootTimeStage]map[RetrievalMethod]time.Duration)
+	out.Raw = nil
+	out.Metadata = nil
+
+	for key, value := range fields {
+		if key == metadataKey {
+			out.Metadata = &Metadata{}
+			if err := json.Unmarshal(value, out.Metadata); err != nil {
+				return fmt.Errorf("unmarshalling metadata from json: %w", err)
+			}
+			continue
+		}
+
+		if key == rawKey {
+			raw, err := unmarshalRaw(value)
+			if err != nil {
+				return err
+			}
+			out.Raw = raw
+			continue
+		}
+
+		stage := BootTimeStage(key)
+
+		// Collapsed records have a single duration per stage.
+		if v := bytes.TrimSpace(value); len(v) > 0 && v[0] != '{' {
+			var d Duration
+			if err := json.Unmarshal(value, &d); err != nil {
+				return fmt.Errorf("unmarshalling stage %s from json: %w", key, err)
+			}
+			out.Values[stage] = map[RetrievalMethod]time.Duration{RetrievalMethodCollapsed: time.Duration(d)}
+			continue
+		}
 
-	for bootTimeStage, methods := range raw {
-		out.Values[bootTimeStage] = make(map[RetrievalMethod]time.Duration)
+		var methods map[RetrievalMethod]Duration
+		if err := json.Unmarshal(value, &methods); err != nil {
+			return fmt.Errorf("unmarshalling stage %s from json: %w", key, err)
+		}
 
-		for retrievalMethod, duration := range methods {
-			out.Values[bootTimeStage][retrievalMethod] = duration
+		out.Values[stage] = make(map[RetrievalMethod]time.Duration)
+		for method, d := range methods {
+			out.Values[stage][method] = time.Duration(d)
 		}
 	}
 
 	return nil
 }
+
+// unmarshalRaw decodes the raw timestamps of a record.
+func unmarshalRaw(value []byte) (map[RetrievalMethod]map[string]time.Duration, error) {
+	var raw map[RetrievalMethod]map[string]Duration
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshalling raw timestamps from json: %w", err)
+	}
+
+	out := make(map[RetrievalMethod]map[string]time.Duration, len(raw))
+	for method, timestamps := range raw {
+		out[method] = make(map[string]time.Duration, len(timestamps))
+		for name, ts := range timestamps {
+			out[method][name] = time.Duration(ts)
+		}
+	}
+
+	return out, nil
+}
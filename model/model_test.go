@@ -0,0 +1,564 @@
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeRecordEqualWithin(t *testing.T) {
+	reference := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+				RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		other     *BootTimeRecord
+		tolerance time.Duration
+		expected  bool
+	}{
+		"identical records are equal": {
+			other: &BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageFirmware: {
+						RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+						RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					},
+				},
+			},
+			expected: true,
+		},
+		"jitter within tolerance is equal": {
+			other: &BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageFirmware: {
+						RetrievalMethodACPIFPDT:       1897*time.Millisecond + 400*time.Microsecond,
+						RetrievalMethodSystemdAnalyze: 1900*time.Millisecond - 400*time.Microsecond,
+					},
+				},
+			},
+			tolerance: time.Millisecond,
+			expected:  true,
+		},
+		"difference above tolerance is not equal": {
+			other: &BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageFirmware: {
+						RetrievalMethodACPIFPDT:       1899 * time.Millisecond,
+						RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					},
+				},
+			},
+			tolerance: time.Millisecond,
+			expected:  false,
+		},
+		"missing cell is not equal": {
+			other: &BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageFirmware: {
+						RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+					},
+				},
+			},
+			tolerance: time.Second,
+			expected:  false,
+		},
+		"extra cell is not equal": {
+			other: &BootTimeRecord{
+				Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+					BootTimeStageFirmware: {
+						RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+						RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					},
+					BootTimeStageLoader: {
+						RetrievalMethodACPIFPDT: 0,
+					},
+				},
+			},
+			tolerance: time.Second,
+			expected:  false,
+		},
+		"nil record is not equal": {
+			other:    nil,
+			expected: false,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, reference.EqualWithin(tc.other, tc.tolerance), name)
+		})
+	}
+}
+
+func TestBootTimeRecordGet(t *testing.T) {
+	r := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {RetrievalMethodACPIFPDT: 1897 * time.Millisecond},
+			BootTimeStageKernel:   {RetrievalMethodSystemdDBUS: 0},
+		},
+	}
+
+	tcs := map[string]struct {
+		record   BootTimeRecord
+		stage    BootTimeStage
+		method   RetrievalMethod
+		expected time.Duration
+		ok       bool
+	}{
+		"present cell":         {record: r, stage: BootTimeStageFirmware, method: RetrievalMethodACPIFPDT, expected: 1897 * time.Millisecond, ok: true},
+		"zero cell is present": {record: r, stage: BootTimeStageKernel, method: RetrievalMethodSystemdDBUS, ok: true},
+		"missing method":       {record: r, stage: BootTimeStageFirmware, method: RetrievalMethodEFIVar},
+		"missing stage":        {record: r, stage: BootTimeStageTotal, method: RetrievalMethodACPIFPDT},
+		"empty record":         {stage: BootTimeStageFirmware, method: RetrievalMethodACPIFPDT},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			d, ok := tc.record.Get(tc.stage, tc.method)
+			assert.Equal(t, tc.expected, d)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestBootTimeRecordSet(t *testing.T) {
+	var r BootTimeRecord
+	r.Set(BootTimeStageFirmware, RetrievalMethodACPIFPDT, 1897*time.Millisecond)
+	r.Set(BootTimeStageFirmware, RetrievalMethodEFIVar, 1702*time.Millisecond)
+	r.Set(BootTimeStageTotal, RetrievalMethodSystemdDBUS, 19*time.Second)
+	r.Set(BootTimeStageFirmware, RetrievalMethodACPIFPDT, 1900*time.Millisecond)
+
+	assert.Equal(t, map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodACPIFPDT: 1900 * time.Millisecond,
+			RetrievalMethodEFIVar:   1702 * time.Millisecond,
+		},
+		BootTimeStageTotal: {
+			RetrievalMethodSystemdDBUS: 19 * time.Second,
+		},
+	}, r.Values)
+}
+
+func TestBootTimeRecordDropStaleSources(t *testing.T) {
+	r := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+				RetrievalMethodEFIVar:   1702 * time.Millisecond,
+			},
+			BootTimeStageKernel: {
+				"custom": time.Second,
+			},
+		},
+	}
+	r.MarkStale(RetrievalMethodEFIVar)
+	r.MarkStale("custom")
+
+	assert.Equal(t, []RetrievalMethod{"custom", RetrievalMethodEFIVar}, r.DropStaleSources())
+	assert.Equal(t, map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+		},
+	}, r.Values)
+	assert.Empty(t, r.DropStaleSources())
+}
+
+func TestBootTimeRecordRemoveMethod(t *testing.T) {
+	r := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:    1897 * time.Millisecond,
+				RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+			},
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdDBUS: 19656 * time.Millisecond,
+			},
+		},
+		Raw: map[RetrievalMethod]map[string]time.Duration{
+			RetrievalMethodSystemdDBUS: {"finish": 4 * time.Second},
+		},
+	}
+
+	r.RemoveMethod(RetrievalMethodSystemdDBUS)
+
+	assert.Equal(t, map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+		},
+	}, r.Values)
+	assert.Nil(t, r.Raw)
+}
+
+func TestBootTimeRecordDeltaFrom(t *testing.T) {
+	ref := &BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+				RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+			},
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdAnalyze: 19 * time.Second,
+			},
+		},
+	}
+
+	r := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:    1697 * time.Millisecond,
+				RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+			},
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdAnalyze: 21 * time.Second,
+			},
+		},
+	}
+
+	assert.Equal(t, map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodACPIFPDT: -200 * time.Millisecond,
+		},
+		BootTimeStageTotal: {
+			RetrievalMethodSystemdAnalyze: 2 * time.Second,
+		},
+	}, r.DeltaFrom(ref).Values)
+}
+
+func benchmarkRecordsFile(b *testing.B, n int) *os.File {
+	b.Helper()
+
+	line := `{"firmware":{"efi_var":1702811000,"systemd_analyze":1708000000,"systemd_dbus":1708265000},"initrd":{"systemd_analyze":200000000,"systemd_dbus":200300000},"kernel":{"systemd_analyze":641000000,"systemd_dbus":641348000},"loader":{"efi_var":151520000,"systemd_analyze":267000000,"systemd_dbus":267711000},"total":{"systemd_analyze":4605000000,"systemd_dbus":4605013000},"userspace":{"systemd_analyze":1787000000,"systemd_dbus":1787389000}}` + "\n"
+
+	file, err := os.Create(filepath.Join(b.TempDir(), "records.jsonl"))
+	require.NoError(b, err)
+	b.Cleanup(func() { file.Close() })
+
+	_, err = file.WriteString(strings.Repeat(line, n))
+	require.NoError(b, err)
+
+	return file
+}
+
+// reportLiveHeap reports the heap still in use while v is reachable, which is
+// the memory retained by the aggregation rather than the total allocated.
+func reportLiveHeap(b *testing.B, v any) {
+	b.Helper()
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	runtime.KeepAlive(v)
+
+	b.ReportMetric(float64(stats.HeapAlloc), "live-B")
+}
+
+// BenchmarkBootTimeRecordsFromFile retains every record before averaging.
+func BenchmarkBootTimeRecordsFromFile(b *testing.B) {
+	file := benchmarkRecordsFile(b, 10000)
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_, err := file.Seek(0, io.SeekStart)
+		require.NoError(b, err)
+
+		records, err := BootTimeRecordsFromFile(file)
+		require.NoError(b, err)
+
+		a := NewBootTimeAccumulator()
+		for _, r := range records {
+			a.Add(r)
+		}
+		_ = a.Average()
+
+		b.StopTimer()
+		reportLiveHeap(b, records)
+		b.StartTimer()
+	}
+}
+
+// BenchmarkAccumulateFromReader streams the records into the accumulator.
+func BenchmarkAccumulateFromReader(b *testing.B) {
+	file := benchmarkRecordsFile(b, 10000)
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_, err := file.Seek(0, io.SeekStart)
+		require.NoError(b, err)
+
+		a, err := AccumulateFromReader(file)
+		require.NoError(b, err)
+		_ = a.Average()
+
+		b.StopTimer()
+		reportLiveHeap(b, a)
+		b.StartTimer()
+	}
+}
+
+// methodsExcept returns every retrieval method but the kept ones.
+func methodsExcept(kept ...RetrievalMethod) []RetrievalMethod {
+	var excluded []RetrievalMethod
+	for _, m := range allRetrievalMethods {
+		if !slices.Contains(kept, m) {
+			excluded = append(excluded, m)
+		}
+	}
+	return excluded
+}
+
+func TestBootTimeRecordToTableWithSelection(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:    1897 * time.Millisecond,
+				RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+			},
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdDBUS: 19656 * time.Millisecond,
+			},
+		},
+	}
+
+	selection := Selection{
+		ExcludedStages: []BootTimeStage{
+			BootTimeStageLoader,
+			BootTimeStageKernel,
+			BootTimeStageInitrd,
+			BootTimeStageUserspace,
+			BootTimeStageTotal,
+		},
+		ExcludedMethods: methodsExcept(RetrievalMethodACPIFPDT),
+	}
+
+	assert.Equal(t, [][]string{
+		{"Stage", "acpi_fpdt"},
+		{"firmware", "1.897s"},
+	}, btr.ToTable(WithSelection(selection)))
+
+	selection.Apply(&btr)
+	assert.Equal(t, map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+		},
+	}, btr.Values)
+}
+
+func TestBootTimeRecordToTableWithUniformUnits(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+				RetrievalMethodSystemdAnalyze: 998 * time.Millisecond,
+			},
+			BootTimeStageKernel: {
+				RetrievalMethodSystemdAnalyze: 718 * time.Millisecond,
+				RetrievalMethodSystemdDBUS:    718400 * time.Microsecond,
+			},
+		},
+	}
+
+	selection := Selection{
+		ExcludedStages: []BootTimeStage{
+			BootTimeStageLoader,
+			BootTimeStageInitrd,
+			BootTimeStageUserspace,
+			BootTimeStageTotal,
+		},
+		ExcludedMethods: methodsExcept(
+			RetrievalMethodACPIFPDT,
+			RetrievalMethodSystemdDBUS,
+			RetrievalMethodSystemdAnalyze,
+		),
+	}
+
+	assert.Equal(t, [][]string{
+		{"Stage", "acpi_fpdt", "systemd_dbus", "systemd_analyze"},
+		{"firmware", "1.90s", "", "1.00s"},
+		{"kernel", "", "718ms", "718ms"},
+	}, btr.ToTable(WithSelection(selection), WithUniformUnits()))
+}
+
+func TestBootTimeRecordToTableWithRange(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {
+				RetrievalMethodACPIFPDT:    1800 * time.Millisecond,
+				RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+			},
+		},
+	}
+	lowest := &BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {RetrievalMethodACPIFPDT: 1700 * time.Millisecond},
+		},
+	}
+	highest := &BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageFirmware: {RetrievalMethodACPIFPDT: 1950 * time.Millisecond},
+		},
+	}
+
+	selection := Selection{
+		ExcludedStages: []BootTimeStage{
+			BootTimeStageLoader,
+			BootTimeStageKernel,
+			BootTimeStageInitrd,
+			BootTimeStageUserspace,
+			BootTimeStageTotal,
+		},
+		ExcludedMethods: methodsExcept(RetrievalMethodACPIFPDT, RetrievalMethodSystemdDBUS),
+	}
+
+	assert.Equal(t, [][]string{
+		{"Stage", "acpi_fpdt", "systemd_dbus"},
+		{"firmware", "1.8s (1.7s–1.95s)", "1.9s"},
+	}, btr.ToTable(WithSelection(selection), WithRange(lowest, highest)))
+
+	assert.Equal(t, [][]string{
+		{"Stage", "acpi_fpdt", "systemd_dbus"},
+		{"firmware", "1.80s (1.70s–1.95s)", "1.90s"},
+	}, btr.ToTable(WithSelection(selection), WithUniformUnits(), WithRange(lowest, highest)))
+}
+
+func TestBootTimeRecordToTriples(t *testing.T) {
+	btr := BootTimeRecord{
+		Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+			BootTimeStageTotal: {
+				RetrievalMethodSystemdAnalyze: 19656 * time.Millisecond,
+			},
+			BootTimeStageFirmware: {
+				RetrievalMethodSystemdDBUS: 1900 * time.Millisecond,
+				RetrievalMethodACPIFPDT:    1897 * time.Millisecond,
+			},
+		},
+	}
+
+	assert.Equal(t, [][3]any{
+		{"firmware", "acpi_fpdt", 1.897},
+		{"firmware", "systemd_dbus", 1.9},
+		{"total", "systemd_analyze", 19.656},
+	}, btr.ToTriples())
+
+	data, err := json.Marshal(btr.ToTriples())
+	require.NoError(t, err)
+	assert.Equal(t, `[["firmware","acpi_fpdt",1.897],["firmware","systemd_dbus",1.9],["total","systemd_analyze",19.656]]`, string(data))
+}
+
+func TestForEachBootTimeRecord(t *testing.T) {
+	longLine := `{"firmware":{"acpi_fpdt":1897000000}` + strings.Repeat(" ", 100*1024) + `}`
+
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, records []*BootTimeRecord, err error, name string)
+	}{
+		"read line longer than 64KB": {
+			input: longLine + "\n" + `{"kernel":{"systemd_dbus":718000000}}` + "\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.Len(t, records, 2, name)
+				assert.Equal(t, 1897*time.Millisecond, records[0].Values[BootTimeStageFirmware][RetrievalMethodACPIFPDT], name)
+				assert.Equal(t, 718*time.Millisecond, records[1].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+			},
+		},
+		"read last line without newline": {
+			input: `{"kernel":{"systemd_dbus":718000000}}` + "\r\n" + `{"kernel":{"systemd_dbus":719000000}}`,
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.Len(t, records, 2, name)
+				assert.Equal(t, 719*time.Millisecond, records[1].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+			},
+		},
+		"read lines with BOM, CRLF and blank lines": {
+			input: "\xef\xbb\xbf" + `{"kernel":{"systemd_dbus":718000000}}` + "\r\n\r\n  \n" + `{"kernel":{"systemd_dbus":719000000}}` + "\r\n\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.Len(t, records, 2, name)
+				assert.Equal(t, 718*time.Millisecond, records[0].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+				assert.Equal(t, 719*time.Millisecond, records[1].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+			},
+		},
+		"read lines with trailing commas": {
+			input: `{"kernel":{"systemd_dbus":718000000}},` + "\n" + `{"kernel":{"systemd_dbus":719000000}} , ` + "\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.Len(t, records, 2, name)
+				assert.Equal(t, 719*time.Millisecond, records[1].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+			},
+		},
+		"read only blank lines": {
+			input: "\n\r\n\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Empty(t, records, name)
+			},
+		},
+		"read invalid line returns error": {
+			input: "{\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.Error(t, err, name)
+				assert.NotErrorIs(t, err, ErrTruncatedRecord, name)
+			},
+		},
+		"read partial last line returns truncation error": {
+			input: `{"kernel":{"systemd_dbus":718000000}}` + "\n" + `{"kernel":{"systemd_d`,
+			validate: func(t *testing.T, records []*BootTimeRecord, err error, name string) {
+				require.ErrorIs(t, err, ErrTruncatedRecord, name)
+				require.Len(t, records, 1, name)
+				assert.Equal(t, 718*time.Millisecond, records[0].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var records []*BootTimeRecord
+			err := ForEachBootTimeRecord(strings.NewReader(tc.input), func(r *BootTimeRecord) error {
+				records = append(records, r)
+				return nil
+			})
+			tc.validate(t, records, err, name)
+		})
+	}
+}
+
+func TestBootTimeRecordsFromFileLenient(t *testing.T) {
+	input := `{"kernel":{"systemd_dbus":"718ms"}}
+
+{"kernel":{"systemd_dbus":
+{"kernel":{"systemd_dbus":"719ms"}}
+{"kernel":{"systemd_d`
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(input), 0o600))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, skipped, err := BootTimeRecordsFromFileLenient(file)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 719*time.Millisecond, records[1].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+
+	require.Len(t, skipped, 2)
+	assert.Equal(t, 3, skipped[0].Number)
+	assert.NotErrorIs(t, skipped[0], ErrTruncatedRecord)
+	assert.ErrorContains(t, skipped[0], "line 3: unmarshalling boot time record from line")
+	assert.Equal(t, 5, skipped[1].Number)
+	assert.ErrorIs(t, skipped[1], ErrTruncatedRecord)
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// CellPercentiles are the percentiles of the durations of a single
+// stage/method cell.
+type CellPercentiles struct {
+	// Count is the number of durations. With fewer durations than needed to
+	// tell the tail apart, the high percentiles are interpolated between the
+	// few durations available, or equal the single one.
+	Count int
+	// Values are the percentiles, in the order of BootTimeStatistics.Percentiles.
+	Values []time.Duration
+}
+
+// BootTimeStatistics are the percentiles of every stage/method cell of a set of
+// records, which unlike the average show the tail of the durations across a
+// fleet.
+type BootTimeStatistics struct {
+	Records     int
+	Percentiles []float64
+	Stages      map[BootTimeStage]map[RetrievalMethod]CellPercentiles
+}
+
+// NewBootTimeStatistics returns the percentiles pcts, between 0 and 100, of
+// every stage/method cell of the records, linearly interpolated between the
+// closest ranks.
+func NewBootTimeStatistics(records []*BootTimeRecord, pcts []float64) (*BootTimeStatistics, error) {
+	btra := NewBootTimeAccumulator(WithRetainedSamples())
+	for _, r := range records {
+		btra.Add(r)
+	}
+	return btra.Percentiles(pcts)
+}
+
+// Percentiles returns the percentiles pcts, between 0 and 100, of every
+// accumulated cell, as with NewBootTimeStatistics. It panics if the
+// accumulator does not retain its samples.
+func (a *BootTimeAccumulator) Percentiles(pcts []float64) (*BootTimeStatistics, error) {
+	a.mustRetainSamples("Percentiles")
+	for _, p := range pcts {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile %v out of range, expected between 0 and 100", p)
+		}
+	}
+
+	stats := &BootTimeStatistics{
+		Records:     a.records,
+		Percentiles: pcts,
+		Stages:      make(map[BootTimeStage]map[RetrievalMethod]CellPercentiles, len(a.cells)),
+	}
+	for stage, methods := range a.cells {
+		stats.Stages[stage] = make(map[RetrievalMethod]CellPercentiles, len(methods))
+		for method, c := range methods {
+			sorted := slices.Clone(c.samples)
+			slices.Sort(sorted)
+			cell := CellPercentiles{Count: c.count, Values: make([]time.Duration, len(pcts))}
+			for i, p := range pcts {
+				cell.Values[i] = percentile(sorted, p)
+			}
+			stats.Stages[stage][method] = cell
+		}
+	}
+
+	return stats, nil
+}
+
+// PercentileName returns the name of the percentile p, such as "p99" or
+// "p99.9".
+func PercentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// MarshalJSON encodes the statistics as an object of stages, each being an
+// object of methods with the count and the named percentiles of the cell.
+func (s BootTimeStatistics) MarshalJSON() ([]byte, error) {
+	stages := make(map[BootTimeStage]map[RetrievalMethod]map[string]any, len(s.Stages))
+	for stage, methods := range s.Stages {
+		stages[stage] = make(map[RetrievalMethod]map[string]any, len(methods))
+		for method, cell := range methods {
+			out := map[string]any{"count": cell.Count}
+			for i, p := range s.Percentiles {
+				out[PercentileName(p)] = Duration(cell.Values[i])
+			}
+			stages[stage][method] = out
+		}
+	}
+
+	return json.Marshal(struct {
+		Records int                                                  `json:"records"`
+		Stages  map[BootTimeStage]map[RetrievalMethod]map[string]any `json:"stages"`
+	}{s.Records, stages})
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func kernelRecords(durations ...time.Duration) []*BootTimeRecord {
+	records := make([]*BootTimeRecord, 0, len(durations))
+	for _, d := range durations {
+		records = append(records, &BootTimeRecord{
+			Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {RetrievalMethodSystemdDBUS: d},
+			},
+		})
+	}
+	return records
+}
+
+func TestNewBootTimeStatistics(t *testing.T) {
+	tcs := map[string]struct {
+		records  []*BootTimeRecord
+		pcts     []float64
+		validate func(t *testing.T, stats *BootTimeStatistics, err error)
+	}{
+		"interpolated between the closest ranks": {
+			records: kernelRecords(400*time.Millisecond, 100*time.Millisecond, 300*time.Millisecond, 200*time.Millisecond),
+			pcts:    []float64{50, 90, 99},
+			validate: func(t *testing.T, stats *BootTimeStatistics, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 4, stats.Records)
+				assert.Equal(t, CellPercentiles{
+					Count:  4,
+					Values: []time.Duration{250 * time.Millisecond, 370 * time.Millisecond, 397 * time.Millisecond},
+				}, stats.Stages[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+			},
+		},
+		"single record": {
+			records: kernelRecords(time.Second),
+			pcts:    []float64{50, 90, 99},
+			validate: func(t *testing.T, stats *BootTimeStatistics, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, CellPercentiles{
+					Count:  1,
+					Values: []time.Duration{time.Second, time.Second, time.Second},
+				}, stats.Stages[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+			},
+		},
+		"two records": {
+			records: kernelRecords(time.Second, 2*time.Second),
+			pcts:    []float64{0, 50, 100},
+			validate: func(t *testing.T, stats *BootTimeStatistics, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second},
+					stats.Stages[BootTimeStageKernel][RetrievalMethodSystemdDBUS].Values)
+			},
+		},
+		"no records": {
+			pcts: []float64{50},
+			validate: func(t *testing.T, stats *BootTimeStatistics, err error) {
+				require.NoError(t, err)
+				assert.Zero(t, stats.Records)
+				assert.Empty(t, stats.Stages)
+			},
+		},
+		"out of range percentile returns error": {
+			records: kernelRecords(time.Second),
+			pcts:    []float64{50, 101},
+			validate: func(t *testing.T, stats *BootTimeStatistics, err error) {
+				require.ErrorContains(t, err, "percentile 101 out of range")
+				assert.Nil(t, stats)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			stats, err := NewBootTimeStatistics(tc.records, tc.pcts)
+			tc.validate(t, stats, err)
+		})
+	}
+}
+
+func TestBootTimeAccumulatorPercentilesWithoutRetainedSamples(t *testing.T) {
+	a := NewBootTimeAccumulator()
+	a.Add(&BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageKernel: {RetrievalMethodSystemdDBUS: 718 * time.Millisecond},
+	}})
+
+	assert.Panics(t, func() { _, _ = a.Percentiles([]float64{50}) })
+}
+
+func TestBootTimeStatisticsMarshalJSON(t *testing.T) {
+	stats, err := NewBootTimeStatistics(kernelRecords(time.Second, 3*time.Second), []float64{50, 99.9})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(stats)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"records":2,"stages":{"kernel":{"systemd_dbus":{"count":2,"p50":"2s","p99.9":"2.998s"}}}}`, string(data))
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PrometheusMetricName is the name of the gauge of the stage durations, in
+// seconds, labelled with the stage and the method.
+const PrometheusMetricName string = "boottime_stage_seconds"
+
+// WritePrometheus writes the record to w in the Prometheus text exposition
+// format, as read by the textfile collector of node_exporter: a sample of
+// PrometheusMetricName per stage/method cell, in canonical order. The cells
+// without value are left out rather than written as zero.
+func (r BootTimeRecord) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# HELP " + PrometheusMetricName + " Duration of the boot stage, as measured by the retrieval method.\n")
+	b.WriteString("# TYPE " + PrometheusMetricName + " gauge\n")
+
+	for _, stage := range allBootTimeStages {
+		for _, method := range recordMethods {
+			d, ok := r.Values[stage][method]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "%s{stage=%q,method=%q} %s\n", PrometheusMetricName, stage, method, formatSeconds(d))
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing prometheus metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeRecordWritePrometheus(t *testing.T) {
+	tcs := map[string]struct {
+		record   BootTimeRecord
+		expected string
+	}{
+		"cells in canonical order": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {RetrievalMethodSystemdDBUS: 718 * time.Millisecond},
+				BootTimeStageFirmware: {
+					RetrievalMethodSystemdAnalyze: 1900 * time.Millisecond,
+					RetrievalMethodACPIFPDT:       1897 * time.Millisecond,
+				},
+			}},
+			expected: `# HELP boottime_stage_seconds Duration of the boot stage, as measured by the retrieval method.
+# TYPE boottime_stage_seconds gauge
+boottime_stage_seconds{stage="firmware",method="acpi_fpdt"} 1.897
+boottime_stage_seconds{stage="firmware",method="systemd_analyze"} 1.9
+boottime_stage_seconds{stage="kernel",method="systemd_dbus"} 0.718
+`,
+		},
+		"collapsed record": {
+			record: BootTimeRecord{Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageTotal: {RetrievalMethodCollapsed: 4605 * time.Millisecond},
+			}},
+			expected: `# HELP boottime_stage_seconds Duration of the boot stage, as measured by the retrieval method.
+# TYPE boottime_stage_seconds gauge
+boottime_stage_seconds{stage="total",method="collapsed"} 4.605
+`,
+		},
+		"empty record only has the headers": {
+			record: BootTimeRecord{},
+			expected: `# HELP boottime_stage_seconds Duration of the boot stage, as measured by the retrieval method.
+# TYPE boottime_stage_seconds gauge
+`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, tc.record.WritePrometheus(&buf))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
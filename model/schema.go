@@ -0,0 +1,90 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Schema is the shape of the stages of an encoded record.
+type Schema string
+
+const (
+	// SchemaNone is the schema of a record without any stage, compatible with
+	// both other schemas.
+	SchemaNone Schema = ""
+	// SchemaNested is the schema of a record with an object of methods per
+	// stage.
+	SchemaNested Schema = "nested"
+	// SchemaFlat is the schema of a collapsed record, with a single duration
+	// per stage.
+	SchemaFlat Schema = "flat"
+)
+
+// ErrMixedSchemas is returned when records of different schemas are read
+// together, such as the outputs of runs with and without collapsed methods
+// concatenated in a file.
+var ErrMixedSchemas = errors.New("records mix flat and nested schemas")
+
+// DetectSchema returns the schema of the jsonl line. A line mixing flat and
+// nested stages returns an error wrapping ErrMixedSchemas.
+func DetectSchema(line []byte) (Schema, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return SchemaNone, fmt.Errorf("unmarshalling from json: %w", err)
+	}
+
+	schema := SchemaNone
+	for key, value := range fields {
+		if key == rawKey || key == metadataKey {
+			continue
+		}
+
+		stageSchema := SchemaFlat
+		if v := bytes.TrimSpace(value); len(v) > 0 && v[0] == '{' {
+			stageSchema = SchemaNested
+		}
+
+		if schema != SchemaNone && schema != stageSchema {
+			return SchemaNone, fmt.Errorf("%w: stage %s is %s", ErrMixedSchemas, key, stageSchema)
+		}
+		schema = stageSchema
+	}
+
+	return schema, nil
+}
+
+// CheckSchemas reads the jsonl records from r and returns their schema, or an
+// error wrapping ErrMixedSchemas naming the first line whose schema differs
+// from the previous lines, blank lines included in the line numbers. Lines
+// failing to parse are ignored, and reported when reading the records.
+func CheckSchemas(r io.Reader) (Schema, error) {
+	schema := SchemaNone
+	var schemaLine int
+	err := forEachLine(r, func(n int, line []byte, _ bool) error {
+		lineSchema, err := DetectSchema(line)
+		if errors.Is(err, ErrMixedSchemas) {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if err != nil || lineSchema == SchemaNone {
+			return nil
+		}
+
+		if schema == SchemaNone {
+			schema, schemaLine = lineSchema, n
+			return nil
+		}
+
+		if lineSchema != schema {
+			return fmt.Errorf("%w: line %d is %s but line %d is %s", ErrMixedSchemas, n, lineSchema, schemaLine, schema)
+		}
+		return nil
+	})
+	if err != nil {
+		return SchemaNone, err
+	}
+
+	return schema, nil
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectSchema(t *testing.T) {
+	tcs := map[string]struct {
+		line     string
+		validate func(t *testing.T, schema Schema, err error)
+	}{
+		"nested record": {
+			line: `{"firmware":{"acpi_fpdt":"1.897s"},"metadata":{"machine_id":"a"}}`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, SchemaNested, schema)
+			},
+		},
+		"flat record": {
+			line: `{"firmware":"1.9s","kernel":"718ms","raw":{"systemd_dbus":{"KernelTimestamp":"0s"}}}`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, SchemaFlat, schema)
+			},
+		},
+		"record without stages": {
+			line: `{"metadata":{"machine_id":"a"}}`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, SchemaNone, schema)
+			},
+		},
+		"record mixing flat and nested stages": {
+			line: `{"firmware":"1.9s","kernel":{"systemd_dbus":"718ms"}}`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.ErrorIs(t, err, ErrMixedSchemas)
+			},
+		},
+		"invalid json": {
+			line: `{"firmware":`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrMixedSchemas)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			schema, err := DetectSchema([]byte(tc.line))
+			tc.validate(t, schema, err)
+		})
+	}
+}
+
+func TestCheckSchemas(t *testing.T) {
+	tcs := map[string]struct {
+		content  string
+		validate func(t *testing.T, schema Schema, err error)
+	}{
+		"consistent nested records": {
+			content: `{"metadata":{"machine_id":"a"}}
+{"firmware":{"acpi_fpdt":"2s"}}
+{"firmware":{"acpi_fpdt":"3s"}}
+`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, SchemaNested, schema)
+			},
+		},
+		"flat record after nested records": {
+			content: `{"firmware":{"acpi_fpdt":"2s"}}
+
+{"firmware":{"acpi_fpdt":"3s"}}
+{"firmware":"2.5s"}
+`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.ErrorIs(t, err, ErrMixedSchemas)
+				assert.ErrorContains(t, err, "line 4 is flat but line 1 is nested")
+			},
+		},
+		"unparseable lines are ignored": {
+			content: `{"firmware":"2s"}
+{"firmware":{"acpi_f`,
+			validate: func(t *testing.T, schema Schema, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, SchemaFlat, schema)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			schema, err := CheckSchemas(strings.NewReader(tc.content))
+			tc.validate(t, schema, err)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseBootTimeStage returns the boot time stage named s, or an error listing
+// the valid stages.
+func ParseBootTimeStage(s string) (BootTimeStage, error) {
+	stage := BootTimeStage(s)
+	if !slices.Contains(allBootTimeStages, stage) {
+		return "", fmt.Errorf("unknown boot time stage %q, expected one of %s", s, joinNames(allBootTimeStages))
+	}
+
+	return stage, nil
+}
+
+// ParseRetrievalMethod returns the retrieval method named s, or an error
+// listing the valid methods.
+func ParseRetrievalMethod(s string) (RetrievalMethod, error) {
+	method := RetrievalMethod(s)
+	if !slices.Contains(allRetrievalMethods, method) {
+		return "", fmt.Errorf("unknown retrieval method %q, expected one of %s", s, joinNames(allRetrievalMethods))
+	}
+
+	return method, nil
+}
+
+func joinNames[T ~string](names []T) string {
+	s := make([]string, len(names))
+	for i, n := range names {
+		s[i] = string(n)
+	}
+	return strings.Join(s, ", ")
+}
+
+// Selection is the set of stages and methods to report. The zero value
+// selects everything.
+type Selection struct {
+	ExcludedStages  []BootTimeStage
+	ExcludedMethods []RetrievalMethod
+}
+
+// Stages returns the selected stages in canonical order.
+func (s Selection) Stages() []BootTimeStage {
+	stages := make([]BootTimeStage, 0, len(allBootTimeStages))
+	for _, stage := range allBootTimeStages {
+		if !slices.Contains(s.ExcludedStages, stage) {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+// Methods returns the selected retrieval methods in canonical order.
+func (s Selection) Methods() []RetrievalMethod {
+	methods := make([]RetrievalMethod, 0, len(allRetrievalMethods))
+	for _, method := range allRetrievalMethods {
+		if !slices.Contains(s.ExcludedMethods, method) {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// Apply removes the excluded stages and methods from the record values.
+func (s Selection) Apply(r *BootTimeRecord) {
+	for _, stage := range s.ExcludedStages {
+		delete(r.Values, stage)
+	}
+
+	for stage, methods := range r.Values {
+		for _, method := range s.ExcludedMethods {
+			delete(methods, method)
+		}
+		if len(methods) == 0 {
+			delete(r.Values, stage)
+		}
+	}
+}
+
+// ApplyStatistics removes the excluded stages and methods from the percentiles
+// of the statistics.
+func (s Selection) ApplyStatistics(stats *BootTimeStatistics) {
+	for _, stage := range s.ExcludedStages {
+		delete(stats.Stages, stage)
+	}
+
+	for stage, methods := range stats.Stages {
+		for _, method := range s.ExcludedMethods {
+			delete(methods, method)
+		}
+		if len(methods) == 0 {
+			delete(stats.Stages, stage)
+		}
+	}
+}
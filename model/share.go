@@ -0,0 +1,41 @@
+package model
+
+// ShareOfTotal returns the share of the stage in the total duration reported
+// by the same method, between 0 and 1, or false if the method has no positive
+// total. Firmware timers and systemd may not use the same reference point, so a
+// stage can be reported longer than the total: its share is then clamped to 1
+// rather than exceeding it, and a negative duration has a share of 0.
+func (r BootTimeRecord) ShareOfTotal(stage BootTimeStage, method RetrievalMethod) (float64, bool) {
+	total, ok := r.Values[BootTimeStageTotal][method]
+	if !ok || total <= 0 {
+		return 0, false
+	}
+
+	d, ok := r.Values[stage][method]
+	if !ok {
+		return 0, false
+	}
+
+	return min(max(float64(d)/float64(total), 0), 1), true
+}
+
+// FirmwareExceedsTotal returns the methods, in canonical order, reporting a
+// firmware duration longer than their total, which are suspect of using another
+// reference point than the other stages.
+func (r BootTimeRecord) FirmwareExceedsTotal() []RetrievalMethod {
+	var suspect []RetrievalMethod
+	for _, m := range allRetrievalMethods {
+		if r.exceedsTotal(BootTimeStageFirmware, m) {
+			suspect = append(suspect, m)
+		}
+	}
+	return suspect
+}
+
+// exceedsTotal reports whether the stage reported by method is longer than the
+// total reported by the same method.
+func (r BootTimeRecord) exceedsTotal(stage BootTimeStage, method RetrievalMethod) bool {
+	total, ok := r.Values[BootTimeStageTotal][method]
+	d, exists := r.Values[stage][method]
+	return ok && exists && d > total
+}
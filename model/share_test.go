@@ -0,0 +1,99 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// inconsistentRecord has a systemd_dbus firmware duration measured from
+// another reference point than its total.
+var inconsistentRecord = BootTimeRecord{
+	Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+		BootTimeStageFirmware: {
+			RetrievalMethodSystemdDBUS:    25 * time.Second,
+			RetrievalMethodSystemdAnalyze: 2 * time.Second,
+			RetrievalMethodACPIFPDT:       2 * time.Second,
+		},
+		BootTimeStageKernel: {
+			RetrievalMethodSystemdDBUS:    -time.Second,
+			RetrievalMethodSystemdAnalyze: 500 * time.Millisecond,
+		},
+		BootTimeStageTotal: {
+			RetrievalMethodSystemdDBUS:    10 * time.Second,
+			RetrievalMethodSystemdAnalyze: 10 * time.Second,
+		},
+	},
+}
+
+func TestBootTimeRecordShareOfTotal(t *testing.T) {
+	tcs := map[string]struct {
+		stage    BootTimeStage
+		method   RetrievalMethod
+		expected float64
+		ok       bool
+	}{
+		"consistent stage": {
+			stage:    BootTimeStageFirmware,
+			method:   RetrievalMethodSystemdAnalyze,
+			expected: 0.2,
+			ok:       true,
+		},
+		"firmware longer than total is clamped": {
+			stage:    BootTimeStageFirmware,
+			method:   RetrievalMethodSystemdDBUS,
+			expected: 1,
+			ok:       true,
+		},
+		"negative stage is clamped": {
+			stage:    BootTimeStageKernel,
+			method:   RetrievalMethodSystemdDBUS,
+			expected: 0,
+			ok:       true,
+		},
+		"method without total": {
+			stage:  BootTimeStageFirmware,
+			method: RetrievalMethodACPIFPDT,
+		},
+		"missing stage": {
+			stage:  BootTimeStageLoader,
+			method: RetrievalMethodSystemdAnalyze,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			share, ok := inconsistentRecord.ShareOfTotal(tc.stage, tc.method)
+			assert.Equal(t, tc.ok, ok, name)
+			assert.InDelta(t, tc.expected, share, 1e-9, name)
+		})
+	}
+}
+
+func TestBootTimeRecordFirmwareExceedsTotal(t *testing.T) {
+	assert.Equal(t, []RetrievalMethod{RetrievalMethodSystemdDBUS}, inconsistentRecord.FirmwareExceedsTotal())
+}
+
+func TestBootTimeRecordToTableWithShares(t *testing.T) {
+	selection := Selection{
+		ExcludedStages: []BootTimeStage{
+			BootTimeStageLoader,
+			BootTimeStageInitrd,
+			BootTimeStageUserspace,
+		},
+		ExcludedMethods: methodsExcept(
+			RetrievalMethodACPIFPDT,
+			RetrievalMethodSystemdDBUS,
+			RetrievalMethodSystemdAnalyze,
+		),
+	}
+
+	assert.Equal(t, [][]string{
+		{"Stage", "acpi_fpdt", "systemd_dbus", "systemd_analyze"},
+		{"firmware", "2s", "25s [100%, suspect]", "2s [20%]"},
+		{"kernel", "", "-1s [0%]", "500ms [5%]"},
+		{"total", "", "10s", "10s"},
+	}, inconsistentRecord.ToTable(WithSelection(selection), WithShares()))
+}
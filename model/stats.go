@@ -0,0 +1,143 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Stats are the statistics of the durations of a single stage/method cell.
+type Stats struct {
+	Count  int           `json:"count"`
+	Mean   time.Duration `json:"mean"`
+	Median time.Duration `json:"median"`
+	P99    time.Duration `json:"p99"`
+	// IQR is the interquartile range, Q3 - Q1, which unlike the standard
+	// deviation is not inflated by a few slow outliers.
+	IQR    time.Duration `json:"iqr"`
+	Min    time.Duration `json:"min"`
+	Max    time.Duration `json:"max"`
+	StdDev time.Duration `json:"stddev"`
+}
+
+// StatsSummary contains the statistics of every stage/method cell of a set of
+// records.
+type StatsSummary struct {
+	Records int                                         `json:"records"`
+	Stages  map[BootTimeStage]map[RetrievalMethod]Stats `json:"stages"`
+}
+
+// Summary returns the statistics of every accumulated cell. The median, p99 and
+// IQR are only computed when the accumulator retains its samples, and are zero
+// otherwise.
+func (a *BootTimeAccumulator) Summary() *StatsSummary {
+	summary := &StatsSummary{
+		Records: a.records,
+		Stages:  make(map[BootTimeStage]map[RetrievalMethod]Stats),
+	}
+
+	for stage, methods := range a.cells {
+		summary.Stages[stage] = make(map[RetrievalMethod]Stats)
+
+		for method, c := range methods {
+			stats := Stats{
+				Count:  c.count,
+				Mean:   c.sum / time.Duration(c.count),
+				Min:    c.min,
+				Max:    c.max,
+				StdDev: c.stdDev(),
+			}
+
+			if len(c.samples) > 0 {
+				sorted := slices.Clone(c.samples)
+				slices.Sort(sorted)
+				stats.Median = percentile(sorted, 50)
+				stats.P99 = percentile(sorted, 99)
+				stats.IQR = interquartileRange(sorted)
+			}
+
+			summary.Stages[stage][method] = stats
+		}
+	}
+
+	return summary
+}
+
+// Median returns a record with the median of every accumulated cell, which
+// unlike the average is not skewed by a few slow boots. It panics if the
+// accumulator does not retain its samples.
+func (a *BootTimeAccumulator) Median() *BootTimeRecord {
+	a.mustRetainSamples("Median")
+	return a.reduce(func(c *cellAccumulator) time.Duration {
+		sorted := slices.Clone(c.samples)
+		slices.Sort(sorted)
+		return percentile(sorted, 50)
+	})
+}
+
+// TrimmedAverage returns a record with the average of every accumulated cell
+// once the trim fraction of its shortest and longest durations are dropped, so
+// that a few anomalous boots, such as one running fsck, do not skew it. The
+// number of durations dropped at each end is rounded down, so a cell with
+// fewer than 1/trim durations is averaged whole: with a trim of 0.1, nothing is
+// dropped below 10 durations. At least one duration is always kept, a trim of
+// 0.5 or more leaving the median. It panics if the accumulator does not retain
+// its samples.
+func (a *BootTimeAccumulator) TrimmedAverage(trim float64) *BootTimeRecord {
+	a.mustRetainSamples("TrimmedAverage")
+	return a.reduce(func(c *cellAccumulator) time.Duration {
+		sorted := slices.Clone(c.samples)
+		slices.Sort(sorted)
+
+		n := min(int(max(trim, 0)*float64(len(sorted))), (len(sorted)-1)/2)
+		kept := sorted[n : len(sorted)-n]
+		var sum time.Duration
+		for _, d := range kept {
+			sum += d
+		}
+		return sum / time.Duration(len(kept))
+	})
+}
+
+// IQR returns a record with the interquartile range of every accumulated cell.
+// It panics if the accumulator does not retain its samples.
+func (a *BootTimeAccumulator) IQR() *BootTimeRecord {
+	a.mustRetainSamples("IQR")
+	return a.reduce(func(c *cellAccumulator) time.Duration {
+		sorted := slices.Clone(c.samples)
+		slices.Sort(sorted)
+		return interquartileRange(sorted)
+	})
+}
+
+// mustRetainSamples panics if the accumulator does not retain its samples,
+// which the order statistic name cannot be computed without. Forgetting
+// WithRetainedSamples is a programming error, which would otherwise go
+// unnoticed behind durations of zero.
+func (a *BootTimeAccumulator) mustRetainSamples(name string) {
+	if !a.retainSamples {
+		panic("model: " + name + " of a BootTimeAccumulator created without WithRetainedSamples")
+	}
+}
+
+// interquartileRange returns Q3 - Q1 of the sorted durations.
+func interquartileRange(sorted []time.Duration) time.Duration {
+	return percentile(sorted, 75) - percentile(sorted, 25)
+}
+
+// percentile returns the p-th percentile (0 <= p <= 100) of the sorted
+// durations, linearly interpolated between the closest ranks.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	fraction := rank - float64(lower)
+	return sorted[lower] + time.Duration(math.Round(fraction*float64(sorted[lower+1]-sorted[lower])))
+}
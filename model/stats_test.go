@@ -0,0 +1,242 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootTimeAccumulatorSummary(t *testing.T) {
+	a := NewBootTimeAccumulator(WithRetainedSamples())
+	for _, ms := range []time.Duration{400, 100, 300, 200} {
+		a.Add(&BootTimeRecord{
+			Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {
+					RetrievalMethodSystemdDBUS: ms * time.Millisecond,
+				},
+			},
+		})
+	}
+
+	summary := a.Summary()
+	require.NotNil(t, summary)
+	assert.Equal(t, 4, summary.Records)
+	assert.Equal(t, Stats{
+		Count:  4,
+		Mean:   250 * time.Millisecond,
+		Median: 250 * time.Millisecond,
+		P99:    397 * time.Millisecond,
+		IQR:    150 * time.Millisecond,
+		Min:    100 * time.Millisecond,
+		Max:    400 * time.Millisecond,
+		StdDev: 111803398,
+	}, summary.Stages[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+}
+
+func TestBootTimeAccumulatorSpread(t *testing.T) {
+	a := NewBootTimeAccumulator()
+	for _, ms := range []time.Duration{400, 100, 300, 200} {
+		a.Add(&BootTimeRecord{
+			Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+				BootTimeStageKernel: {
+					RetrievalMethodSystemdDBUS: ms * time.Millisecond,
+				},
+			},
+		})
+	}
+
+	assert.Equal(t, 100*time.Millisecond, a.Min().Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+	assert.Equal(t, 400*time.Millisecond, a.Max().Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+	assert.Equal(t, time.Duration(111803398), a.StdDev().Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+}
+
+func TestBootTimeAccumulatorIQR(t *testing.T) {
+	tcs := map[string]struct {
+		opts     []AccumulatorOption
+		expected time.Duration
+		panics   bool
+	}{
+		"retained samples": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			expected: 200 * time.Millisecond,
+		},
+		"without retained samples panics": {
+			panics: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := NewBootTimeAccumulator(tc.opts...)
+			// A slow outlier does not change the interquartile range much.
+			for _, ms := range []time.Duration{100, 200, 300, 400, 20000} {
+				a.Add(&BootTimeRecord{
+					Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+						BootTimeStageKernel: {
+							RetrievalMethodSystemdDBUS: ms * time.Millisecond,
+						},
+					},
+				})
+			}
+
+			if tc.panics {
+				assert.Panics(t, func() { a.IQR() })
+				return
+			}
+			assert.Equal(t, tc.expected, a.IQR().Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+		})
+	}
+}
+
+func TestBootTimeAccumulatorMedian(t *testing.T) {
+	tcs := map[string]struct {
+		opts     []AccumulatorOption
+		expected time.Duration
+		panics   bool
+	}{
+		"retained samples": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			expected: 300 * time.Millisecond,
+		},
+		"without retained samples panics": {
+			panics: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := NewBootTimeAccumulator(tc.opts...)
+			// An fsck run skews the average to 4.2s, but not the median.
+			for _, ms := range []time.Duration{100, 200, 300, 400, 20000} {
+				a.Add(&BootTimeRecord{
+					Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+						BootTimeStageKernel: {
+							RetrievalMethodSystemdDBUS: ms * time.Millisecond,
+						},
+					},
+				})
+			}
+
+			if tc.panics {
+				assert.Panics(t, func() { a.Median() })
+				return
+			}
+			assert.Equal(t, tc.expected, a.Median().Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS], name)
+		})
+	}
+}
+
+func TestBootTimeAccumulatorTrimmedAverage(t *testing.T) {
+	tenBoots := []time.Duration{100, 200, 300, 400, 500, 600, 700, 800, 900, 20000}
+
+	tcs := map[string]struct {
+		opts     []AccumulatorOption
+		samples  []time.Duration
+		trim     float64
+		expected time.Duration
+		panics   bool
+	}{
+		"outliers are dropped": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			samples:  tenBoots,
+			trim:     0.1,
+			expected: 550 * time.Millisecond,
+		},
+		"no trim is the average": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			samples:  tenBoots,
+			expected: 2450 * time.Millisecond,
+		},
+		"too few samples to trim are averaged whole": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			samples:  []time.Duration{100, 200, 300, 400, 20000},
+			trim:     0.1,
+			expected: 4200 * time.Millisecond,
+		},
+		"dropped count is rounded down": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			samples:  []time.Duration{100, 200, 300, 400, 20000},
+			trim:     0.3,
+			expected: 300 * time.Millisecond,
+		},
+		"half trim leaves the median": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			samples:  []time.Duration{100, 200, 300, 20000},
+			trim:     0.5,
+			expected: 250 * time.Millisecond,
+		},
+		"single sample is kept": {
+			opts:     []AccumulatorOption{WithRetainedSamples()},
+			samples:  []time.Duration{100},
+			trim:     0.9,
+			expected: 100 * time.Millisecond,
+		},
+		"without retained samples panics": {
+			samples: tenBoots,
+			trim:    0.1,
+			panics:  true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			a := NewBootTimeAccumulator(tc.opts...)
+			for _, ms := range tc.samples {
+				a.Add(&BootTimeRecord{
+					Values: map[BootTimeStage]map[RetrievalMethod]time.Duration{
+						BootTimeStageKernel: {
+							RetrievalMethodSystemdDBUS: ms * time.Millisecond,
+						},
+					},
+				})
+			}
+
+			if tc.panics {
+				assert.Panics(t, func() { a.TrimmedAverage(tc.trim) })
+				return
+			}
+			assert.Equal(t, tc.expected, a.TrimmedAverage(tc.trim).Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tcs := map[string]struct {
+		sorted   []time.Duration
+		p        float64
+		expected time.Duration
+	}{
+		"empty samples": {
+			sorted:   nil,
+			p:        50,
+			expected: 0,
+		},
+		"single sample": {
+			sorted:   []time.Duration{time.Second},
+			p:        99,
+			expected: time.Second,
+		},
+		"interpolated median": {
+			sorted:   []time.Duration{time.Second, 2 * time.Second},
+			p:        50,
+			expected: 1500 * time.Millisecond,
+		},
+		"maximum": {
+			sorted:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			p:        100,
+			expected: 3 * time.Second,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, percentile(tc.sorted, tc.p), name)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrInvalidRecord is returned by ValidateRecordLine, and when reading records
+// with WithStrictValidation, for a record which parses but holds unknown stages
+// or methods, or negative durations.
+var ErrInvalidRecord = errors.New("invalid boot time record")
+
+// ValidateRecordLine checks that the jsonl line is a record whose stages and
+// methods are all known, and whose durations are not negative.
+func ValidateRecordLine(line []byte) error {
+	var rec BootTimeRecord
+	if err := UnmarshalBootTimeRecord(line, &rec); err != nil {
+		return err
+	}
+
+	return validateRecord(&rec)
+}
+
+// validateRecord checks the stages, methods and durations of rec, in lexical
+// order so that the first problem reported does not change between runs.
+func validateRecord(rec *BootTimeRecord) error {
+	for _, stage := range slices.Sorted(maps.Keys(rec.Values)) {
+		if !slices.Contains(allBootTimeStages, stage) {
+			return fmt.Errorf("%w: unknown stage %q", ErrInvalidRecord, stage)
+		}
+
+		methods := rec.Values[stage]
+		for _, method := range slices.Sorted(maps.Keys(methods)) {
+			if !slices.Contains(recordMethods, method) {
+				return fmt.Errorf("%w: unknown method %q in stage %s", ErrInvalidRecord, method, stage)
+			}
+			if d := methods[method]; d < 0 {
+				return fmt.Errorf("%w: negative duration %s for %s (%s)", ErrInvalidRecord, d, stage, method)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRecordLine(t *testing.T) {
+	tcs := map[string]struct {
+		line     string
+		validate func(t *testing.T, err error)
+	}{
+		"valid nested record": {
+			line: `{"firmware":{"acpi_fpdt":"1.897s","systemd_dbus":"1.9s"},"metadata":{"machine_id":"a"}}`,
+			validate: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"valid flat record": {
+			line: `{"firmware":"1.9s","kernel":"718ms"}`,
+			validate: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		"unknown stage returns error": {
+			line: `{"firmwar":{"acpi_fpdt":"1s"}}`,
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidRecord)
+				assert.ErrorContains(t, err, `unknown stage "firmwar"`)
+			},
+		},
+		"unknown method returns error": {
+			line: `{"firmware":{"acpi":"1s"}}`,
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidRecord)
+				assert.ErrorContains(t, err, `unknown method "acpi" in stage firmware`)
+			},
+		},
+		"negative duration returns error": {
+			line: `{"kernel":{"systemd_dbus":"-718ms"}}`,
+			validate: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidRecord)
+				assert.ErrorContains(t, err, "negative duration -718ms for kernel (systemd_dbus)")
+			},
+		},
+		"invalid json returns error": {
+			line: `{"kernel":`,
+			validate: func(t *testing.T, err error) {
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrInvalidRecord)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tc.validate(t, ValidateRecordLine([]byte(tc.line)))
+		})
+	}
+}
+
+func TestBootTimeRecordsFromFileWithStrictValidation(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, records []*BootTimeRecord, err error)
+	}{
+		"valid records": {
+			input: `{"kernel":{"systemd_dbus":"718ms"}}` + "\n\n" + `{"kernel":{"systemd_dbus":"719ms"}}` + "\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error) {
+				require.NoError(t, err)
+				require.Len(t, records, 2)
+				assert.Equal(t, 719*time.Millisecond, records[1].Values[BootTimeStageKernel][RetrievalMethodSystemdDBUS])
+			},
+		},
+		"invalid record reports its line": {
+			input: `{"kernel":{"systemd_dbus":"718ms"}}` + "\n" + `{"kernel":{"systemd_dbus":"-1s"}}` + "\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrInvalidRecord)
+				assert.ErrorContains(t, err, "line 2: ")
+				assert.Nil(t, records)
+			},
+		},
+		"line numbers count blank lines": {
+			input: `{"kernel":{"systemd_dbus":"718ms"}}` + "\n\n" + `{"kernel":{"systemd_dbus":"-1s"}}` + "\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrInvalidRecord)
+				assert.ErrorContains(t, err, "line 3: ")
+				assert.Nil(t, records)
+			},
+		},
+		"unparsable record reports its line": {
+			input: `{"kernel":` + "\n" + `{"kernel":{"systemd_dbus":"718ms"}}` + "\n",
+			validate: func(t *testing.T, records []*BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "line 1: unmarshalling boot time record from line")
+				assert.Nil(t, records)
+			},
+		},
+		"truncated last record returns the previous ones": {
+			input: `{"kernel":{"systemd_dbus":"718ms"}}` + "\n" + `{"kernel":{"systemd_d`,
+			validate: func(t *testing.T, records []*BootTimeRecord, err error) {
+				require.ErrorIs(t, err, ErrTruncatedRecord)
+				assert.ErrorContains(t, err, "line 2: ")
+				assert.Len(t, records, 1)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "records.jsonl")
+			require.NoError(t, os.WriteFile(path, []byte(tc.input), 0o600))
+			file, err := os.Open(path)
+			require.NoError(t, err)
+			defer file.Close()
+
+			records, err := BootTimeRecordsFromFile(file, WithStrictValidation())
+			tc.validate(t, records, err)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package platform detects the kind of Linux host boottime runs on, since some
+// of them, such as WSL, have a kernel without firmware tables.
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const pathOSRelease string = "/proc/sys/kernel/osrelease"
+
+// Platform is the kind of host.
+type Platform string
+
+const (
+	// PlatformLinux is a Linux host booted by its own firmware, either bare
+	// metal or a virtual machine.
+	PlatformLinux Platform = "linux"
+	// PlatformWSL1 is the Windows Subsystem for Linux translating Linux system
+	// calls, without any Linux kernel.
+	PlatformWSL1 Platform = "wsl1"
+	// PlatformWSL2 is the Windows Subsystem for Linux running a Linux kernel in
+	// a lightweight virtual machine, started without firmware nor ACPI tables.
+	PlatformWSL2 Platform = "wsl2"
+)
+
+// IsWSL reports whether the platform is a Windows Subsystem for Linux, where
+// the firmware and loader stages cannot be measured.
+func (p Platform) IsWSL() bool {
+	return p == PlatformWSL1 || p == PlatformWSL2
+}
+
+// Detect returns the platform of the host. A host whose kernel release cannot
+// be read is assumed to be PlatformLinux.
+func Detect() Platform {
+	data, err := os.ReadFile(filepath.Clean(pathOSRelease))
+	if err != nil {
+		return PlatformLinux
+	}
+	return FromOSRelease(string(data))
+}
+
+// FromOSRelease returns the platform running the kernel release, as found in
+// /proc/sys/kernel/osrelease. The WSL kernels have "microsoft" in their
+// release, such as "5.15.153.1-microsoft-standard-WSL2" for WSL2 or
+// "4.4.0-19041-Microsoft" for WSL1.
+func FromOSRelease(release string) Platform {
+	release = strings.ToLower(strings.TrimSpace(release))
+	switch {
+	case !strings.Contains(release, "microsoft"):
+		return PlatformLinux
+	case strings.Contains(release, "wsl2"), strings.Contains(release, "microsoft-standard"):
+		return PlatformWSL2
+	default:
+		return PlatformWSL1
+	}
+}
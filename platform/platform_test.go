@@ -0,0 +1,38 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromOSRelease(t *testing.T) {
+	tcs := map[string]struct {
+		release  string
+		expected Platform
+	}{
+		"linux": {
+			release:  "6.8.0-45-generic\n",
+			expected: PlatformLinux,
+		},
+		"wsl2": {
+			release:  "5.15.153.1-microsoft-standard-WSL2\n",
+			expected: PlatformWSL2,
+		},
+		"wsl2 with custom kernel": {
+			release:  "6.6.36.3-microsoft-standard\n",
+			expected: PlatformWSL2,
+		},
+		"wsl1": {
+			release:  "4.4.0-19041-Microsoft\n",
+			expected: PlatformWSL1,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, FromOSRelease(tc.release), name)
+		})
+	}
+}
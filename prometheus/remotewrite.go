@@ -0,0 +1,154 @@
+// Package prometheus pushes boot time records to a Prometheus compatible
+// endpoint with the remote write protocol, for setups without a local scrape
+// target such as managed Prometheus services.
+//
+// Every stage/method cell of a record is sent as a sample of the
+// boottime_stage_seconds gauge, labelled with the stage and the method, and
+// with the hostname and the machine id of the metadata, so that the series of
+// the hosts of a fleet pushing to the same endpoint do not collide.
+package prometheus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+const requestTimeout time.Duration = 10 * time.Second
+
+// RemoteWrite sends the record, sampled at ts, to the remote write endpoint at
+// url.
+func RemoteWrite(url string, r *model.BootTimeRecord, ts time.Time) error {
+	body := encodeSnappy(encodeWriteRequest(r, ts))
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	client := http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending record to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sending record to %s: %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// encodeWriteRequest returns the protobuf encoding of a prometheus.WriteRequest
+// with one time series per cell of the record, in canonical order:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(r *model.BootTimeRecord, ts time.Time) []byte {
+	var req []byte
+	// Collapsed records are written as well, though collapsed is not a
+	// retrieval method.
+	methods := append((model.Selection{}).Methods(), model.RetrievalMethodCollapsed)
+	var host, machineID string
+	if r.Metadata != nil {
+		host, machineID = r.Metadata.Hostname, r.Metadata.MachineID
+	}
+	for _, stage := range (model.Selection{}).Stages() {
+		for _, method := range methods {
+			d, ok := r.Values[stage][method]
+			if !ok {
+				continue
+			}
+
+			// Labels must be sorted by name, and empty ones are the same
+			// as missing ones.
+			var series []byte
+			for _, label := range [][2]string{
+				{"__name__", model.PrometheusMetricName},
+				{"host", host},
+				{"machine_id", machineID},
+				{"method", string(method)},
+				{"stage", string(stage)},
+			} {
+				if label[1] != "" {
+					series = appendBytes(series, 1, encodeLabel(label[0], label[1]))
+				}
+			}
+
+			var sample []byte
+			sample = appendTag(sample, 1, wireFixed64)
+			sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(d.Seconds()))
+			sample = appendTag(sample, 2, wireVarint)
+			sample = binary.AppendUvarint(sample, uint64(ts.UnixMilli()))
+			series = appendBytes(series, 2, sample)
+
+			req = appendBytes(req, 1, series)
+		}
+	}
+
+	return req
+}
+
+const (
+	wireVarint  uint64 = 0
+	wireFixed64 uint64 = 1
+	wireBytes   uint64 = 2
+)
+
+func encodeLabel(name, value string) []byte {
+	var label []byte
+	label = appendBytes(label, 1, []byte(name))
+	label = appendBytes(label, 2, []byte(value))
+	return label
+}
+
+func appendTag(b []byte, field, wireType uint64) []byte {
+	return binary.AppendUvarint(b, field<<3|wireType)
+}
+
+func appendBytes(b []byte, field uint64, data []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// maxLiteralSize is the largest literal whose length fits the two bytes form
+// of a snappy literal tag.
+const maxLiteralSize int = 1 << 16
+
+// encodeSnappy returns data in the snappy block format, as expected by remote
+// write endpoints. The payload being tiny, it is stored as literals only, which
+// any snappy decoder accepts, rather than compressed.
+func encodeSnappy(data []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := min(len(data), maxLiteralSize)
+
+		switch {
+		case n <= 60:
+			b = append(b, byte(n-1)<<2)
+		case n <= 1<<8:
+			b = append(b, 60<<2, byte(n-1))
+		default:
+			b = append(b, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+
+		b = append(b, data[:n]...)
+		data = data[n:]
+	}
+
+	return b
+}
@@ -0,0 +1,190 @@
+package prometheus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeSnappy(t *testing.T) {
+	tcs := map[string]struct {
+		data     []byte
+		expected []byte
+	}{
+		"short literal": {
+			data:     []byte("hello"),
+			expected: []byte{0x05, 0x10, 'h', 'e', 'l', 'l', 'o'},
+		},
+		"one byte length literal": {
+			data:     bytes.Repeat([]byte{'a'}, 100),
+			expected: append([]byte{0x64, 60 << 2, 99}, bytes.Repeat([]byte{'a'}, 100)...),
+		},
+		"empty": {
+			data:     nil,
+			expected: []byte{0x00},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, encodeSnappy(tc.data), name)
+		})
+	}
+}
+
+func TestRemoteWrite(t *testing.T) {
+	record := &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageFirmware: {
+				model.RetrievalMethodACPIFPDT: 1500 * time.Millisecond,
+			},
+		},
+	}
+	ts := time.UnixMilli(1700000000000)
+	// body is the record encoded by hand from the protobuf definitions of the
+	// WriteRequest, in a single snappy literal.
+	body := slices.Concat(
+		[]byte{0x60},        // uncompressed length: 96
+		[]byte{60 << 2, 95}, // literal of 96 bytes
+		[]byte{0x0a, 0x5e},  // WriteRequest.timeseries: 94 bytes
+		[]byte{0x0a, 0x22},  // TimeSeries.labels: 34 bytes
+		[]byte{0x0a, 0x08}, []byte("__name__"),
+		[]byte{0x12, 0x16}, []byte("boottime_stage_seconds"),
+		[]byte{0x0a, 0x13}, // TimeSeries.labels: 19 bytes
+		[]byte{0x0a, 0x06}, []byte("method"),
+		[]byte{0x12, 0x09}, []byte("acpi_fpdt"),
+		[]byte{0x0a, 0x11}, // TimeSeries.labels: 17 bytes
+		[]byte{0x0a, 0x05}, []byte("stage"),
+		[]byte{0x12, 0x08}, []byte("firmware"),
+		[]byte{0x12, 0x10},                               // TimeSeries.samples: 16 bytes
+		[]byte{0x09, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f},       // Sample.value: 1.5
+		[]byte{0x10, 0x80, 0xd0, 0x95, 0xff, 0xbc, 0x31}, // Sample.timestamp: 1700000000000
+	)
+
+	tcs := map[string]struct {
+		status   int
+		validate func(t *testing.T, req *http.Request, got []byte, err error)
+	}{
+		"accepted": {
+			status: http.StatusNoContent,
+			validate: func(t *testing.T, req *http.Request, got []byte, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "snappy", req.Header.Get("Content-Encoding"))
+				assert.Equal(t, "application/x-protobuf", req.Header.Get("Content-Type"))
+				assert.Equal(t, body, got)
+			},
+		},
+		"rejected": {
+			status: http.StatusBadRequest,
+			validate: func(t *testing.T, req *http.Request, got []byte, err error) {
+				assert.ErrorContains(t, err, "400 Bad Request")
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var req *http.Request
+			var got []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = r
+				got, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			err := RemoteWrite(srv.URL, record, ts)
+			tc.validate(t, req, got, err)
+		})
+	}
+}
+
+func TestEncodeWriteRequestLabels(t *testing.T) {
+	values := map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+		model.BootTimeStageFirmware: {model.RetrievalMethodACPIFPDT: 1500 * time.Millisecond},
+	}
+
+	tcs := map[string]struct {
+		metadata *model.Metadata
+		expected []string
+	}{
+		"host and machine id": {
+			metadata: &model.Metadata{Hostname: "node-1", MachineID: "a"},
+			expected: []string{"__name__=boottime_stage_seconds", "host=node-1", "machine_id=a", "method=acpi_fpdt", "stage=firmware"},
+		},
+		"empty machine id is left out": {
+			metadata: &model.Metadata{Hostname: "node-1"},
+			expected: []string{"__name__=boottime_stage_seconds", "host=node-1", "method=acpi_fpdt", "stage=firmware"},
+		},
+		"without metadata": {
+			expected: []string{"__name__=boottime_stage_seconds", "method=acpi_fpdt", "stage=firmware"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := encodeWriteRequest(&model.BootTimeRecord{Values: values, Metadata: tc.metadata}, time.UnixMilli(0))
+
+			timeseries := decodeFields(t, req)
+			require.Len(t, timeseries, 1)
+			var labels []string
+			for _, field := range decodeFields(t, timeseries[0].data) {
+				if field.number != 1 {
+					continue
+				}
+				label := decodeFields(t, field.data)
+				require.Len(t, label, 2)
+				labels = append(labels, string(label[0].data)+"="+string(label[1].data))
+			}
+			assert.Equal(t, tc.expected, labels)
+		})
+	}
+}
+
+// protoField is a length-delimited field of a protobuf message.
+type protoField struct {
+	number uint64
+	data   []byte
+}
+
+// decodeFields returns the length-delimited fields of the protobuf message b,
+// skipping the others.
+func decodeFields(t *testing.T, b []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Positive(t, n)
+		b = b[n:]
+
+		switch tag & 7 {
+		case wireVarint:
+			_, n = binary.Uvarint(b)
+			require.Positive(t, n)
+			b = b[n:]
+		case wireFixed64:
+			b = b[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			require.Positive(t, n)
+			b = b[n:]
+			fields = append(fields, protoField{number: tag >> 3, data: b[:size]})
+			b = b[size:]
+		default:
+			require.Failf(t, "unexpected wire type", "%d", tag&7)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,117 @@
+// Package remote implements a minimal protocol to push boot time records from
+// hosts to a central collector over TCP.
+//
+// Each record is sent as a frame made of a 4-byte big-endian length followed
+// by the JSON encoding of the record, identical to a jsonl line.
+package remote
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/boreec/boottime/model"
+)
+
+const (
+	// maxFrameSize is the largest frame accepted by the collector.
+	maxFrameSize uint32 = 1024 * 1024
+
+	dialTimeout time.Duration = 10 * time.Second
+)
+
+// ErrFrameTooLarge is returned when a frame exceeds the maximum size.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// Send connects to the collector listening on addr and sends the record.
+func Send(addr string, r *model.BootTimeRecord) error {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if err := writeFrame(conn, r); err != nil {
+		return fmt.Errorf("sending record to %s: %w", addr, err)
+	}
+
+	return nil
+}
+
+// Serve accepts connections on ln and calls handler for every record received,
+// with the host of the sender. Connections are handled concurrently, so
+// handler must be safe for concurrent use. Serve returns when ln is closed.
+func Serve(ln net.Listener, handler func(host string, r *model.BootTimeRecord)) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+
+		go handleConn(conn, handler)
+	}
+}
+
+func handleConn(conn net.Conn, handler func(host string, r *model.BootTimeRecord)) {
+	defer conn.Close()
+
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		host = conn.RemoteAddr().String()
+	}
+
+	br := bufio.NewReader(conn)
+	for {
+		r, err := readFrame(br)
+		if err != nil {
+			return
+		}
+		handler(host, r)
+	}
+}
+
+func writeFrame(w io.Writer, r *model.BootTimeRecord) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshalling record to json: %w", err)
+	}
+
+	if len(payload) > int(maxFrameSize) {
+		return ErrFrameTooLarge
+	}
+
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+
+	_, err = w.Write(frame)
+	return err
+}
+
+func readFrame(r io.Reader) (*model.BootTimeRecord, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+
+	if size > maxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("reading frame payload: %w", err)
+	}
+
+	var rec model.BootTimeRecord
+	if err := model.UnmarshalBootTimeRecord(payload, &rec); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
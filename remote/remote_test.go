@@ -0,0 +1,53 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/boreec/boottime/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	type received struct {
+		host   string
+		record *model.BootTimeRecord
+	}
+	ch := make(chan received, 1)
+	go Serve(ln, func(host string, r *model.BootTimeRecord) {
+		ch <- received{host: host, record: r}
+	})
+
+	sent := &model.BootTimeRecord{
+		Values: map[model.BootTimeStage]map[model.RetrievalMethod]time.Duration{
+			model.BootTimeStageFirmware: {
+				model.RetrievalMethodACPIFPDT: 1897 * time.Millisecond,
+			},
+		},
+	}
+	require.NoError(t, Send(ln.Addr().String(), sent))
+
+	select {
+	case got := <-ch:
+		assert.Equal(t, "127.0.0.1", got.host)
+		assert.Equal(t, sent.Values, got.record.Values)
+	case <-time.After(5 * time.Second):
+		t.Fatal("record not received")
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.BigEndian, maxFrameSize+1))
+
+	_, err := readFrame(&buf)
+	require.ErrorIs(t, err, ErrFrameTooLarge)
+}
@@ -0,0 +1,70 @@
+package systemd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// UnitTiming is the time a unit took to start, as reported by systemd-analyze
+// blame.
+type UnitTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// RetrieveBlame runs systemd-analyze blame and returns the units of the boot,
+// the slowest first.
+func RetrieveBlame() ([]UnitTiming, error) {
+	return RetrieveBlameContext(context.Background())
+}
+
+// RetrieveBlameContext is RetrieveBlame, killing systemd-analyze when ctx is
+// done.
+func RetrieveBlameContext(ctx context.Context) ([]UnitTiming, error) {
+	out, err := exec.CommandContext(ctx, "systemd-analyze", "blame").Output()
+	if err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	units, err := ParseBlameOutput(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("parsing command output: %w", err)
+	}
+
+	return units, nil
+}
+
+// ParseBlameOutput parses the output of systemd-analyze blame, a line per unit
+// such as "1min 3.402s apt-daily.service", and returns the units sorted by
+// descending duration.
+func ParseBlameOutput(output string) ([]UnitTiming, error) {
+	var units []UnitTiming
+	for i, line := range strings.Split(output, "\n") {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+
+		if len(words) < 2 {
+			return nil, fmt.Errorf("parsing line %d %q: expected a duration and a unit", i+1, line)
+		}
+
+		// The duration of slow units spans several words.
+		d, err := parseDuration(words[:len(words)-1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing line %d: %w", i+1, err)
+		}
+		units = append(units, UnitTiming{Name: words[len(words)-1], Duration: d})
+	}
+
+	slices.SortStableFunc(units, func(a, b UnitTiming) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+
+	return units, nil
+}
@@ -0,0 +1,74 @@
+package systemd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlameOutput(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, units []UnitTiming, err error)
+	}{
+		"units are sorted by descending duration": {
+			input: `         12.345s NetworkManager-wait-online.service
+1min 3.402s apt-daily.service
+          987ms snapd.service
+          987ms udisks2.service
+           54us sys-kernel-tracing.mount
+`,
+			validate: func(t *testing.T, units []UnitTiming, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []UnitTiming{
+					{Name: "apt-daily.service", Duration: time.Minute + 3402*time.Millisecond},
+					{Name: "NetworkManager-wait-online.service", Duration: 12345 * time.Millisecond},
+					{Name: "snapd.service", Duration: 987 * time.Millisecond},
+					{Name: "udisks2.service", Duration: 987 * time.Millisecond},
+					{Name: "sys-kernel-tracing.mount", Duration: 54 * time.Microsecond},
+				}, units)
+			},
+		},
+		"hours span several words": {
+			input: `1h 2min 3.5s fsck@dev-sda1.service`,
+			validate: func(t *testing.T, units []UnitTiming, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []UnitTiming{
+					{Name: "fsck@dev-sda1.service", Duration: time.Hour + 2*time.Minute + 3500*time.Millisecond},
+				}, units)
+			},
+		},
+		"empty output returns no unit": {
+			input: "\n",
+			validate: func(t *testing.T, units []UnitTiming, err error) {
+				require.NoError(t, err)
+				assert.Empty(t, units)
+			},
+		},
+		"line without duration returns error": {
+			input: `12.345s a.service
+b.service`,
+			validate: func(t *testing.T, units []UnitTiming, err error) {
+				require.ErrorContains(t, err, "parsing line 2")
+				assert.Nil(t, units)
+			},
+		},
+		"line with invalid duration returns error": {
+			input: `potatoes a.service`,
+			validate: func(t *testing.T, units []UnitTiming, err error) {
+				require.ErrorContains(t, err, "parsing line 1")
+				assert.Nil(t, units)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			units, err := ParseBlameOutput(tc.input)
+			tc.validate(t, units, err)
+		})
+	}
+}
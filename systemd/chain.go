@@ -0,0 +1,106 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ChainEntry is a unit of the critical chain of the boot, as reported by
+// systemd-analyze critical-chain.
+type ChainEntry struct {
+	Name string
+	// Depth is the depth of the unit in the tree, 0 for the unit whose startup
+	// the chain leads to. Every unit is started after the units below it.
+	Depth int
+	// ActivatedAt is the time the unit became active or started, after the
+	// "@" character.
+	ActivatedAt time.Duration
+	// Duration is the time the unit took to start, after the "+" character.
+	// It is zero for the units which do not run anything, such as targets.
+	Duration time.Duration
+}
+
+// RetrieveCriticalChain runs systemd-analyze critical-chain and returns the
+// units gating the completion of the boot, in the order of the tree.
+func RetrieveCriticalChain() ([]ChainEntry, error) {
+	return RetrieveCriticalChainContext(context.Background())
+}
+
+// RetrieveCriticalChainContext is RetrieveCriticalChain, killing
+// systemd-analyze when ctx is done.
+func RetrieveCriticalChainContext(ctx context.Context) ([]ChainEntry, error) {
+	out, err := exec.CommandContext(ctx, "systemd-analyze", "critical-chain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	entries, err := ParseCriticalChainOutput(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("parsing command output: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ParseCriticalChainOutput parses the output of systemd-analyze
+// critical-chain, a tree of units such as "  └─docker.service @10.528s
+// +2.741s", skipping the explanation printed before the tree.
+func ParseCriticalChainOutput(output string) ([]ChainEntry, error) {
+	var entries []ChainEntry
+	for i, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "The time ") {
+			continue
+		}
+
+		entry, err := parseChainLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("parsing line %d %q: %w", i+1, line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseChainLine parses a line of the critical chain tree. The depth is given
+// by the indentation of the "└─" or "├─" branch, two columns per level.
+func parseChainLine(line string) (ChainEntry, error) {
+	var entry ChainEntry
+	if i := strings.IndexAny(line, "└├"); i >= 0 {
+		entry.Depth = utf8.RuneCountInString(line[:i])/2 + 1
+		_, size := utf8.DecodeRuneInString(line[i:])
+		line = strings.TrimPrefix(line[i+size:], "─")
+	}
+
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return ChainEntry{}, errors.New("no unit name")
+	}
+	entry.Name = words[0]
+
+	// The times of slow units span several words, such as "@1min 3.2s".
+	var dest *time.Duration
+	for _, w := range words[1:] {
+		switch {
+		case strings.HasPrefix(w, "@"):
+			dest, w = &entry.ActivatedAt, w[1:]
+		case strings.HasPrefix(w, "+"):
+			dest, w = &entry.Duration, w[1:]
+		case dest == nil:
+			return ChainEntry{}, fmt.Errorf("unexpected word %q before the @ or + times", w)
+		}
+
+		d, err := parseDuration([]string{w})
+		if err != nil {
+			return ChainEntry{}, err
+		}
+		*dest += d
+	}
+
+	return entry, nil
+}
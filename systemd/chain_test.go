@@ -0,0 +1,71 @@
+package systemd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCriticalChainOutput(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, entries []ChainEntry, err error)
+	}{
+		"tree with depths and times": {
+			input: `The time when unit became active or started is printed after the "@" character.
+The time the unit took to start is printed after the "+" character.
+
+graphical.target @1min 13.270s
+└─multi-user.target @1min 13.270s
+  └─docker.service @1min 10.528s +2.741s
+    └─network-online.target @10.525s
+      └─NetworkManager-wait-online.service @4.210s +1min 6.314s
+      ├─systemd-tmpfiles-setup.service @3.628s +31ms
+      │ └─local-fs.target @3.625s
+`,
+			validate: func(t *testing.T, entries []ChainEntry, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []ChainEntry{
+					{Name: "graphical.target", Depth: 0, ActivatedAt: time.Minute + 13270*time.Millisecond},
+					{Name: "multi-user.target", Depth: 1, ActivatedAt: time.Minute + 13270*time.Millisecond},
+					{Name: "docker.service", Depth: 2, ActivatedAt: time.Minute + 10528*time.Millisecond, Duration: 2741 * time.Millisecond},
+					{Name: "network-online.target", Depth: 3, ActivatedAt: 10525 * time.Millisecond},
+					{Name: "NetworkManager-wait-online.service", Depth: 4, ActivatedAt: 4210 * time.Millisecond, Duration: time.Minute + 6314*time.Millisecond},
+					{Name: "systemd-tmpfiles-setup.service", Depth: 4, ActivatedAt: 3628 * time.Millisecond, Duration: 31 * time.Millisecond},
+					{Name: "local-fs.target", Depth: 5, ActivatedAt: 3625 * time.Millisecond},
+				}, entries)
+			},
+		},
+		"unit without times": {
+			input: `basic.target`,
+			validate: func(t *testing.T, entries []ChainEntry, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []ChainEntry{{Name: "basic.target"}}, entries)
+			},
+		},
+		"invalid time returns error": {
+			input: `basic.target @potatoes`,
+			validate: func(t *testing.T, entries []ChainEntry, err error) {
+				require.ErrorContains(t, err, "parsing line 1")
+				assert.Nil(t, entries)
+			},
+		},
+		"text after the unit returns error": {
+			input: `Bootup is not yet finished.`,
+			validate: func(t *testing.T, entries []ChainEntry, err error) {
+				require.ErrorContains(t, err, "unexpected word")
+				assert.Nil(t, entries)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			entries, err := ParseCriticalChainOutput(tc.input)
+			tc.validate(t, entries, err)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package systemd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+const (
+	// startupFinishedMessageID is the MESSAGE_ID logged by the system manager
+	// once the boot is finished ("Startup finished in ...").
+	startupFinishedMessageID string = "b07a249cd024414a82dd00cd181378ff"
+
+	// journalMaxEntrySize is the longest journal entry accepted by the parser.
+	journalMaxEntrySize int = 1024 * 1024
+
+	// journalOutputFields are the fields of journalEntry requested from
+	// journalctl, which always prints __MONOTONIC_TIMESTAMP. Leaving out the
+	// other fields, such as the messages, shrinks the output to parse.
+	journalOutputFields string = "_TRANSPORT,SYSLOG_FACILITY,MESSAGE_ID,INITRD_USEC"
+)
+
+// ErrJournalStartupNotFinished is returned when the journal of the current boot
+// does not contain the "Startup finished" entry of the system manager.
+var ErrJournalStartupNotFinished = errors.New("startup finished entry not found in journal")
+
+// journalEntry contains the subset of journal fields used to find the boot
+// markers.
+type journalEntry struct {
+	Monotonic      string `json:"__MONOTONIC_TIMESTAMP"`
+	Transport      string `json:"_TRANSPORT"`
+	SyslogFacility string `json:"SYSLOG_FACILITY"`
+	MessageID      string `json:"MESSAGE_ID"`
+	InitrdUSec     string `json:"INITRD_USEC"`
+}
+
+// isKernelMessage reports whether the entry was logged by the kernel itself.
+// Userspace programs (including PID 1 before journald starts) can also write
+// to the kernel log buffer, but they never use the kernel syslog facility.
+func (e journalEntry) isKernelMessage() bool {
+	return e.Transport == "kernel" && (e.SyslogFacility == "" || e.SyslogFacility == "0")
+}
+
+// RetrieveBootTimeWithJournal reads the journal of the current boot to find
+// the boot markers. It is an alternative to the D-Bus properties when the
+// system bus is not reachable but the journal is readable.
+func RetrieveBootTimeWithJournal() (*BootTimeRecord, error) {
+	return RetrieveBootTimeWithJournalContext(context.Background())
+}
+
+// RetrieveBootTimeWithJournalContext is RetrieveBootTimeWithJournal, killing
+// journalctl when ctx is done.
+func RetrieveBootTimeWithJournalContext(ctx context.Context) (*BootTimeRecord, error) {
+	cmd := exec.CommandContext(ctx, "journalctl", "-b", "-o", "json", "--output-fields="+journalOutputFields)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	btr, parseErr := ParseJournalOutput(stdout)
+
+	// The parser stops reading at the startup finished entry, so the rest of
+	// the journal is not needed.
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+
+	// Killing journalctl truncates its output, which is not a parsing error.
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	if parseErr != nil {
+		return nil, fmt.Errorf("parsing command output: %w", parseErr)
+	}
+
+	return btr, nil
+}
+
+// ParseJournalOutput parses the output of `journalctl -b -o json` and returns
+// the kernel, initrd and userspace durations:
+//   - the kernel starts at monotonic time 0 and ends with the first entry not
+//     logged by the kernel, which is the first userspace process.
+//   - the userspace ends with the "Startup finished" entry of the system
+//     manager. If that entry carries INITRD_USEC, the initrd duration is split
+//     out of the userspace duration.
+//
+// Firmware, loader and total durations are not available in the journal.
+func ParseJournalOutput(r io.Reader) (*BootTimeRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), journalMaxEntrySize)
+
+	var userspaceStart, finish uint64
+	var initrd uint64
+	var userspaceStarted bool
+
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("unmarshalling journal entry: %w", err)
+		}
+
+		mono, err := strconv.ParseUint(entry.Monotonic, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing monotonic timestamp %q: %w", entry.Monotonic, err)
+		}
+
+		if !userspaceStarted && !entry.isKernelMessage() {
+			userspaceStart = mono
+			userspaceStarted = true
+		}
+
+		if entry.MessageID == startupFinishedMessageID {
+			finish = mono
+			if entry.InitrdUSec != "" {
+				initrd, err = strconv.ParseUint(entry.InitrdUSec, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("parsing INITRD_USEC %q: %w", entry.InitrdUSec, err)
+				}
+			}
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+
+	if finish == 0 {
+		return nil, ErrJournalStartupNotFinished
+	}
+
+	if finish < userspaceStart+initrd {
+		return nil, fmt.Errorf("startup finished at %dus before userspace started at %dus", finish, userspaceStart+initrd)
+	}
+
+	return &BootTimeRecord{
+		Kernel:    usec(userspaceStart),
+		Initrd:    usec(initrd),
+		Userspace: usec(finish - userspaceStart - initrd),
+	}, nil
+}
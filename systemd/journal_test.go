@@ -0,0 +1,112 @@
+package systemd
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJournalOutput(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, btr *BootTimeRecord, err error, name string)
+	}{
+		"parse boot with initrd successfully": {
+			input: `{"__MONOTONIC_TIMESTAMP":"0","_TRANSPORT":"kernel","SYSLOG_FACILITY":"0","MESSAGE":"Linux version 6.8.0"}
+{"__MONOTONIC_TIMESTAMP":"512000","_TRANSPORT":"kernel","SYSLOG_FACILITY":"0","MESSAGE":"Freeing unused kernel image memory"}
+{"__MONOTONIC_TIMESTAMP":"718000","_TRANSPORT":"kernel","SYSLOG_FACILITY":"3","MESSAGE":"systemd 255 running in system mode"}
+{"__MONOTONIC_TIMESTAMP":"800000","_TRANSPORT":"driver","MESSAGE":"Journal started"}
+{"__MONOTONIC_TIMESTAMP":"16042000","_TRANSPORT":"journal","MESSAGE_ID":"b07a249cd024414a82dd00cd181378ff","INITRD_USEC":"2049000","MESSAGE":"Startup finished"}
+{"__MONOTONIC_TIMESTAMP":"17000000","_TRANSPORT":"journal","MESSAGE":"after boot"}`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, btr, name)
+				assert.Equal(t, 718*time.Millisecond, btr.Kernel, name)
+				assert.Equal(t, 2049*time.Millisecond, btr.Initrd, name)
+				assert.Equal(t, 13275*time.Millisecond, btr.Userspace, name)
+				assert.Zero(t, btr.Firmware, name)
+				assert.Zero(t, btr.Loader, name)
+				assert.Zero(t, btr.Total, name)
+			},
+		},
+		"parse boot without initrd successfully": {
+			input: `{"__MONOTONIC_TIMESTAMP":"0","_TRANSPORT":"kernel","MESSAGE":"Linux version 6.8.0"}
+{"__MONOTONIC_TIMESTAMP":"2300000","_TRANSPORT":"stdout","MESSAGE":"first userspace message"}
+{"__MONOTONIC_TIMESTAMP":"7400000","_TRANSPORT":"journal","MESSAGE_ID":"b07a249cd024414a82dd00cd181378ff","MESSAGE":"Startup finished"}`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, btr, name)
+				assert.Equal(t, 2300*time.Millisecond, btr.Kernel, name)
+				assert.Zero(t, btr.Initrd, name)
+				assert.Equal(t, 5100*time.Millisecond, btr.Userspace, name)
+			},
+		},
+		"parse unfinished boot returns error": {
+			input: `{"__MONOTONIC_TIMESTAMP":"0","_TRANSPORT":"kernel","MESSAGE":"Linux version 6.8.0"}
+{"__MONOTONIC_TIMESTAMP":"2300000","_TRANSPORT":"stdout","MESSAGE":"first userspace message"}`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.ErrorIs(t, err, ErrJournalStartupNotFinished, name)
+				require.Nil(t, btr, name)
+			},
+		},
+		"parse invalid json returns error": {
+			input: `{"__MONOTONIC_TIMESTAMP":"0",`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.Error(t, err, name)
+				require.Nil(t, btr, name)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			btr, err := ParseJournalOutput(strings.NewReader(tc.input))
+			tc.validate(t, btr, err, name)
+		})
+	}
+}
+
+// TestParseJournalOutputMatchesDbus checks that the journal markers and the
+// D-Bus timestamps of the same boot produce the same durations.
+func TestParseJournalOutputMatchesDbus(t *testing.T) {
+	dbusRecord, err := BootTimeRecordFromTimestamps(MonotonicTimestamps{
+		Firmware:  1897000,
+		Loader:    1715000,
+		InitRD:    718000,
+		Userspace: 2767000,
+		Finish:    16042000,
+	})
+	require.NoError(t, err)
+
+	journalRecord, err := ParseJournalOutput(strings.NewReader(
+		`{"__MONOTONIC_TIMESTAMP":"0","_TRANSPORT":"kernel","MESSAGE":"Linux version 6.8.0"}
+{"__MONOTONIC_TIMESTAMP":"718000","_TRANSPORT":"kernel","SYSLOG_FACILITY":"3","MESSAGE":"systemd 255 running in system mode"}
+{"__MONOTONIC_TIMESTAMP":"16042000","_TRANSPORT":"journal","MESSAGE_ID":"b07a249cd024414a82dd00cd181378ff","INITRD_USEC":"2049000"}`,
+	))
+	require.NoError(t, err)
+
+	assert.Equal(t, dbusRecord.Kernel, journalRecord.Kernel)
+	assert.Equal(t, dbusRecord.Initrd, journalRecord.Initrd)
+	assert.Equal(t, dbusRecord.Userspace, journalRecord.Userspace)
+}
+
+func TestJournalOutputFields(t *testing.T) {
+	entryType := reflect.TypeFor[journalEntry]()
+	var fields []string
+	for i := range entryType.NumField() {
+		if name := entryType.Field(i).Tag.Get("json"); name != "__MONOTONIC_TIMESTAMP" {
+			fields = append(fields, name)
+		}
+	}
+
+	requested := strings.Split(journalOutputFields, ",")
+	slices.Sort(fields)
+	slices.Sort(requested)
+	assert.Equal(t, fields, requested)
+}
@@ -0,0 +1,16 @@
+package systemd
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Reboot asks systemd to reboot the host, returning once the reboot is
+// scheduled.
+func Reboot() error {
+	if err := exec.Command("systemctl", "reboot").Run(); err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+
+	return nil
+}
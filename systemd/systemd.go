@@ -1,6 +1,7 @@
 package systemd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
@@ -21,10 +22,56 @@ type BootTimeRecord struct {
 	Initrd    time.Duration
 	Userspace time.Duration
 	Total     time.Duration
+	// ReachedTarget is the default target of the boot, such as
+	// "graphical.target" or "multi-user.target", and ReachedAfter the time
+	// userspace took to reach it, when the system became usable. They are only
+	// parsed from the systemd-analyze time output, and empty if it has no such
+	// line.
+	ReachedTarget string
+	ReachedAfter  time.Duration
+	// Generators is the time the manager spent running the generators, and
+	// UnitsLoad the time it spent loading the units, both part of the
+	// userspace stage. They are only read through dbus, and zero if the
+	// manager did not log them.
+	Generators time.Duration
+	UnitsLoad  time.Duration
 }
 
-func RetrieveBootTimeWithAnalyzeCommand() (*BootTimeRecord, error) {
-	cmd := exec.Command("systemd-analyze", "time")
+// AnalyzeScope is the service manager systemd-analyze connects to.
+type AnalyzeScope string
+
+const (
+	// AnalyzeScopeSystem measures the startup of the system service manager,
+	// that is the boot.
+	AnalyzeScopeSystem AnalyzeScope = "system"
+	// AnalyzeScopeUser measures the startup of the service manager of the
+	// calling user, that is the user session.
+	AnalyzeScopeUser AnalyzeScope = "user"
+)
+
+// ParseAnalyzeScope returns the scope named s.
+func ParseAnalyzeScope(s string) (AnalyzeScope, error) {
+	switch scope := AnalyzeScope(s); scope {
+	case AnalyzeScopeSystem, AnalyzeScopeUser:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown systemd-analyze scope %q, expected %s or %s", s, AnalyzeScopeSystem, AnalyzeScopeUser)
+}
+
+// RetrieveBootTimeWithAnalyzeCommand runs systemd-analyze time for the service
+// manager of the scope. An empty scope is AnalyzeScopeSystem.
+func RetrieveBootTimeWithAnalyzeCommand(scope AnalyzeScope) (*BootTimeRecord, error) {
+	return RetrieveBootTimeWithAnalyzeCommandContext(context.Background(), scope)
+}
+
+// RetrieveBootTimeWithAnalyzeCommandContext is RetrieveBootTimeWithAnalyzeCommand,
+// killing systemd-analyze when ctx is done.
+func RetrieveBootTimeWithAnalyzeCommandContext(ctx context.Context, scope AnalyzeScope) (*BootTimeRecord, error) {
+	if scope == "" {
+		scope = AnalyzeScopeSystem
+	}
+
+	cmd := exec.CommandContext(ctx, "systemd-analyze", "--"+string(scope), "time")
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("command failed: %w", err)
@@ -39,7 +86,32 @@ func RetrieveBootTimeWithAnalyzeCommand() (*BootTimeRecord, error) {
 }
 
 func RetrieveBootTimeWithDbus() (*BootTimeRecord, error) {
-	conn, err := dbus.SystemBus()
+	return RetrieveBootTimeWithDbusContext(context.Background())
+}
+
+// RetrieveBootTimeWithDbusContext is RetrieveBootTimeWithDbus, giving up on
+// the system bus when ctx is done.
+func RetrieveBootTimeWithDbusContext(ctx context.Context) (*BootTimeRecord, error) {
+	ts, err := RetrieveMonotonicTimestampsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return BootTimeRecordFromTimestamps(*ts)
+}
+
+// RetrieveMonotonicTimestamps reads the timestamps of the systemd manager
+// through dbus. Timestamps which cannot be read are left to zero.
+func RetrieveMonotonicTimestamps() (*MonotonicTimestamps, error) {
+	return RetrieveMonotonicTimestampsContext(context.Background())
+}
+
+// RetrieveMonotonicTimestampsContext is RetrieveMonotonicTimestamps, giving up
+// on the system bus when ctx is done.
+func RetrieveMonotonicTimestampsContext(ctx context.Context) (*MonotonicTimestamps, error) {
+	// A private connection, unlike the shared one of dbus.SystemBus, can be
+	// bound to ctx and closed.
+	conn, err := dbus.ConnectSystemBus(dbus.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
 	}
@@ -47,19 +119,27 @@ func RetrieveBootTimeWithDbus() (*BootTimeRecord, error) {
 
 	obj := conn.Object("org.freedesktop.systemd1", "/org/freedesktop/systemd1")
 
-	var firmwareTs, loaderTs, initrdTs, userspaceTs, finishTs uint64
+	var ts MonotonicTimestamps
 	properties := map[string]*uint64{
-		"FirmwareTimestampMonotonic":  &firmwareTs,
-		"LoaderTimestampMonotonic":    &loaderTs,
-		"InitRDTimestampMonotonic":    &initrdTs,
-		"UserspaceTimestampMonotonic": &userspaceTs,
-		"FinishTimestampMonotonic":    &finishTs,
+		"FirmwareTimestampMonotonic":  &ts.Firmware,
+		"LoaderTimestampMonotonic":    &ts.Loader,
+		"InitRDTimestampMonotonic":    &ts.InitRD,
+		"UserspaceTimestampMonotonic": &ts.Userspace,
+		"FinishTimestampMonotonic":    &ts.Finish,
+
+		"GeneratorsStartTimestampMonotonic":  &ts.GeneratorsStart,
+		"GeneratorsFinishTimestampMonotonic": &ts.GeneratorsFinish,
+		"UnitsLoadStartTimestampMonotonic":   &ts.UnitsLoadStart,
+		"UnitsLoadFinishTimestampMonotonic":  &ts.UnitsLoadFinish,
 	}
 
 	for propName, dest := range properties {
 		var value dbus.Variant
-		err := obj.Call("org.freedesktop.DBus.Properties.Get", 0,
+		err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.Get", 0,
 			"org.freedesktop.systemd1.Manager", propName).Store(&value)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("reading property %s: %w", propName, ctxErr)
+		}
 		if err != nil {
 			continue
 		}
@@ -69,52 +149,99 @@ func RetrieveBootTimeWithDbus() (*BootTimeRecord, error) {
 		}
 	}
 
-	if finishTs == 0 {
-		return nil, errors.New("bootup is not yet finished")
-	}
+	return &ts, nil
+}
 
-	usec := func(us uint64) time.Duration {
-		return time.Duration(us) * time.Microsecond
+// MonotonicTimestamps are the systemd manager timestamps, in microseconds.
+type MonotonicTimestamps struct {
+	Firmware  uint64
+	Loader    uint64
+	InitRD    uint64
+	Userspace uint64
+	Finish    uint64
+
+	// The generators and units load timestamps are those of the last run of
+	// the manager, after switching from the initrd to the root file system.
+	GeneratorsStart  uint64
+	GeneratorsFinish uint64
+	UnitsLoadStart   uint64
+	UnitsLoadFinish  uint64
+}
+
+// BootTimeRecordFromTimestamps computes the boot time stages from the systemd
+// manager timestamps, the same way as systemd-analyze.
+func BootTimeRecordFromTimestamps(ts MonotonicTimestamps) (*BootTimeRecord, error) {
+	if ts.Finish == 0 {
+		return nil, errors.New("bootup is not yet finished")
 	}
 
 	// Determine kernel_done_time
 	var kernelDoneTime uint64
-	if initrdTs > 0 {
-		kernelDoneTime = initrdTs
+	if ts.InitRD > 0 {
+		kernelDoneTime = ts.InitRD
 	} else {
-		kernelDoneTime = userspaceTs
+		kernelDoneTime = ts.Userspace
 	}
 
 	record := &BootTimeRecord{}
 
 	// Match systemd's calculation exactly
-	if firmwareTs > 0 && loaderTs > 0 {
-		record.Firmware = usec(firmwareTs - loaderTs)
+	if ts.Firmware > 0 && ts.Loader > 0 {
+		record.Firmware = usec(ts.Firmware - ts.Loader)
 	}
 
-	if loaderTs > 0 {
-		record.Loader = usec(loaderTs)
+	if ts.Loader > 0 {
+		record.Loader = usec(ts.Loader)
 	}
 
 	record.Kernel = usec(kernelDoneTime)
 
-	if initrdTs > 0 && userspaceTs > 0 {
-		record.Initrd = usec(userspaceTs - initrdTs)
+	if ts.InitRD > 0 && ts.Userspace > 0 {
+		record.Initrd = usec(ts.Userspace - ts.InitRD)
 	}
 
-	if finishTs > 0 && userspaceTs > 0 {
-		record.Userspace = usec(finishTs - userspaceTs)
+	if ts.Finish > 0 && ts.Userspace > 0 {
+		record.Userspace = usec(ts.Finish - ts.Userspace)
 	}
 
-	if firmwareTs > 0 && finishTs > 0 {
-		record.Total = usec(firmwareTs + finishTs)
-	}
+	// The firmware and loader timestamps count backwards from the start of the
+	// kernel, so adding the firmware timestamp, which spans the firmware and
+	// the loader, to the finish timestamp gives the end-to-end duration. Like
+	// systemd-analyze, the total is the finish timestamp alone when the
+	// firmware does not report its timestamp, such as on most VMs.
+	record.Total = usec(ts.Firmware + ts.Finish)
+
+	record.Generators = span(ts.GeneratorsStart, ts.GeneratorsFinish)
+	record.UnitsLoad = span(ts.UnitsLoadStart, ts.UnitsLoadFinish)
 
 	return record, nil
 }
 
+func usec(us uint64) time.Duration {
+	return time.Duration(us) * time.Microsecond
+}
+
+// span returns the duration from start to finish, or zero if either is missing
+// or finish is before start.
+func span(start, finish uint64) time.Duration {
+	if start == 0 || finish < start {
+		return 0
+	}
+	return usec(finish - start)
+}
+
+// ErrParseAnalyzeCommandNoStage is returned when none of the stages in the
+// systemd-analyze time output could be parsed.
+var ErrParseAnalyzeCommandNoStage = errors.New("no stage duration could be parsed")
+
 // ParseAnalyzeCommandOutput parses the string output of the systemd-analyze time
-// command and returns the duration.
+// command. Stages whose duration cannot be parsed, such as "n/a (loader)" or
+// "∞ (firmware)" on some systemd versions, are left to zero, and an error is
+// only returned if no stage could be parsed. So are the stages missing from the
+// output, such as the firmware and loader on VMs and containers, which print
+// "Startup finished in 2.3s (kernel) + 5.1s (userspace) = 7.4s". The line
+// following the stages, such as "graphical.target reached after 13.270s in
+// userspace.", sets ReachedTarget and ReachedAfter if present.
 func ParseAnalyzeCommandOutput(output string) (*BootTimeRecord, error) {
 	lines := strings.Split(output, "\n")
 	if output == "" || len(lines) == 0 {
@@ -125,47 +252,103 @@ func ParseAnalyzeCommandOutput(output string) (*BootTimeRecord, error) {
 	words := strings.Fields(line)
 
 	var record BootTimeRecord
-	var err error
+	stages := map[string]*time.Duration{
+		"(firmware)":  &record.Firmware,
+		"(loader)":    &record.Loader,
+		"(kernel)":    &record.Kernel,
+		"(initrd)":    &record.Initrd,
+		"(userspace)": &record.Userspace,
+	}
+
+	parsed := 0
 	for idx, word := range words {
-		switch {
-		case strings.Contains(word, "(firmware)"):
-			record.Firmware, err = parseDuration(words[idx-1 : idx])
-			if err != nil {
-				err = fmt.Errorf("parsing firmware duration: %w", err)
-			}
-		case strings.Contains(word, "(loader)"):
-			record.Loader, err = parseDuration(words[idx-1 : idx])
-			if err != nil {
-				err = fmt.Errorf("parsing loader duration: %w", err)
+		if word == "=" {
+			// The total spans several words for long boots, such as
+			// "1min 5.998s", and may be followed by informational text.
+			if d, ok := parseLeadingDuration(words[idx+1:]); ok {
+				record.Total = d
+				parsed++
 			}
-		case strings.Contains(word, "(kernel)"):
-			record.Kernel, err = parseDuration(words[idx-1 : idx])
-			if err != nil {
-				err = fmt.Errorf("parsing kernel duration: %w", err)
-			}
-		case strings.Contains(word, "(initrd)"):
-			record.Initrd, err = parseDuration(words[idx-1 : idx])
-			if err != nil {
-				err = fmt.Errorf("parsing initrd duration: %w", err)
-			}
-		case strings.Contains(word, "(userspace)"):
-			record.Userspace, err = parseDuration(words[idx-1 : idx])
-			if err != nil {
-				err = fmt.Errorf("parsing userspace duration: %w", err)
+			continue
+		}
+
+		for suffix, dest := range stages {
+			if !strings.Contains(word, suffix) || idx == 0 {
+				continue
 			}
-		case strings.Contains(word, "="):
-			record.Total, err = parseDuration(words[idx+1:])
-			if err != nil {
-				err = fmt.Errorf("parsing total duration: %w", err)
+			// Durations of slow stages span several words, such as
+			// "1min 24.321s (userspace)".
+			if d, ok := parseTrailingDuration(words[:idx]); ok {
+				*dest = d
+				parsed++
 			}
 		}
-		if err != nil {
-			return nil, err
+	}
+
+	if parsed == 0 {
+		return nil, fmt.Errorf("parsing %q: %w", line, ErrParseAnalyzeCommandNoStage)
+	}
+
+	for _, l := range lines[1:] {
+		if target, after, ok := parseReachedTarget(l); ok {
+			record.ReachedTarget, record.ReachedAfter = target, after
+			break
 		}
 	}
+
 	return &record, nil
 }
 
+// parseReachedTarget parses a line such as "graphical.target reached after
+// 1min 5.998s in userspace.", and reports false for any other line.
+func parseReachedTarget(line string) (string, time.Duration, bool) {
+	words := strings.Fields(line)
+	if len(words) < 4 || !strings.HasSuffix(words[0], ".target") || words[1] != "reached" || words[2] != "after" {
+		return "", 0, false
+	}
+
+	after, ok := parseLeadingDuration(words[3:])
+	if !ok {
+		return "", 0, false
+	}
+
+	return words[0], after, true
+}
+
+// parseLeadingDuration sums the durations of the leading words, up to the
+// first word which is not a duration. It reports false if the first word is
+// not a duration.
+func parseLeadingDuration(words []string) (time.Duration, bool) {
+	var total time.Duration
+	n := 0
+	for _, w := range words {
+		d, err := parseDuration([]string{w})
+		if err != nil {
+			break
+		}
+		total += d
+		n++
+	}
+	return total, n > 0
+}
+
+// parseTrailingDuration sums the durations of the trailing words, down to the
+// last word which is not a duration, such as "+" or "in". It reports false if
+// the last word is not a duration.
+func parseTrailingDuration(words []string) (time.Duration, bool) {
+	var total time.Duration
+	n := 0
+	for i := len(words) - 1; i >= 0; i-- {
+		d, err := parseDuration(words[i : i+1])
+		if err != nil {
+			break
+		}
+		total += d
+		n++
+	}
+	return total, n > 0
+}
+
 func parseDuration(words []string) (time.Duration, error) {
 	totalDuration := time.Duration(0)
 	for _, w := range words {
@@ -25,6 +25,26 @@ graphical.target reached after 13.270s in userspace.`,
 				assert.Equal(t, time.Duration(2049)*time.Millisecond, btr.Initrd, name)
 				assert.Equal(t, time.Duration(13275)*time.Millisecond, btr.Userspace, name)
 				assert.Equal(t, time.Duration(19656)*time.Millisecond, btr.Total, name)
+				assert.Equal(t, "graphical.target", btr.ReachedTarget, name)
+				assert.Equal(t, 13270*time.Millisecond, btr.ReachedAfter, name)
+			},
+		},
+		"parse multi-user target reached after a long userspace": {
+			input: `Startup finished in 716ms (kernel) + 1min 24.321s (userspace) = 1min 25.037s
+multi-user.target reached after 1min 24.102s in userspace`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, "multi-user.target", btr.ReachedTarget, name)
+				assert.Equal(t, time.Minute+24102*time.Millisecond, btr.ReachedAfter, name)
+			},
+		},
+		"parse unparsable reached target line leaves it empty": {
+			input: `Startup finished in 716ms (kernel) + 4.5s (userspace) = 5.216s
+basic.target reached after n/a in userspace.`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Empty(t, btr.ReachedTarget, name)
+				assert.Zero(t, btr.ReachedAfter, name)
 			},
 		},
 		"parse valid input successfully for long boot": {
@@ -48,11 +68,113 @@ graphical.target reached after 58.126s in userspace.`,
 				require.Nil(t, btr, name)
 			},
 		},
-		"parse input with bad duration returns error": {
+		"parse input with compound stage durations": {
+			input: `Startup finished in 1.734s (firmware) + 3.698s (loader) + 716ms (kernel) + 1.722s (initrd) + 1min 24.321s (userspace) = 1min 32.191s`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, time.Duration(1734)*time.Millisecond, btr.Firmware, name)
+				assert.Equal(t, time.Duration(1722)*time.Millisecond, btr.Initrd, name)
+				assert.Equal(t, time.Minute+time.Duration(24321)*time.Millisecond, btr.Userspace, name)
+				assert.Equal(t, time.Minute+time.Duration(32191)*time.Millisecond, btr.Total, name)
+			},
+		},
+		"parse input with hours in a stage duration": {
+			input: `Startup finished in 2.1s (kernel) + 1h 3min 2s (userspace) = 1h 3min 4.1s`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, time.Duration(2100)*time.Millisecond, btr.Kernel, name)
+				assert.Equal(t, time.Hour+3*time.Minute+2*time.Second, btr.Userspace, name)
+				assert.Equal(t, time.Hour+3*time.Minute+time.Duration(4100)*time.Millisecond, btr.Total, name)
+			},
+		},
+		"parse input without firmware and loader": {
+			input: `Startup finished in 2.3s (kernel) + 5.1s (userspace) = 7.4s
+graphical.target reached after 5.097s in userspace.`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, &BootTimeRecord{
+					Kernel:        2300 * time.Millisecond,
+					Userspace:     5100 * time.Millisecond,
+					Total:         7400 * time.Millisecond,
+					ReachedTarget: "graphical.target",
+					ReachedAfter:  5097 * time.Millisecond,
+				}, btr, name)
+			},
+		},
+		"parse input without firmware and loader but with initrd": {
+			input: `Startup finished in 1.012s (kernel) + 2.049s (initrd) + 4.523s (userspace) = 7.585s`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, &BootTimeRecord{
+					Kernel:    1012 * time.Millisecond,
+					Initrd:    2049 * time.Millisecond,
+					Userspace: 4523 * time.Millisecond,
+					Total:     7585 * time.Millisecond,
+				}, btr, name)
+			},
+		},
+		"parse input without loader": {
+			input: `Startup finished in 1.897s (firmware) + 718ms (kernel) + 13.275s (userspace) = 15.890s`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, &BootTimeRecord{
+					Firmware:  1897 * time.Millisecond,
+					Kernel:    718 * time.Millisecond,
+					Userspace: 13275 * time.Millisecond,
+					Total:     15890 * time.Millisecond,
+				}, btr, name)
+			},
+		},
+		"parse input with bad durations skips them": {
 			input: `Startup finished in potatoes (firmware) + potatoes (loader) + potatoesms (kernel) + 2.049potatoes (initrd) + 13.275s (userspace) = 19.656s
 graphical.target reached after 13.270s in userspace.`,
 			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
-				require.Error(t, err, name)
+				require.NoError(t, err, name)
+				require.NotNil(t, btr, name)
+				assert.Zero(t, btr.Firmware, name)
+				assert.Zero(t, btr.Loader, name)
+				assert.Zero(t, btr.Kernel, name)
+				assert.Zero(t, btr.Initrd, name)
+				assert.Equal(t, time.Duration(13275)*time.Millisecond, btr.Userspace, name)
+				assert.Equal(t, time.Duration(19656)*time.Millisecond, btr.Total, name)
+			},
+		},
+		"parse input with n/a stage and trailing informational line": {
+			input: `Startup finished in 1.897s (firmware) + n/a (loader) + 718ms (kernel) + 13.275s (userspace) = 15.890s
+graphical.target reached after 13.270s in userspace.
+`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, btr, name)
+				assert.Equal(t, time.Duration(1897)*time.Millisecond, btr.Firmware, name)
+				assert.Zero(t, btr.Loader, name)
+				assert.Equal(t, time.Duration(718)*time.Millisecond, btr.Kernel, name)
+				assert.Equal(t, time.Duration(13275)*time.Millisecond, btr.Userspace, name)
+				assert.Equal(t, time.Duration(15890)*time.Millisecond, btr.Total, name)
+			},
+		},
+		"parse input with infinite stage and text after total": {
+			input: `Startup finished in ∞ (firmware) + 718ms (kernel) + 13.275s (userspace) = 13.993s (approximately)`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.NoError(t, err, name)
+				require.NotNil(t, btr, name)
+				assert.Zero(t, btr.Firmware, name)
+				assert.Equal(t, time.Duration(718)*time.Millisecond, btr.Kernel, name)
+				assert.Equal(t, time.Duration(13993)*time.Millisecond, btr.Total, name)
+			},
+		},
+		"parse input without any duration returns error": {
+			input: `Startup finished in potatoes (firmware) + n/a (loader) + potatoesms (kernel) = potatoes
+graphical.target reached after 13.270s in userspace.`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.ErrorIs(t, err, ErrParseAnalyzeCommandNoStage, name)
+				require.Nil(t, btr, name)
+			},
+		},
+		"parse informational output returns error": {
+			input: `Bootup is not yet finished (org.freedesktop.systemd1.Manager.FinishTimestampMonotonic=0).`,
+			validate: func(t *testing.T, btr *BootTimeRecord, err error, name string) {
+				require.ErrorIs(t, err, ErrParseAnalyzeCommandNoStage, name)
 				require.Nil(t, btr, name)
 			},
 		},
@@ -66,3 +188,132 @@ graphical.target reached after 13.270s in userspace.`,
 		})
 	}
 }
+
+func TestBootTimeRecordFromTimestamps(t *testing.T) {
+	tcs := map[string]struct {
+		ts       MonotonicTimestamps
+		validate func(t *testing.T, btr *BootTimeRecord, err error)
+	}{
+		"total is the sum of the stages": {
+			// The timestamps of the boot printed by systemd-analyze as
+			// 1.897s (firmware) + 1.715s (loader) + 718ms (kernel) +
+			// 2.049s (initrd) + 13.275s (userspace).
+			ts: MonotonicTimestamps{
+				Firmware:  3_612_000,
+				Loader:    1_715_000,
+				InitRD:    718_000,
+				Userspace: 2_767_000,
+				Finish:    16_042_000,
+			},
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &BootTimeRecord{
+					Firmware:  1897 * time.Millisecond,
+					Loader:    1715 * time.Millisecond,
+					Kernel:    718 * time.Millisecond,
+					Initrd:    2049 * time.Millisecond,
+					Userspace: 13275 * time.Millisecond,
+					Total:     19654 * time.Millisecond,
+				}, btr)
+				assert.Equal(t, btr.Firmware+btr.Loader+btr.Kernel+btr.Initrd+btr.Userspace, btr.Total)
+			},
+		},
+		"total without firmware timestamp is the finish timestamp": {
+			ts: MonotonicTimestamps{
+				Userspace: 900_000,
+				Finish:    4_000_000,
+			},
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, &BootTimeRecord{
+					Kernel:    900 * time.Millisecond,
+					Userspace: 3100 * time.Millisecond,
+					Total:     4 * time.Second,
+				}, btr)
+			},
+		},
+		"generators and units load": {
+			ts: MonotonicTimestamps{
+				Userspace:        900_000,
+				Finish:           4_000_000,
+				GeneratorsStart:  1_000_000,
+				GeneratorsFinish: 1_045_000,
+				UnitsLoadStart:   1_046_000,
+				UnitsLoadFinish:  1_190_000,
+			},
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 45*time.Millisecond, btr.Generators)
+				assert.Equal(t, 144*time.Millisecond, btr.UnitsLoad)
+				assert.Equal(t, 3100*time.Millisecond, btr.Userspace)
+			},
+		},
+		"missing units load finish is zero": {
+			ts: MonotonicTimestamps{
+				Userspace:      900_000,
+				Finish:         4_000_000,
+				UnitsLoadStart: 1_046_000,
+			},
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.NoError(t, err)
+				assert.Zero(t, btr.UnitsLoad)
+				assert.Zero(t, btr.Generators)
+			},
+		},
+		"unfinished boot returns error": {
+			ts: MonotonicTimestamps{
+				Firmware:  3_612_000,
+				Userspace: 900_000,
+			},
+			validate: func(t *testing.T, btr *BootTimeRecord, err error) {
+				require.ErrorContains(t, err, "not yet finished")
+				assert.Nil(t, btr)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			btr, err := BootTimeRecordFromTimestamps(tc.ts)
+			tc.validate(t, btr, err)
+		})
+	}
+}
+
+func TestParseAnalyzeScope(t *testing.T) {
+	tcs := map[string]struct {
+		input    string
+		validate func(t *testing.T, scope AnalyzeScope, err error, name string)
+	}{
+		"system": {
+			input: "system",
+			validate: func(t *testing.T, scope AnalyzeScope, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, AnalyzeScopeSystem, scope, name)
+			},
+		},
+		"user": {
+			input: "user",
+			validate: func(t *testing.T, scope AnalyzeScope, err error, name string) {
+				require.NoError(t, err, name)
+				assert.Equal(t, AnalyzeScopeUser, scope, name)
+			},
+		},
+		"unknown scope returns error": {
+			input: "global",
+			validate: func(t *testing.T, scope AnalyzeScope, err error, name string) {
+				require.Error(t, err, name)
+				assert.Empty(t, scope, name)
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			scope, err := ParseAnalyzeScope(tc.input)
+			tc.validate(t, scope, err, name)
+		})
+	}
+}